@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// accessTokenScopes are the scopes GitLab accepts for project and group access tokens
+var accessTokenScopes = []string{
+	"api",
+	"read_api",
+	"read_registry",
+	"write_registry",
+	"read_repository",
+	"write_repository",
+	"create_runner",
+	"manage_runner",
+	"ai_features",
+	"k8s_proxy",
+}
+
+// accessTokenLevels are the roles an access token can be given, 0 leaves it to GitLab
+var accessTokenLevels = []int{0, 10, 15, 20, 30, 40, 50}
+
+var tokenName string
+var tokenScopes string
+var tokenExpiresAt string
+var tokenAccessLevel int
+
+// createdAccessToken is the subset of the access token API response that is reported
+type createdAccessToken struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expires_at"`
+	AccessLevel int      `json:"access_level"`
+	Token       string   `json:"token"`
+}
+
+// setAccessTokenCmd creates a project or group access token
+var setAccessTokenCmd = &cobra.Command{
+	Use:   "access-token",
+	Short: "Create a project or group access token",
+	Long: `Create a project (--destination-project) or group (--destination-group)
+access token on the destination instance, or on the source with --source.
+Access tokens can't be copied between instances, so this creates a fresh one,
+e.g. for CI after a migration.
+
+--scopes is a comma-separated list of: ` + strings.Join(accessTokenScopes, ", ") + `.
+--expires-at is a date (YYYY-MM-DD); when omitted GitLab applies its default.
+
+The token value is printed once and is never written to disk, store it in a
+secret manager or CI variable right away.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (destinationProjectID != "" && destinationGroupID != "") || (destinationProjectID == "" && destinationGroupID == "") {
+			log.Println("Error: Either --destination-project or --destination-group must be provided.")
+			return
+		}
+
+		payload, err := accessTokenPayload(tokenName, tokenScopes, tokenExpiresAt, tokenAccessLevel)
+		if err != nil {
+			log.Println("Error:", err)
+			return
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			log.Println("Error:", err)
+			return
+		}
+
+		baseURL := config.DestinationBaseURL
+		accessToken := config.DestinationAccessToken
+		if isSource {
+			baseURL = config.SourceBaseURL
+			accessToken = config.SourceAccessToken
+		}
+
+		target := "project " + destinationProjectID
+		tokensURL := fmt.Sprintf("%s/api/v4/projects/%s/access_tokens", baseURL, url.PathEscape(destinationProjectID))
+		if destinationGroupID != "" {
+			target = "group " + destinationGroupID
+			tokensURL = fmt.Sprintf("%s/api/v4/groups/%s/access_tokens", baseURL, url.PathEscape(destinationGroupID))
+		}
+
+		token, err := createAccessToken(tokensURL, accessToken, payload)
+		if err != nil {
+			log.Printf("Error creating access token for %s: %v", target, err)
+			return
+		}
+
+		expires := token.ExpiresAt
+		if expires == "" {
+			expires = "never"
+		}
+		fmt.Printf("Created access token %q (ID: %d) for %s\n", token.Name, token.ID, target)
+		fmt.Printf("  scopes: %s, access level: %d, expires: %s\n", strings.Join(token.Scopes, ", "), token.AccessLevel, expires)
+		fmt.Printf("  token: %s\n", token.Token)
+		fmt.Println("WARNING: this is the only time the token is shown and it was not saved anywhere, store it now")
+	},
+}
+
+// accessTokenPayload validates the access token flags and builds the request body
+func accessTokenPayload(name, scopeList, expiresAt string, accessLevel int) (map[string]interface{}, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("--name is required")
+	}
+
+	scopes := utils.ParseColumns(scopeList)
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("--scopes is required (supported: %s)", strings.Join(accessTokenScopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(accessTokenScopes, scope) {
+			return nil, fmt.Errorf("unsupported scope %q (supported: %s)", scope, strings.Join(accessTokenScopes, ", "))
+		}
+	}
+
+	if !slices.Contains(accessTokenLevels, accessLevel) {
+		return nil, fmt.Errorf("unsupported --access-level %d (use 10, 15, 20, 30, 40 or 50)", accessLevel)
+	}
+
+	payload := map[string]interface{}{
+		"name":   name,
+		"scopes": scopes,
+	}
+	if expiresAt != "" {
+		expires, err := time.Parse(time.DateOnly, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --expires-at %q, use YYYY-MM-DD", expiresAt)
+		}
+		if !expires.After(time.Now()) {
+			return nil, fmt.Errorf("--expires-at %s is not in the future", expiresAt)
+		}
+		payload["expires_at"] = expiresAt
+	}
+	if accessLevel != 0 {
+		payload["access_level"] = accessLevel
+	}
+	return payload, nil
+}
+
+// createAccessToken POSTs the access token payload to tokensURL and returns the created token
+func createAccessToken(tokensURL, accessToken string, payload map[string]interface{}) (*createdAccessToken, error) {
+	var token createdAccessToken
+	if err := postGitLabJSON(tokensURL, accessToken, payload, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func init() {
+	setAccessTokenCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "The project ID to create the access token for")
+	setAccessTokenCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "The group ID to create the access token for")
+	setAccessTokenCmd.Flags().BoolVarP(&isSource, "source", "s", false, "Create the token on the source instance instead of the destination instance")
+	setAccessTokenCmd.Flags().StringVar(&tokenName, "name", "", "Name of the access token")
+	setAccessTokenCmd.Flags().StringVar(&tokenScopes, "scopes", "", "Comma-separated token scopes, e.g. api,read_repository")
+	setAccessTokenCmd.Flags().StringVar(&tokenExpiresAt, "expires-at", "", "Expiry date of the token (YYYY-MM-DD)")
+	setAccessTokenCmd.Flags().IntVar(&tokenAccessLevel, "access-level", 0, "Role of the token: 10 guest, 15 planner, 20 reporter, 30 developer, 40 maintainer, 50 owner (default: GitLab's)")
+
+	setCmd.AddCommand(setAccessTokenCmd)
+}
@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// applyResources are the resource types a plan step can migrate, each by the
+// migrate subcommand of the same name
+var applyResources = []string{
+	"group-settings", "labels", "milestones", "members", "protected-branches",
+	"approval-rules", "variables", "webhooks", "pipeline-schedules",
+}
+
+// applyTargetFlags are the flags a step's source and destination are passed
+// with; they and --dry-run can't be given in the flags of a step
+var applyTargetFlags = []string{"group", "project", "destination-group", "destination-project", "dry-run"}
+
+var applyOnly string
+var applySkip string
+var applyDryRun bool
+
+// applyTarget is the source and destination of a step, a group or project each
+type applyTarget struct {
+	SourceGroup        string `yaml:"source_group,omitempty"`
+	SourceProject      string `yaml:"source_project,omitempty"`
+	DestinationGroup   string `yaml:"destination_group,omitempty"`
+	DestinationProject string `yaml:"destination_project,omitempty"`
+}
+
+// applyStep is one migrate command of a plan
+type applyStep struct {
+	Resource    string `yaml:"resource"`
+	applyTarget `yaml:",inline"`
+	// Flags are further flags of the migrate command, e.g. on-conflict: update
+	Flags map[string]string `yaml:"flags,omitempty"`
+}
+
+// applyPlan is the content of a plan file. Its source and destination apply to
+// every step that doesn't name its own.
+type applyPlan struct {
+	applyTarget `yaml:",inline"`
+	Steps       []applyStep `yaml:"steps"`
+}
+
+// applyCmd runs the migrate commands listed in a plan file
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan.yaml>",
+	Short: "Run the migrate commands listed in a plan file",
+	Long: `Run the migrate commands listed in a YAML plan file, one step after the other.
+Each step names a resource type to migrate, the migrate subcommand of the same
+name: ` + strings.Join(applyResources, ", ") + `.
+
+The source and destination at the top of the plan are used by every step that
+doesn't set its own. Further flags of a migrate command go under flags:
+
+  source_group: "12"
+  destination_group: new-org
+  steps:
+    - resource: labels
+    - resource: variables
+      flags:
+        recursive: "true"
+        on-conflict: update
+    - resource: webhooks
+      source_project: "34"
+      destination_project: new-org/app
+      flags:
+        token: s3cret
+
+The whole plan is checked before the first step runs. A failed step doesn't
+stop the ones after it, except for a rejected token; the failed resource types
+are listed at the end, so they can be run again with --only.
+
+--only and --skip run a subset of the steps without editing the plan, given as
+comma-separated resource types, e.g. --only variables,labels or --skip
+webhooks. --dry-run runs every selected step with its --dry-run, reporting
+what would change without changing anything.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		selected, err := parseResourceSelection(applyOnly, applySkip)
+		if err != nil {
+			return err
+		}
+
+		plan, err := readApplyPlan(args[0])
+		if err != nil {
+			return err
+		}
+
+		var steps []int
+		for i, step := range plan.Steps {
+			if selected(step.Resource) {
+				steps = append(steps, i)
+			} else {
+				log.Printf("Skipping step %d (%s), it is not selected", i+1, step.Resource)
+			}
+		}
+		if len(steps) == 0 {
+			return fmt.Errorf("no step of %s is selected by --only or --skip", args[0])
+		}
+
+		var failed []string
+		for n, i := range steps {
+			step := plan.Steps[i]
+			log.Printf("Step %d (%d of %d): migrate %s", i+1, n+1, len(steps), step.Resource)
+			if err := runApplyStep(step, plan.applyTarget.merge(step.applyTarget)); err != nil {
+				if errors.Is(err, utils.ErrUnauthorized) {
+					return err
+				}
+				log.Printf("Error: step %d (%s) failed: %v", i+1, step.Resource, err)
+				if !slices.Contains(failed, step.Resource) {
+					failed = append(failed, step.Resource)
+				}
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("%d of %d steps failed, run them again with --only %s", len(failed), len(steps), strings.Join(failed, ","))
+		}
+		log.Printf("Applied %d steps of %s", len(steps), args[0])
+		return nil
+	},
+}
+
+// parseResourceSelection checks the --only and --skip resource types and
+// returns whether a step of the given type is to be run
+func parseResourceSelection(only, skip string) (func(resource string) bool, error) {
+	if only != "" && skip != "" {
+		return nil, fmt.Errorf("--only and --skip can't be combined")
+	}
+	flag, names := "--only", utils.ParseColumns(only)
+	if only == "" {
+		flag, names = "--skip", utils.ParseColumns(skip)
+	}
+	for _, name := range names {
+		if !slices.Contains(applyResources, name) {
+			return nil, fmt.Errorf("%s: unknown resource type %q (known: %s)", flag, name, strings.Join(applyResources, ", "))
+		}
+	}
+
+	return func(resource string) bool {
+		if only != "" {
+			return slices.Contains(names, resource)
+		}
+		return !slices.Contains(names, resource)
+	}, nil
+}
+
+// readApplyPlan reads a plan file and checks every step against the migrate
+// command it runs
+func readApplyPlan(path string) (*applyPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	var plan applyPlan
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&plan); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid plan %s: %v", path, err)
+	}
+	if len(plan.Steps) == 0 {
+		return nil, fmt.Errorf("plan %s has no steps", path)
+	}
+
+	for i, step := range plan.Steps {
+		if err := checkApplyStep(step, plan.applyTarget.merge(step.applyTarget)); err != nil {
+			return nil, fmt.Errorf("plan %s, step %d: %v", path, i+1, err)
+		}
+	}
+	return &plan, nil
+}
+
+// checkApplyStep reports a step whose resource type is unknown, or that passes
+// flags its migrate command doesn't have
+func checkApplyStep(step applyStep, target applyTarget) error {
+	if !slices.Contains(applyResources, step.Resource) {
+		return fmt.Errorf("unknown resource type %q (known: %s)", step.Resource, strings.Join(applyResources, ", "))
+	}
+	cmd := migrateSubcommand(step.Resource)
+
+	for flag, value := range target.flags() {
+		if value != "" && cmd.Flags().Lookup(flag) == nil {
+			return fmt.Errorf("migrate %s has no --%s", step.Resource, flag)
+		}
+	}
+	for flag := range step.Flags {
+		if slices.Contains(applyTargetFlags, flag) {
+			return fmt.Errorf("--%s can't be set in flags, use the source and destination fields or apply --dry-run", flag)
+		}
+		if cmd.Flags().Lookup(flag) == nil {
+			return fmt.Errorf("migrate %s has no --%s", step.Resource, flag)
+		}
+	}
+	return nil
+}
+
+// merge returns the target with the source and destination a step sets
+// replacing those of the plan
+func (t applyTarget) merge(step applyTarget) applyTarget {
+	if step.SourceGroup != "" || step.SourceProject != "" {
+		t.SourceGroup, t.SourceProject = step.SourceGroup, step.SourceProject
+	}
+	if step.DestinationGroup != "" || step.DestinationProject != "" {
+		t.DestinationGroup, t.DestinationProject = step.DestinationGroup, step.DestinationProject
+	}
+	return t
+}
+
+// flags returns the target as the flags of a migrate command
+func (t applyTarget) flags() map[string]string {
+	return map[string]string{
+		"group":               t.SourceGroup,
+		"project":             t.SourceProject,
+		"destination-group":   t.DestinationGroup,
+		"destination-project": t.DestinationProject,
+	}
+}
+
+// migrateSubcommand returns the migrate subcommand for a resource type
+func migrateSubcommand(resource string) *cobra.Command {
+	for _, cmd := range migrateCmd.Commands() {
+		if cmd.Name() == resource {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// runApplyStep runs the migrate command of a step with its flags set, and
+// resets them afterwards so they don't carry over to the next step
+func runApplyStep(step applyStep, target applyTarget) error {
+	cmd := migrateSubcommand(step.Resource)
+	defer resetFlagSet(cmd.Flags())
+
+	flags := target.flags()
+	for flag, value := range step.Flags {
+		flags[flag] = value
+	}
+	if applyDryRun {
+		flags["dry-run"] = "true"
+	}
+	for flag, value := range flags {
+		if value == "" {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			return fmt.Errorf("invalid --%s %q: %v", flag, value, err)
+		}
+	}
+	return cmd.RunE(cmd, nil)
+}
+
+// resetFlagSet sets the flags that were changed back to their defaults
+func resetFlagSet(flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		// Setting a slice flag to its "[]" default would add "[]" as a value
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+func init() {
+	applyCmd.Flags().StringVar(&applyOnly, "only", "", "Comma-separated resource types to run the steps of, e.g. variables,labels")
+	applyCmd.Flags().StringVar(&applySkip, "skip", "", "Comma-separated resource types whose steps are left out, e.g. webhooks")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Run every selected step with --dry-run, without changing the destination")
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writePlan writes a plan file to a temporary directory and returns its path
+func writePlan(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseResourceSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		only, skip string
+		want       string
+		wantErr    string
+	}{
+		{"everything", "", "", "labels variables webhooks", ""},
+		{"only", "variables, labels", "", "labels variables", ""},
+		{"skip", "", "webhooks", "labels variables", ""},
+		{"unknown type", "variables,lables", "", "", `--only: unknown resource type "lables"`},
+		{"unknown skipped type", "", "hooks", "", `--skip: unknown resource type "hooks"`},
+		{"both", "labels", "webhooks", "", "--only and --skip can't be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := parseResourceSelection(tt.only, tt.skip)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseResourceSelection: %v", err)
+			}
+			var got []string
+			for _, resource := range []string{"labels", "variables", "webhooks"} {
+				if selected(resource) {
+					got = append(got, resource)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("selected %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadApplyPlanChecksSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    string
+		wantErr string
+	}{
+		{"unknown resource", "steps:\n  - resource: issues\n", `step 1: unknown resource type "issues"`},
+		{"unknown field", "steps:\n  - resource: labels\n    source_projet: \"1\"\n", "field source_projet not found"},
+		{"flag of another command", "steps:\n  - resource: labels\n    flags:\n      token: s3cret\n", "step 1: migrate labels has no --token"},
+		{"target in flags", "steps:\n  - resource: labels\n    flags:\n      project: \"1\"\n", "step 1: --project can't be set in flags"},
+		{"group source for a project resource", "source_group: \"1\"\nsteps:\n  - resource: labels\n  - resource: webhooks\n", "step 2: migrate webhooks has no --group"},
+		{"no steps", "source_group: \"1\"\n", "has no steps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readApplyPlan(writePlan(t, tt.plan))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	plan, err := readApplyPlan(writePlan(t, "source_group: \"1\"\nsteps:\n  - resource: webhooks\n    source_project: \"3\"\n    destination_project: \"4\"\n"))
+	if err != nil {
+		t.Fatalf("readApplyPlan: %v", err)
+	}
+	if got := plan.applyTarget.merge(plan.Steps[0].applyTarget); got.SourceGroup != "" || got.SourceProject != "3" {
+		t.Errorf("step target = %+v, want the step's own source project", got)
+	}
+}
+
+// applyServer serves the labels, milestones and webhooks of projects 1 and 2,
+// recording the requests. Writing milestones fails when failMilestones is set.
+func applyServer(t *testing.T, failMilestones bool) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/1/labels":
+			fmt.Fprint(w, `[{"name":"bug","color":"#ff0000"}]`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/1/milestones":
+			fmt.Fprint(w, `[{"id":1,"title":"v1","state":"active"}]`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/1/hooks":
+			fmt.Fprint(w, `[{"id":1,"url":"https://ci.example.com/hook"}]`)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `[]`)
+		case failMilestones && strings.HasSuffix(r.URL.Path, "/milestones"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":10}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, requests...)
+	}
+}
+
+const testPlan = `source_project: "1"
+destination_project: "2"
+steps:
+  - resource: labels
+  - resource: milestones
+  - resource: webhooks
+    flags:
+      token: s3cret
+`
+
+func TestApplyRunsSelectedSteps(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantWrites []string
+		wantNoGet  string
+	}{
+		{"all steps", nil, []string{"POST /api/v4/projects/2/labels", "POST /api/v4/projects/2/milestones", "POST /api/v4/projects/2/hooks"}, ""},
+		{"only", []string{"--only", "labels,milestones"}, []string{"POST /api/v4/projects/2/labels", "POST /api/v4/projects/2/milestones"}, "/hooks"},
+		{"skip", []string{"--skip", "labels"}, []string{"POST /api/v4/projects/2/milestones", "POST /api/v4/projects/2/hooks"}, "/labels"},
+		{"dry run", []string{"--skip", "webhooks", "--dry-run"}, nil, "/hooks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := applyServer(t, false)
+			args := append([]string{"-c", writeTestConfig(t, server.URL), "-q", "apply", writePlan(t, testPlan)}, tt.args...)
+			if err := executeCommand(t, args...); err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+
+			var writes []string
+			for _, request := range requests() {
+				if !strings.HasPrefix(request, "GET ") {
+					writes = append(writes, request)
+				}
+				if tt.wantNoGet != "" && strings.HasSuffix(request, tt.wantNoGet) {
+					t.Errorf("request %s made for a step that isn't selected", request)
+				}
+			}
+			if strings.Join(writes, ", ") != strings.Join(tt.wantWrites, ", ") {
+				t.Errorf("writes = %v, want %v", writes, tt.wantWrites)
+			}
+		})
+	}
+}
+
+func TestApplyReportsFailedSteps(t *testing.T) {
+	server, requests := applyServer(t, true)
+
+	err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "apply", writePlan(t, testPlan))
+	if want := "1 of 3 steps failed, run them again with --only milestones"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+	// The steps after the failed one still run
+	found := false
+	for _, request := range requests() {
+		found = found || request == "POST /api/v4/projects/2/hooks"
+	}
+	if !found {
+		t.Error("the webhooks step didn't run after the milestones step failed")
+	}
+}
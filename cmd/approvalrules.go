@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// approvalRuleColumns are the default table columns for approval rules
+var approvalRuleColumns = []string{"id", "name", "rule_type", "approvals_required"}
+
+var approvalRulesDryRun bool
+
+// approvalRule is a project merge request approval rule as returned by the API
+type approvalRule struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	RuleType          string `json:"rule_type"`
+	ApprovalsRequired int    `json:"approvals_required"`
+	Users             []struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"users"`
+	Groups []struct {
+		ID       int64  `json:"id"`
+		FullPath string `json:"full_path"`
+	} `json:"groups"`
+	ProtectedBranches []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"protected_branches"`
+}
+
+// approvalRulePayload creates an approval rule on the destination
+type approvalRulePayload struct {
+	Name               string  `json:"name"`
+	ApprovalsRequired  int     `json:"approvals_required"`
+	RuleType           string  `json:"rule_type,omitempty"`
+	UserIDs            []int64 `json:"user_ids,omitempty"`
+	GroupIDs           []int64 `json:"group_ids,omitempty"`
+	ProtectedBranchIDs []int64 `json:"protected_branch_ids,omitempty"`
+}
+
+// getApprovalRulesCmd lists the approval rules of a project
+var getApprovalRulesCmd = &cobra.Command{
+	Use:   "approval-rules",
+	Short: "Retrieve the merge request approval rules of a GitLab project",
+	Long: `Retrieve the merge request approval rules of a project (-p) with their
+eligible approvers and protected branches.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if projectID == "" {
+			return fmt.Errorf("--project must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+		if isDestination {
+			baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+		}
+		_, collectionURL := namespacedEndpoint(baseURL, "", projectID, "approval_rules")
+
+		rules, err := fetchApprovalRules(collectionURL, accessToken)
+		if err != nil {
+			return notFoundError(err, "project", projectID)
+		}
+
+		if outputFormat == outputFormatTable {
+			return printTable(rules, approvalRuleColumns, "")
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("approval-rules", "", projectID, isDestination, false)
+		}
+
+		if err := saveOutputToFile(rules, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// migrateApprovalRulesCmd copies approval rules between projects
+var migrateApprovalRulesCmd = &cobra.Command{
+	Use:   "approval-rules",
+	Short: "Migrate merge request approval rules between GitLab projects",
+	Long: `Copy the merge request approval rules of a source project (-p) to a
+destination project (-P), keeping their name, the number of approvals
+required, the eligible users and groups and the protected branches they
+apply to.
+
+User, group and branch IDs differ between instances, so they are looked up on
+the destination by username, group path and branch name. Those that can't be
+found are left out of the rule with a warning; a rule none of whose protected
+branches exist on the destination fails instead of applying to every branch,
+so migrate protected branches first.
+
+Rules whose name already exists on the destination are skipped, as are the
+code owner and report rules GitLab manages itself. Use --dry-run to list what
+would be created without changing the destination.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if projectID == "" || destinationProjectID == "" {
+			return fmt.Errorf("provide a source project (-p) and a destination project (--destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migrateApprovalRules(config)
+	},
+}
+
+// fetchApprovalRules lists the approval rules of a project
+func fetchApprovalRules(collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	rules, err := newGitLabClient("", accessToken).GetPaginated(collectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching approval rules: %w", err)
+	}
+	return rules, nil
+}
+
+// decodeApprovalRules reads approval rules listed by the API
+func decodeApprovalRules(rules []map[string]interface{}) ([]approvalRule, error) {
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	var decoded []approvalRule
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("approval rules are not in the expected format: %v", err)
+	}
+	return decoded, nil
+}
+
+// remapApprovalRule returns the payload to create rule on the destination,
+// with the destination IDs of its users, groups and protected branches.
+// missing lists those that have no match there.
+func (m *idMapper) remapApprovalRule(rule approvalRule) (payload approvalRulePayload, missing []string, err error) {
+	payload = approvalRulePayload{Name: rule.Name, ApprovalsRequired: rule.ApprovalsRequired}
+	if rule.RuleType == "any_approver" {
+		payload.RuleType = rule.RuleType
+	}
+
+	for _, user := range rule.Users {
+		id, err := m.userID(user.Username)
+		if err != nil {
+			return payload, nil, err
+		}
+		if id == 0 {
+			missing = append(missing, "user "+user.Username)
+			continue
+		}
+		payload.UserIDs = append(payload.UserIDs, id)
+	}
+	for _, group := range rule.Groups {
+		id, err := m.groupID(group.FullPath)
+		if err != nil {
+			return payload, nil, err
+		}
+		if id == 0 {
+			missing = append(missing, "group "+group.FullPath)
+			continue
+		}
+		payload.GroupIDs = append(payload.GroupIDs, id)
+	}
+	for _, branch := range rule.ProtectedBranches {
+		id, err := m.branchID(branch.Name)
+		if err != nil {
+			return payload, nil, err
+		}
+		if id == 0 {
+			missing = append(missing, "protected branch "+branch.Name)
+			continue
+		}
+		payload.ProtectedBranchIDs = append(payload.ProtectedBranchIDs, id)
+	}
+	return payload, missing, nil
+}
+
+// migrateApprovalRules creates the source approval rules whose name is missing
+// on the destination
+func migrateApprovalRules(config *utils.Config) error {
+	sourceTarget, sourceURL := namespacedEndpoint(config.SourceBaseURL, "", projectID, "approval_rules")
+	destinationTarget, destinationURL := namespacedEndpoint(config.DestinationBaseURL, "", destinationProjectID, "approval_rules")
+	_, protectedBranchesURL := namespacedEndpoint(config.DestinationBaseURL, "", destinationProjectID, "protected_branches")
+
+	listed, err := fetchApprovalRules(sourceURL, config.SourceAccessToken)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	sourceRules, err := decodeApprovalRules(listed)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	destinationRules, err := fetchApprovalRules(destinationURL, config.DestinationAccessToken)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]bool, len(destinationRules))
+	for _, rule := range destinationRules {
+		name, _ := rule["name"].(string)
+		existing[name] = true
+	}
+
+	prefix := ""
+	if approvalRulesDryRun {
+		prefix = "[dry run] "
+	}
+
+	mapper := newIDMapper(config.DestinationBaseURL, config.DestinationAccessToken, protectedBranchesURL)
+	log.Printf("Migrating %d approval rules from %s to %s", len(sourceRules), sourceTarget, destinationTarget)
+	created, skipped, failed := 0, 0, 0
+	for _, rule := range sourceRules {
+		if rule.RuleType == "code_owner" || rule.RuleType == "report_approver" {
+			fmt.Printf("skipped: approval rule %s (%s rules are managed by GitLab)\n", rule.Name, rule.RuleType)
+			skipped++
+			continue
+		}
+		if existing[rule.Name] {
+			fmt.Printf("skipped: approval rule %s (already exists)\n", rule.Name)
+			skipped++
+			continue
+		}
+
+		payload, missing, err := mapper.remapApprovalRule(rule)
+		if err != nil {
+			if errors.Is(err, utils.ErrUnauthorized) {
+				return err
+			}
+			fmt.Printf("failed: approval rule %s: %v\n", rule.Name, err)
+			failed++
+			continue
+		}
+		if len(missing) > 0 {
+			log.Printf("Warning: approval rule %s: no match on the destination for %s, left out of the rule", rule.Name, strings.Join(missing, ", "))
+		}
+		if len(rule.ProtectedBranches) > 0 && len(payload.ProtectedBranchIDs) == 0 {
+			fmt.Printf("failed: approval rule %s: none of its protected branches exist on the destination\n", rule.Name)
+			failed++
+			continue
+		}
+
+		if !approvalRulesDryRun {
+			if err := postGitLabJSON(destinationURL, config.DestinationAccessToken, payload, nil); err != nil {
+				if errors.Is(err, utils.ErrUnauthorized) {
+					return err
+				}
+				fmt.Printf("failed: approval rule %s: %v\n", rule.Name, err)
+				failed++
+				continue
+			}
+			existing[rule.Name] = true
+		}
+		fmt.Printf("%screated: approval rule %s (%d approvals, %d users, %d groups)\n", prefix, rule.Name, payload.ApprovalsRequired, len(payload.UserIDs), len(payload.GroupIDs))
+		created++
+	}
+
+	if approvalRulesDryRun {
+		log.Printf("Dry run: %d approval rules would be created, %d skipped, %d failed", created, skipped, failed)
+		return nil
+	}
+	log.Printf("Created %d approval rules, skipped %d, %d failed", created, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d approval rules could not be created", failed)
+	}
+	return nil
+}
+
+func init() {
+	getApprovalRulesCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to list the approval rules of")
+	getCmd.AddCommand(getApprovalRulesCmd)
+
+	migrateApprovalRulesCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateApprovalRulesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migrateApprovalRulesCmd.Flags().BoolVar(&approvalRulesDryRun, "dry-run", false, "List the approval rules that would be created without changing the destination")
+	migrateCmd.AddCommand(migrateApprovalRulesCmd)
+}
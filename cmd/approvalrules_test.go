@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// approvalRulesFixture is a source project's approval rules as the API lists them
+const approvalRulesFixture = `[
+	{
+		"id": 101,
+		"name": "Backend",
+		"rule_type": "regular",
+		"approvals_required": 2,
+		"users": [
+			{"id": 11, "username": "alice"},
+			{"id": 12, "username": "bob"},
+			{"id": 13, "username": "carol"}
+		],
+		"groups": [
+			{"id": 21, "full_path": "acme/backend"},
+			{"id": 22, "full_path": "acme/gone"}
+		],
+		"protected_branches": [
+			{"id": 31, "name": "main"},
+			{"id": 32, "name": "release/*"}
+		]
+	},
+	{
+		"id": 102,
+		"name": "All Members",
+		"rule_type": "any_approver",
+		"approvals_required": 1,
+		"users": [],
+		"groups": [],
+		"protected_branches": []
+	}
+]`
+
+func TestRemapApprovalRule(t *testing.T) {
+	var listed []map[string]interface{}
+	if err := json.Unmarshal([]byte(approvalRulesFixture), &listed); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := decodeApprovalRules(listed)
+	if err != nil {
+		t.Fatalf("decodeApprovalRules: %v", err)
+	}
+
+	// Every lookup is answered from the mapper's cache, 0 meaning no match
+	mapper := newIDMapper("http://gitlab.invalid", "dsttoken", "")
+	mapper.users = map[string]int64{"alice": 511, "bob": 0, "carol": 513}
+	mapper.groups = map[string]int64{"acme/backend": 521, "acme/gone": 0}
+	mapper.branches = map[string]int64{"main": 531}
+
+	payload, missing, err := mapper.remapApprovalRule(rules[0])
+	if err != nil {
+		t.Fatalf("remapApprovalRule: %v", err)
+	}
+	want := approvalRulePayload{
+		Name:               "Backend",
+		ApprovalsRequired:  2,
+		UserIDs:            []int64{511, 513},
+		GroupIDs:           []int64{521},
+		ProtectedBranchIDs: []int64{531},
+	}
+	if !reflect.DeepEqual(payload, want) {
+		t.Errorf("payload = %+v, want %+v", payload, want)
+	}
+	wantMissing := []string{"user bob", "group acme/gone", "protected branch release/*"}
+	if !reflect.DeepEqual(missing, wantMissing) {
+		t.Errorf("missing = %v, want %v", missing, wantMissing)
+	}
+
+	payload, missing, err = mapper.remapApprovalRule(rules[1])
+	if err != nil {
+		t.Fatalf("remapApprovalRule: %v", err)
+	}
+	want = approvalRulePayload{Name: "All Members", ApprovalsRequired: 1, RuleType: "any_approver"}
+	if !reflect.DeepEqual(payload, want) || len(missing) != 0 {
+		t.Errorf("payload = %+v, missing %v, want %+v and nothing missing", payload, missing, want)
+	}
+}
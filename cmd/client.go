@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+var requestTimeout time.Duration
+var maxRetries int
+var maxIdleConns int
+var maxConnsPerHost int
+var dialTimeout time.Duration
+var responseHeaderTimeout time.Duration
+var retryBaseDelay time.Duration
+var retryMaxDelay time.Duration
+var customHeaders headerFlag
+var noCache bool
+var insecureFlag bool
+
+// insecureSkipTLSVerify is the resolved TLS verification setting: the config's
+// insecure_skip_tls_verify, overridden by --insecure. It is set by loadConfig.
+var insecureSkipTLSVerify bool
+
+// listCache holds project lists fetched during this run, see paginateCached
+var listCache = utils.NewListCache()
+
+// headerFlag is a repeatable --header "Name: Value" flag. Values are validated
+// as they are parsed and never printed when they look like secrets.
+type headerFlag struct {
+	headers http.Header
+	display []string
+}
+
+// Set implements pflag.Value
+func (f *headerFlag) Set(value string) error {
+	name, headerValue, err := utils.ParseHeader(value)
+	if err != nil {
+		return err
+	}
+	if f.headers == nil {
+		f.headers = make(http.Header)
+	}
+	f.headers.Add(name, headerValue)
+	f.display = append(f.display, utils.RedactHeader(name, headerValue))
+	return nil
+}
+
+// String implements pflag.Value
+func (f *headerFlag) String() string {
+	if len(f.display) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(f.display, ", ") + "]"
+}
+
+// Type implements pflag.Value
+func (f *headerFlag) Type() string {
+	return "stringArray"
+}
+
+// newHTTPClientConfig returns the HTTP client configuration shared by all
+// commands, with the connection tuning and header flags applied
+func newHTTPClientConfig() *utils.HTTPClientConfig {
+	httpConfig := utils.NewDefaultConfig()
+	httpConfig.Timeout = requestTimeout
+	httpConfig.MaxIdleConns = maxIdleConns
+	httpConfig.MaxConnsPerHost = maxConnsPerHost
+	httpConfig.DialTimeout = dialTimeout
+	httpConfig.ResponseHeaderTimeout = responseHeaderTimeout
+	httpConfig.Headers = customHeaders.headers
+	httpConfig.SkipTLSVerification = insecureSkipTLSVerify
+	return httpConfig
+}
+
+// newGitLabClient returns an API client for the instance at baseURL, using the
+// HTTP client configuration and page size shared by all commands. Callers that
+// pass full URLs to its methods can leave baseURL empty.
+func newGitLabClient(baseURL, accessToken string) *utils.GitLabClient {
+	client := utils.NewGitLabClient(baseURL, accessToken, utils.CreateHTTPClient(newHTTPClientConfig()))
+	client.PerPage = pageSize()
+	return client
+}
+
+// validateHTTPFlags checks the --timeout and --max-retries values
+func validateHTTPFlags() error {
+	if requestTimeout < 0 {
+		return fmt.Errorf("--timeout must not be negative, got %s", requestTimeout)
+	}
+	if maxRetries < 0 {
+		return fmt.Errorf("--max-retries must not be negative, got %d", maxRetries)
+	}
+	return nil
+}
+
+// resolveInsecure sets insecureSkipTLSVerify from the config, letting an
+// explicit --insecure (or --insecure=false) take precedence
+func resolveInsecure(config *utils.Config) {
+	insecureSkipTLSVerify = config.InsecureSkipTLSVerify
+	if rootCmd.PersistentFlags().Changed("insecure") {
+		insecureSkipTLSVerify = insecureFlag
+	}
+	if insecureSkipTLSVerify {
+		warnOnce("TLS certificate verification is disabled, connections to GitLab can be intercepted")
+	}
+}
+
+// resolveDataDir sets the directory output files are written to from
+// data_dir, overridden by --data-dir
+func resolveDataDir(config *utils.Config) {
+	utils.DataDir = utils.DefaultDataDir
+	if config.DataDir != "" {
+		utils.DataDir = config.DataDir
+	}
+	if rootCmd.PersistentFlags().Changed("data-dir") {
+		utils.DataDir = dataDir
+	}
+}
+
+// applyRateLimitRetries makes requests answered with 429 Too Many Requests and
+// no Retry-After header back off like other failed requests
+func applyRateLimitRetries() {
+	utils.RateLimitRetries = maxRetries
+	utils.RateLimitBackoff = retryBackoff()
+}
+
+// retryBackoff returns the jittered backoff between retries of failed
+// requests, from the --retry-base-delay and --retry-max-delay flags
+func retryBackoff() utils.Backoff {
+	return utils.Backoff{Base: retryBaseDelay, Max: retryMaxDelay}
+}
+
+// paginateCached fetches every page of the list at path like
+// client.GetPaginated, reusing the result of an identical earlier request in
+// this run unless --no-cache is set. Only use it for collections the run
+// doesn't modify.
+func paginateCached(client *utils.GitLabClient, path string) ([]map[string]interface{}, error) {
+	fetch := func() ([]map[string]interface{}, error) {
+		return client.GetPaginated(path)
+	}
+	if noCache {
+		return fetch()
+	}
+	return listCache.Fetch(client.URL(path), client.AccessToken, fetch)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+func TestResolveInsecureSetsTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name       string
+		configured bool
+		flag       string
+		want       bool
+	}{
+		{"verified by default", false, "", false},
+		{"config skips verification", true, "", true},
+		{"--insecure skips verification", false, "true", true},
+		{"--insecure=false wins over the config", true, "false", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				resetFlags(rootCmd)
+				insecureSkipTLSVerify = false
+			})
+			if tt.flag != "" {
+				if err := rootCmd.PersistentFlags().Set("insecure", tt.flag); err != nil {
+					t.Fatal(err)
+				}
+			}
+			resolveInsecure(&utils.Config{InsecureSkipTLSVerify: tt.configured})
+
+			client := utils.CreateHTTPClient(newHTTPClientConfig())
+			transport, ok := client.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("transport is a %T, want *http.Transport", client.Transport)
+			}
+			if got := transport.TLSClientConfig.InsecureSkipVerify; got != tt.want {
+				t.Errorf("InsecureSkipVerify = %v, want %v", got, tt.want)
+			}
+
+			// The test server's certificate isn't trusted, so only an
+			// unverified connection gets through
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if connected := err == nil; connected != tt.want {
+				t.Errorf("connected to a server with an untrusted certificate: %v, want %v (error: %v)", connected, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGetWritesToDataDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"key":"A","value":"1"}]`)
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { utils.DataDir = utils.DefaultDataDir })
+
+	tests := []struct {
+		name          string
+		configDataDir bool
+		flagDataDir   bool
+		want          string
+	}{
+		{"data_dir", true, false, "config"},
+		{"--data-dir wins", true, true, "flag"},
+		{"--data-dir", false, true, "flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// get stores the generated file name in the --output global
+			t.Cleanup(func() { outputFile = "" })
+			dir := t.TempDir()
+			configPath := writeTestConfig(t, server.URL)
+			if tt.configDataDir {
+				file, err := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fmt.Fprintf(file, "data_dir: %s\n", filepath.Join(dir, "config"))
+				file.Close()
+			}
+			args := []string{"-c", configPath, "-q"}
+			if tt.flagDataDir {
+				args = append(args, "--data-dir", filepath.Join(dir, "flag"))
+			}
+
+			if err := executeCommand(t, append(args, "get", "variables", "-p", "5")...); err != nil {
+				t.Fatalf("get variables: %v", err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || entries[0].Name() != tt.want {
+				t.Fatalf("directories created: %v, want only %s", entries, tt.want)
+			}
+			files, _ := filepath.Glob(filepath.Join(dir, tt.want, "*-gitlab_get_variables_p-5.json"))
+			if len(files) != 1 {
+				t.Errorf("output files in %s: %v, want one for project 5", tt.want, files)
+			}
+		})
+	}
+}
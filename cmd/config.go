@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd is the parent command for inspecting the configuration
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the gitlab-migrate configuration",
+}
+
+// configShowCmd prints the effective configuration with secrets masked
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration with secrets masked",
+	Long: `Print the configuration gitlab-migrate would use, along with the file it
+was loaded from and any --header values. Access tokens and the auth password
+are masked, keeping the last 4 characters of long ones so tokens can be told
+apart, and secret-looking headers are replaced by ****, so the output is safe
+to paste into a bug report.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := loadConfig()
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			return
+		}
+
+		fmt.Printf("# Loaded from %s\n", configPath)
+		fmt.Print(config)
+		for _, header := range customHeaders.display {
+			fmt.Printf("# --header %s\n", header)
+		}
+	},
+}
+
+// configValidateOutput is the config validate --output value
+var configValidateOutput string
+
+// configValidateOutputText is the default config validate output, one line per check
+const configValidateOutputText = "text"
+
+// configValidateCmd checks that both GitLab instances can be reached with their tokens
+var configValidateCmd = &cobra.Command{
+	Use:     "validate",
+	Aliases: []string{"check"},
+	Short:   "Check that the source and destination URLs and tokens work",
+	Long: `Load the configuration and make an authenticated request to the source and
+destination instances, reporting for each whether the URL is reachable, its
+TLS certificate is valid and the access token is accepted, along with the
+token's scopes when GitLab reports them. Nothing is changed on either side.
+
+--output json prints the same report as one JSON object listing each
+check's name, status and message, for monitoring or CI checks. A config that
+can't be loaded is reported as a failed "config" check.
+
+Exits with status 1 when either side fails, so it can guard a migration
+script.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if configValidateOutput != configValidateOutputText && configValidateOutput != outputFormatJSON {
+			log.Printf("Error: unsupported output %q (use %s or %s)", configValidateOutput, configValidateOutputText, outputFormatJSON)
+			os.Exit(1)
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			if configValidateOutput == outputFormatJSON {
+				// CI parsing the report still gets one, naming the failed check
+				writeValidationReport(validationReport{
+					ConfigFile: configPath,
+					Checks:     []instanceCheck{{Name: "config", Status: "FAIL", Message: err.Error()}},
+				})
+			} else {
+				log.Printf("Error loading config: %v", err)
+			}
+			os.Exit(1)
+		}
+
+		report := validationReport{
+			ConfigFile:  configPath,
+			Source:      checkInstance("source", config.SourceBaseURL, config.SourceAccessToken),
+			Destination: checkInstance("destination", config.DestinationBaseURL, config.DestinationAccessToken),
+		}
+		report.OK = report.Source.OK && report.Destination.OK
+
+		if configValidateOutput == outputFormatJSON {
+			writeValidationReport(report)
+		} else {
+			fmt.Printf("# Loaded from %s\n", configPath)
+			report.Source.print()
+			report.Destination.print()
+		}
+		if !report.OK {
+			os.Exit(1)
+		}
+	},
+}
+
+// validationReport is the outcome of config validate. Checks holds the checks
+// that apply to neither side, such as loading the config file.
+type validationReport struct {
+	ConfigFile  string          `json:"config_file"`
+	OK          bool            `json:"ok"`
+	Checks      []instanceCheck `json:"checks,omitempty"`
+	Source      *instanceReport `json:"source,omitempty"`
+	Destination *instanceReport `json:"destination,omitempty"`
+}
+
+// writeValidationReport prints report as indented JSON on stdout
+func writeValidationReport(report validationReport) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("Error writing report: %v", err)
+		os.Exit(1)
+	}
+}
+
+// instanceReport is the outcome of the checks against one instance
+type instanceReport struct {
+	Side      string          `json:"-"`
+	BaseURL   string          `json:"base_url"`
+	OK        bool            `json:"ok"`
+	Checks    []instanceCheck `json:"checks"`
+	Username  string          `json:"username,omitempty"`
+	Scopes    []string        `json:"scopes,omitempty"`
+	ExpiresAt string          `json:"expires_at,omitempty"`
+}
+
+// instanceCheck is a single check result. Name identifies the check (config,
+// settings, url, reachable, tls, token, response or scopes) and Status is ok,
+// warn, info or FAIL.
+type instanceCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// add records a check result
+func (r *instanceReport) add(name, status, message string) {
+	r.Checks = append(r.Checks, instanceCheck{Name: name, Status: status, Message: message})
+}
+
+// fail records a failed check, with an optional hint on how to fix it
+func (r *instanceReport) fail(name, message, hint string) *instanceReport {
+	r.Checks = append(r.Checks, instanceCheck{Name: name, Status: "FAIL", Message: message, Hint: hint})
+	r.OK = false
+	return r
+}
+
+// print writes the report in the text format, one line per check
+func (r *instanceReport) print() {
+	fmt.Printf("%s (%s):\n", r.Side, r.BaseURL)
+	for _, check := range r.Checks {
+		fmt.Printf("  %-4s  %s\n", check.Status, check.Message)
+		if check.Hint != "" {
+			fmt.Printf("        %s\n", check.Hint)
+		}
+	}
+}
+
+// checkInstance checks whether baseURL is reachable and accepts accessToken,
+// reporting the outcome of each check under the given side
+func checkInstance(side, baseURL, accessToken string) *instanceReport {
+	report := &instanceReport{Side: side, BaseURL: baseURL}
+	if baseURL == "" || accessToken == "" {
+		return report.fail("settings", fmt.Sprintf("%s_base_url and %s_access_token must both be set", side, side), "")
+	}
+
+	req, err := http.NewRequest("GET", baseURL+"/api/v4/user", nil)
+	if err != nil {
+		return report.fail("url", fmt.Sprintf("invalid URL: %v", err), "")
+	}
+	req.Header.Set("PRIVATE-TOKEN", accessToken)
+
+	client := utils.CreateHTTPClient(newHTTPClientConfig())
+	resp, err := utils.DoWithRetry(client, req)
+	var certErr *tls.CertificateVerificationError
+	switch {
+	case errors.Is(err, utils.ErrUnauthorized):
+		report.add("reachable", "ok", "reachable")
+		return report.fail("token", "token rejected (401 Unauthorized): it is wrong, expired or revoked", "")
+	case errors.As(err, &certErr):
+		return report.fail("tls", fmt.Sprintf("TLS certificate not valid: %v", certErr.Err), "use insecure_skip_tls_verify or --insecure only for trusted self-signed instances")
+	case err != nil:
+		return report.fail("reachable", fmt.Sprintf("not reachable: %v", err), "")
+	}
+	defer resp.Body.Close()
+
+	report.add("reachable", "ok", "reachable")
+	if req.URL.Scheme == "https" {
+		if insecureSkipTLSVerify {
+			report.add("tls", "warn", "TLS certificate not verified (insecure_skip_tls_verify or --insecure)")
+		} else {
+			report.add("tls", "ok", "TLS certificate valid")
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return report.fail("token", fmt.Sprintf("%s/api/v4/user returned %s", baseURL, resp.Status), "")
+	}
+
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return report.fail("response", fmt.Sprintf("unexpected response, is this a GitLab instance? %v", err), "")
+	}
+	report.OK = true
+	report.Username = user.Username
+	report.add("token", "ok", fmt.Sprintf("token accepted (user %s)", user.Username))
+
+	// Older instances don't have this endpoint; the check passes either way
+	var token struct {
+		Scopes    []string `json:"scopes"`
+		ExpiresAt string   `json:"expires_at"`
+	}
+	if err := getGitLabJSON(baseURL+"/api/v4/personal_access_tokens/self", accessToken, &token); err != nil {
+		report.add("scopes", "info", "token scopes not available")
+		return report
+	}
+	report.Scopes, report.ExpiresAt = token.Scopes, token.ExpiresAt
+	line := fmt.Sprintf("token scopes: %s", strings.Join(token.Scopes, ", "))
+	if token.ExpiresAt != "" {
+		line += fmt.Sprintf(" (expires %s)", token.ExpiresAt)
+	}
+	report.add("scopes", "info", line)
+	return report
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configValidateCmd.Flags().StringVar(&configValidateOutput, "output", configValidateOutputText, "Report format: text, or json for a single JSON object")
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
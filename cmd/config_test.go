@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckInstance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v4/user":
+			fmt.Fprint(w, `{"username":"root"}`)
+		case "/api/v4/personal_access_tokens/self":
+			fmt.Fprint(w, `{"scopes":["api"],"expires_at":"2030-01-01"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	report := checkInstance("source", server.URL, "good")
+	var names []string
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+	}
+	if fmt.Sprint(names) != "[reachable token scopes]" {
+		t.Errorf("check names = %v, want [reachable token scopes]", names)
+	}
+	if !report.OK || report.Username != "root" || fmt.Sprint(report.Scopes) != "[api]" || report.ExpiresAt != "2030-01-01" {
+		t.Errorf("report = %+v, want an ok report for root with scope api expiring 2030-01-01", report)
+	}
+
+	report = checkInstance("destination", server.URL, "revoked")
+	if report.OK {
+		t.Errorf("report = %+v, want it to fail for a rejected token", report)
+	}
+	if last := report.Checks[len(report.Checks)-1]; last.Name != "token" || last.Status != "FAIL" {
+		t.Errorf("last check = %+v, want a failed token check", last)
+	}
+
+	if report := checkInstance("source", "", ""); report.OK || len(report.Checks) != 1 {
+		t.Errorf("report = %+v, want a single failed check for missing settings", report)
+	}
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var showValues bool
+var diffExitCode bool
+var stripValues bool
+
+// Exit codes of "diff variables --exit-code"
+const (
+	diffExitDifferent = 1
+	diffExitError     = 2
+)
+
+// diffAttributes are the non-secret variable fields compared besides the value
+var diffAttributes = []string{"variable_type", "protected", "masked", "raw", "description"}
+
+// variableChange describes a variable present on both sides with differing fields
+type variableChange struct {
+	Key              string            `json:"key"`
+	Scope            string            `json:"environment_scope"`
+	Attributes       []string          `json:"attributes,omitempty"`
+	SourceAttrs      map[string]string `json:"source_attributes,omitempty"`
+	DestinationAttrs map[string]string `json:"destination_attributes,omitempty"`
+	ValueDiffers     bool              `json:"value_differs"`
+	SourceValue      string            `json:"source_value,omitempty"`
+	DestinationValue string            `json:"destination_value,omitempty"`
+	Masked           bool              `json:"masked"`
+}
+
+// variableDiff is the result of comparing two variable lists
+type variableDiff struct {
+	OnlyInSource      []string         `json:"only_in_source"`
+	OnlyInDestination []string         `json:"only_in_destination"`
+	Changed           []variableChange `json:"changed"`
+	Identical         int              `json:"identical"`
+}
+
+// diffCmd is the parent command for "diff" operations
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare GitLab resources between the source and destination",
+	Long: `Diff command compares resources on the source instance with the destination instance.
+Use subcommands to specify what type of data you want to compare.`,
+}
+
+// diffVariablesCmd compares variables between a source and destination project or group
+var diffVariablesCmd = &cobra.Command{
+	Use:   "variables",
+	Short: "Compare CI/CD variables between source and destination",
+	Long: `Compare CI/CD variables of a source group or project with a destination group or project.
+Variables are matched by key and environment scope and reported as only in
+source, only in destination, or present on both sides but different.
+
+Variable values are secrets, so by default a changed value is only reported as
+"values differ". Use --show-values to print them. Values of masked variables
+are never printed, even with --show-values. With --strip-values, values are
+replaced by a SHA-256 fingerprint as soon as they are fetched, so value drift
+is still detected but no value can reach the output.
+
+With --exit-code the command exits like "git diff --exit-code", so CI jobs can
+fail on drift:
+  0  source and destination are identical
+  1  differences were found
+  2  the comparison could not be run
+
+With --recursive, every project of the source group and its subgroups is
+compared with the destination project at the same path relative to the
+destination group, e.g. src/team/api with dst/team/api for -g src -G dst.
+Group variables are not compared. A diff is printed per project, followed by
+the projects found in only one of the groups and an overall summary.
+--exit-code treats projects on one side only as differences, and exits with 2
+when any project could not be compared.
+
+--output-format json prints the result as JSON instead, for CI jobs to
+check. Values are only included with --show-values, and never for masked
+variables.
+
+Required flags:
+- Source: Use either -g (group ID) or -p (project ID)
+- Destination: Use either --destination-group or --destination-project`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validateDiffOutputFormat(); err != nil {
+			log.Printf("Error: %v", err)
+			exitDiffError()
+			return
+		}
+
+		if recursive && (groupID == "" || destinationGroupID == "") {
+			log.Println("Error: --recursive requires a source group (-g) and a destination group (--destination-group)")
+			exitDiffError()
+			return
+		}
+
+		if (groupID == "" && projectID == "") || (destinationGroupID == "" && destinationProjectID == "") {
+			log.Println("Error: Source and destination IDs must be provided using one of:")
+			log.Println("  - Source group (-g) or project (-p)")
+			log.Println("  - Destination group (--destination-group) or project (--destination-project)")
+			exitDiffError()
+			return
+		}
+
+		if stripValues && showValues {
+			log.Println("Error: --show-values and --strip-values can't be combined")
+			exitDiffError()
+			return
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			exitDiffError()
+			return
+		}
+
+		if recursive {
+			runRecursiveDiff(config)
+			return
+		}
+
+		sourceTarget, sourceURL := variablesEndpoint(config.SourceBaseURL, groupID, projectID)
+		destinationTarget, destinationURL := variablesEndpoint(config.DestinationBaseURL, destinationGroupID, destinationProjectID)
+
+		sourceVars, err := fetchVariables(sourceURL, config.SourceAccessToken)
+		if err != nil {
+			log.Printf("Error fetching source variables for %s: %v", sourceTarget, err)
+			exitDiffError()
+			return
+		}
+		destinationVars, err := fetchVariables(destinationURL, config.DestinationAccessToken)
+		if err != nil {
+			log.Printf("Error fetching destination variables for %s: %v", destinationTarget, err)
+			exitDiffError()
+			return
+		}
+
+		if stripValues {
+			stripVariableValues(sourceVars)
+			stripVariableValues(destinationVars)
+		}
+
+		diff := diffVariables(sourceVars, destinationVars)
+		if diffOutputFormat == diffOutputJSON {
+			if err := diff.printJSON(showValues); err != nil {
+				log.Printf("Error writing JSON: %v", err)
+				exitDiffError()
+				return
+			}
+		} else {
+			fmt.Printf("Comparing variables of source %s with destination %s\n", sourceTarget, destinationTarget)
+			diff.print(showValues)
+		}
+
+		if diffExitCode && !diff.identical() {
+			os.Exit(diffExitDifferent)
+		}
+	},
+}
+
+// exitDiffError exits with diffExitError when --exit-code is set, so a failed
+// comparison is not mistaken for "no differences"
+func exitDiffError() {
+	if diffExitCode {
+		os.Exit(diffExitError)
+	}
+}
+
+// stripVariableValues replaces every value with a SHA-256 fingerprint so values
+// can still be compared without being kept around or printed
+func stripVariableValues(variables []map[string]interface{}) {
+	for _, variable := range variables {
+		sum := sha256.Sum256([]byte(diffValue(variable["value"])))
+		variable["value"] = "sha256:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// variablesEndpoint returns a description and the variables URL for a group or project
+func variablesEndpoint(baseURL, groupID, projectID string) (string, string) {
+	if groupID != "" {
+		return "group " + groupID, fmt.Sprintf("%s/api/v4/groups/%s/variables", baseURL, groupID)
+	}
+	return "project " + projectID, fmt.Sprintf("%s/api/v4/projects/%s/variables", baseURL, projectID)
+}
+
+// diffVariables compares source and destination variables by key and environment scope
+func diffVariables(source, destination []map[string]interface{}) variableDiff {
+	index := func(variables []map[string]interface{}) map[string]map[string]interface{} {
+		indexed := make(map[string]map[string]interface{}, len(variables))
+		for _, variable := range variables {
+			key, _ := variable["key"].(string)
+			indexed[variableID(key, variableScope(variable))] = variable
+		}
+		return indexed
+	}
+	sourceIndex := index(source)
+	destinationIndex := index(destination)
+
+	var diff variableDiff
+	for _, id := range sortedKeys(sourceIndex) {
+		sourceVar := sourceIndex[id]
+		destinationVar, ok := destinationIndex[id]
+		if !ok {
+			diff.OnlyInSource = append(diff.OnlyInSource, describeVariable(sourceVar))
+			continue
+		}
+
+		change := variableChange{
+			Key:              sourceVar["key"].(string),
+			Scope:            variableScope(sourceVar),
+			SourceAttrs:      make(map[string]string),
+			DestinationAttrs: make(map[string]string),
+			SourceValue:      diffValue(sourceVar["value"]),
+			DestinationValue: diffValue(destinationVar["value"]),
+			Masked:           sourceVar["masked"] == true || destinationVar["masked"] == true,
+		}
+		change.ValueDiffers = change.SourceValue != change.DestinationValue
+
+		for _, attr := range diffAttributes {
+			sourceAttr, inSource := sourceVar[attr]
+			destinationAttr, inDestination := destinationVar[attr]
+			// Fields unknown to one of the instances can't be compared
+			if !inSource || !inDestination {
+				continue
+			}
+			if diffValue(sourceAttr) != diffValue(destinationAttr) {
+				change.Attributes = append(change.Attributes, attr)
+				change.SourceAttrs[attr] = diffValue(sourceAttr)
+				change.DestinationAttrs[attr] = diffValue(destinationAttr)
+			}
+		}
+
+		if change.ValueDiffers || len(change.Attributes) > 0 {
+			diff.Changed = append(diff.Changed, change)
+		} else {
+			diff.Identical++
+		}
+	}
+
+	for _, id := range sortedKeys(destinationIndex) {
+		if _, ok := sourceIndex[id]; !ok {
+			diff.OnlyInDestination = append(diff.OnlyInDestination, describeVariable(destinationIndex[id]))
+		}
+	}
+
+	return diff
+}
+
+// identical reports whether the diff found no differences
+func (d variableDiff) identical() bool {
+	return len(d.OnlyInSource) == 0 && len(d.OnlyInDestination) == 0 && len(d.Changed) == 0
+}
+
+// print writes the diff, revealing values only when showValues is set and
+// neither side of the variable is masked
+func (d variableDiff) print(showValues bool) {
+	if len(d.OnlyInSource) > 0 {
+		fmt.Println("Only in source:")
+		for _, v := range d.OnlyInSource {
+			fmt.Printf("  %s\n", v)
+		}
+	}
+	if len(d.OnlyInDestination) > 0 {
+		fmt.Println("Only in destination:")
+		for _, v := range d.OnlyInDestination {
+			fmt.Printf("  %s\n", v)
+		}
+	}
+	if len(d.Changed) > 0 {
+		fmt.Println("Different:")
+		for _, c := range d.Changed {
+			fmt.Printf("  %s (scope %s)\n", c.Key, c.Scope)
+			if c.ValueDiffers {
+				switch {
+				case c.Masked:
+					fmt.Println("    values differ (masked, not shown)")
+				case showValues:
+					fmt.Printf("    value: %q -> %q\n", c.SourceValue, c.DestinationValue)
+				default:
+					fmt.Println("    values differ")
+				}
+			}
+			for _, attr := range c.Attributes {
+				fmt.Printf("    %s: %s -> %s\n", attr, c.SourceAttrs[attr], c.DestinationAttrs[attr])
+			}
+		}
+	}
+	fmt.Printf("%d only in source, %d only in destination, %d different, %d identical\n",
+		len(d.OnlyInSource), len(d.OnlyInDestination), len(d.Changed), d.Identical)
+}
+
+// describeVariable formats a variable's key and scope for diff listings
+func describeVariable(variable map[string]interface{}) string {
+	key, _ := variable["key"].(string)
+	return fmt.Sprintf("%s (scope %s)", key, variableScope(variable))
+}
+
+// diffValue normalizes a decoded JSON field for comparison
+func diffValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// sortedKeys returns the keys of a variable index in sorted order
+func sortedKeys(index map[string]map[string]interface{}) []string {
+	keys := make([]string, 0, len(index))
+	for k := range index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	diffVariablesCmd.Flags().StringVarP(&groupID, "group", "g", "", "Source group ID")
+	diffVariablesCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	diffVariablesCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "Destination group ID")
+	diffVariablesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID")
+	diffVariablesCmd.Flags().BoolVar(&showValues, "show-values", false, "Print differing values (values of masked variables are never printed)")
+	diffVariablesCmd.Flags().BoolVar(&stripValues, "strip-values", false, "Compare values by fingerprint only so they can never appear in the output")
+	diffVariablesCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with 1 when differences are found and 2 on errors")
+	diffVariablesCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Compare every project of the source group and its subgroups with the destination project at the same relative path")
+	diffVariablesCmd.Flags().StringVar(&diffOutputFormat, "output-format", diffOutputText, "Output format: text or json")
+
+	diffCmd.AddCommand(diffVariablesCmd)
+	rootCmd.AddCommand(diffCmd)
+}
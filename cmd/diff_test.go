@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffHidesValues(t *testing.T) {
+	source := []map[string]interface{}{
+		{"key": "TOKEN", "value": "source-secret"},
+		{"key": "MASKED", "value": "masked-source", "masked": true},
+	}
+	destination := []map[string]interface{}{
+		{"key": "TOKEN", "value": "destination-secret"},
+		{"key": "MASKED", "value": "masked-destination", "masked": true},
+	}
+	diff := diffVariables(source, destination)
+
+	tests := []struct {
+		showValues bool
+		want       []string
+		hidden     []string
+	}{
+		{false, []string{"values differ"}, []string{"source-secret", "destination-secret", "masked-source", "masked-destination"}},
+		{true, []string{`value: "source-secret" -> "destination-secret"`, "values differ (masked, not shown)"}, []string{"masked-source", "masked-destination"}},
+	}
+	for _, tt := range tests {
+		for _, format := range []string{"text", "json"} {
+			output := captureStdout(t, func() {
+				if format == "json" {
+					if err := diff.printJSON(tt.showValues); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				diff.print(tt.showValues)
+			})
+			if format == "text" {
+				for _, want := range tt.want {
+					if !strings.Contains(output, want) {
+						t.Errorf("--show-values=%v output lacks %q:\n%s", tt.showValues, want, output)
+					}
+				}
+			}
+			for _, value := range tt.hidden {
+				if strings.Contains(output, value) {
+					t.Errorf("--show-values=%v %s output reveals %q:\n%s", tt.showValues, format, value, output)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// Output formats of "diff variables"
+const (
+	diffOutputText = "text"
+	diffOutputJSON = "json"
+)
+
+var diffOutputFormat string
+
+// projectVariablesDiff is the comparison of one project present in both groups
+type projectVariablesDiff struct {
+	Path                 string        `json:"path"`
+	SourceProjectID      int64         `json:"source_project_id"`
+	DestinationProjectID int64         `json:"destination_project_id"`
+	Identical            bool          `json:"identical"`
+	Error                string        `json:"error,omitempty"`
+	Diff                 *variableDiff `json:"diff,omitempty"`
+}
+
+// groupVariablesDiff is the result of "diff variables --recursive"
+type groupVariablesDiff struct {
+	SourceGroup       string                 `json:"source_group"`
+	DestinationGroup  string                 `json:"destination_group"`
+	Projects          []projectVariablesDiff `json:"projects"`
+	OnlyInSource      []string               `json:"only_in_source"`
+	OnlyInDestination []string               `json:"only_in_destination"`
+	Summary           groupDiffSummary       `json:"summary"`
+}
+
+// groupDiffSummary counts the outcome of a recursive comparison
+type groupDiffSummary struct {
+	Compared          int `json:"compared"`
+	Identical         int `json:"identical"`
+	Different         int `json:"different"`
+	Failed            int `json:"failed"`
+	OnlyInSource      int `json:"only_in_source"`
+	OnlyInDestination int `json:"only_in_destination"`
+}
+
+// validateDiffOutputFormat checks the --output-format value of diff variables
+func validateDiffOutputFormat() error {
+	if diffOutputFormat != diffOutputText && diffOutputFormat != diffOutputJSON {
+		return fmt.Errorf("unsupported --output-format value %q (use %s or %s)", diffOutputFormat, diffOutputText, diffOutputJSON)
+	}
+	return nil
+}
+
+// diffGroupsRecursive compares the variables of every project of the source
+// group and its subgroups with the destination project at the same path
+// relative to the destination group, and lists the projects found on one side
+// only
+func diffGroupsRecursive(config *utils.Config, sourceGroupID, destinationGroupID string) (*groupVariablesDiff, error) {
+	sourceProjects, err := projectsByRelativePath(config.SourceBaseURL, config.SourceAccessToken, sourceGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	destinationProjects, err := projectsByRelativePath(config.DestinationBaseURL, config.DestinationAccessToken, destinationGroupID)
+	if err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+
+	result := &groupVariablesDiff{
+		SourceGroup:       sourceGroupID,
+		DestinationGroup:  destinationGroupID,
+		Projects:          []projectVariablesDiff{},
+		OnlyInSource:      []string{},
+		OnlyInDestination: []string{},
+	}
+	for path, id := range sourceProjects {
+		if destinationID, ok := destinationProjects[path]; ok {
+			result.Projects = append(result.Projects, projectVariablesDiff{Path: path, SourceProjectID: id, DestinationProjectID: destinationID})
+		} else {
+			result.OnlyInSource = append(result.OnlyInSource, path)
+		}
+	}
+	for path := range destinationProjects {
+		if _, ok := sourceProjects[path]; !ok {
+			result.OnlyInDestination = append(result.OnlyInDestination, path)
+		}
+	}
+	sort.Slice(result.Projects, func(i, j int) bool { return result.Projects[i].Path < result.Projects[j].Path })
+	sort.Strings(result.OnlyInSource)
+	sort.Strings(result.OnlyInDestination)
+
+	// A project that can't be compared is reported, unless the token was
+	// rejected: then every other project would fail the same way
+	err = forEachUntilError(len(result.Projects), snapshotConcurrency, func(i int) error {
+		project := &result.Projects[i]
+		diff, err := diffProjectVariables(config, project.SourceProjectID, project.DestinationProjectID)
+		if err != nil {
+			project.Error = err.Error()
+			if errors.Is(err, utils.ErrUnauthorized) {
+				return err
+			}
+			return nil
+		}
+		project.Diff = &diff
+		project.Identical = diff.identical()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Summary.Compared = len(result.Projects)
+	result.Summary.OnlyInSource = len(result.OnlyInSource)
+	result.Summary.OnlyInDestination = len(result.OnlyInDestination)
+	for _, project := range result.Projects {
+		switch {
+		case project.Error != "":
+			result.Summary.Failed++
+		case project.Identical:
+			result.Summary.Identical++
+		default:
+			result.Summary.Different++
+		}
+	}
+	return result, nil
+}
+
+// diffProjectVariables compares the variables of a source and destination project
+func diffProjectVariables(config *utils.Config, sourceID, destinationID int64) (variableDiff, error) {
+	_, sourceURL := variablesEndpoint(config.SourceBaseURL, "", strconv.FormatInt(sourceID, 10))
+	_, destinationURL := variablesEndpoint(config.DestinationBaseURL, "", strconv.FormatInt(destinationID, 10))
+
+	sourceVars, err := fetchVariables(sourceURL, config.SourceAccessToken)
+	if err != nil {
+		return variableDiff{}, fmt.Errorf("source project %d: %w", sourceID, err)
+	}
+	destinationVars, err := fetchVariables(destinationURL, config.DestinationAccessToken)
+	if err != nil {
+		return variableDiff{}, fmt.Errorf("destination project %d: %w", destinationID, err)
+	}
+	if stripValues {
+		stripVariableValues(sourceVars)
+		stripVariableValues(destinationVars)
+	}
+	return diffVariables(sourceVars, destinationVars), nil
+}
+
+// identical reports whether every project matched and none differ or failed
+func (d *groupVariablesDiff) identical() bool {
+	return d.Summary.Different == 0 && d.Summary.Failed == 0 && d.Summary.OnlyInSource == 0 && d.Summary.OnlyInDestination == 0
+}
+
+// print writes the per-project diffs followed by the overall summary
+func (d *groupVariablesDiff) print(showValues bool) {
+	fmt.Printf("Comparing variables of the projects of source group %s with destination group %s\n", d.SourceGroup, d.DestinationGroup)
+	for _, project := range d.Projects {
+		fmt.Printf("\n%s (source %d, destination %d)\n", project.Path, project.SourceProjectID, project.DestinationProjectID)
+		if project.Error != "" {
+			fmt.Printf("Error: %s\n", project.Error)
+			continue
+		}
+		project.Diff.print(showValues)
+	}
+
+	if len(d.OnlyInSource) > 0 {
+		fmt.Println("\nProjects only in source:")
+		for _, path := range d.OnlyInSource {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+	if len(d.OnlyInDestination) > 0 {
+		fmt.Println("\nProjects only in destination:")
+		for _, path := range d.OnlyInDestination {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	s := d.Summary
+	fmt.Printf("\n%d projects compared: %d identical, %d different, %d failed; %d only in source, %d only in destination\n",
+		s.Compared, s.Identical, s.Different, s.Failed, s.OnlyInSource, s.OnlyInDestination)
+}
+
+// printJSON writes the comparison as indented JSON to stdout. Values are only
+// included with showValues, and never for masked variables.
+func (d *groupVariablesDiff) printJSON(showValues bool) error {
+	for _, project := range d.Projects {
+		if project.Diff != nil {
+			*project.Diff = project.Diff.forJSON(showValues)
+		}
+	}
+	return writeDiffJSON(d)
+}
+
+// printJSON writes the diff as indented JSON to stdout. Values are only
+// included with showValues, and never for masked variables.
+func (d variableDiff) printJSON(showValues bool) error {
+	return writeDiffJSON(d.forJSON(showValues))
+}
+
+// forJSON returns a copy of the diff without the values that may not be
+// printed, with empty lists instead of nil so they encode as []
+func (d variableDiff) forJSON(showValues bool) variableDiff {
+	out := variableDiff{
+		OnlyInSource:      append([]string{}, d.OnlyInSource...),
+		OnlyInDestination: append([]string{}, d.OnlyInDestination...),
+		Changed:           append([]variableChange{}, d.Changed...),
+		Identical:         d.Identical,
+	}
+	for i := range out.Changed {
+		if change := &out.Changed[i]; !showValues || change.Masked {
+			change.SourceValue, change.DestinationValue = "", ""
+		}
+	}
+	return out
+}
+
+// writeDiffJSON encodes a diff result as indented JSON to stdout
+func writeDiffJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// runRecursiveDiff runs "diff variables --recursive" and exits according to
+// --exit-code
+func runRecursiveDiff(config *utils.Config) {
+	result, err := diffGroupsRecursive(config, groupID, destinationGroupID)
+	if err != nil {
+		log.Printf("Error comparing groups: %v", err)
+		exitDiffError()
+		return
+	}
+
+	if diffOutputFormat == diffOutputJSON {
+		if err := result.printJSON(showValues); err != nil {
+			log.Printf("Error writing JSON: %v", err)
+			exitDiffError()
+			return
+		}
+	} else {
+		result.print(showValues)
+	}
+
+	if result.Summary.Failed > 0 {
+		exitDiffError()
+	} else if diffExitCode && !result.identical() {
+		os.Exit(diffExitDifferent)
+	}
+}
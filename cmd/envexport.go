@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// shellName matches the variable names a POSIX shell can export
+var shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// writeEnvExport writes variables as "export KEY='VALUE'" lines that can be
+// sourced by a shell, sorted by key and scope. Only variables for all
+// environments (scope *) are exported; scoped variables, file variables and
+// keys that aren't valid shell names are listed in comments, without values.
+func writeEnvExport(w io.Writer, variables []map[string]interface{}) error {
+	sorted := make([]interface{}, len(variables))
+	for i, v := range variables {
+		sorted[i] = v
+	}
+	for _, item := range sortVariables(sorted) {
+		variable := item.(map[string]interface{})
+		key, _ := variable["key"].(string)
+		value, _ := variable["value"].(string)
+		scope := variableScope(variable)
+
+		var line string
+		switch {
+		case !shellName.MatchString(key):
+			line = fmt.Sprintf("# %s (scope %s) is not a valid shell variable name, not exported", key, scope)
+		case variable["variable_type"] == "file":
+			line = fmt.Sprintf("# %s (scope %s) is a file variable, GitLab sets it to the path of a file holding the value; not exported", key, scope)
+		case scope != "*":
+			line = fmt.Sprintf("# %s is scoped to %s, not exported", key, scope)
+		default:
+			line = fmt.Sprintf("export %s=%s", key, shellQuote(value))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shellQuote wraps s in single quotes, closing and reopening them around any
+// single quote in s, so the shell reads it literally
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+var ensureEnvironments bool
+
+// referencedEnvironments returns the environments named by the scopes of
+// variables, sorted. Wildcard scopes such as "*" or "review/*" don't name a
+// single environment and are left out.
+func referencedEnvironments(variables []interface{}) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, v := range variables {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scope := variableScope(variable)
+		if strings.Contains(scope, "*") || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		names = append(names, scope)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ensureProjectEnvironments creates the environments referenced by variable
+// scopes that don't exist on the project yet
+func ensureProjectEnvironments(ctx context.Context, baseURL, accessToken, projectID string, variables []interface{}) error {
+	names := referencedEnvironments(variables)
+	if len(names) == 0 {
+		return nil
+	}
+
+	collectionURL := fmt.Sprintf("%s/api/v4/projects/%s/environments", baseURL, projectID)
+	httpConfig := newHTTPClientConfig()
+	client := utils.CreateHTTPClient(httpConfig)
+
+	environments, err := utils.PaginateContext(ctx, client, collectionURL, accessToken, defaultPerPage)
+	if err != nil {
+		return fmt.Errorf("error fetching environments: %w", err)
+	}
+	existing := make(map[string]bool, len(environments))
+	for _, environment := range environments {
+		name, _ := environment["name"].(string)
+		existing[name] = true
+	}
+
+	for _, name := range names {
+		if existing[name] {
+			continue
+		}
+		if variablesDryRun {
+			fmt.Printf("[dry run] created: environment %s for project %s\n", name, projectID)
+			continue
+		}
+		payload, err := json.Marshal(map[string]string{"name": name})
+		if err != nil {
+			return fmt.Errorf("error marshaling environment payload: %v", err)
+		}
+		if err := makeGitLabAPIRequestContext(ctx, "POST", collectionURL, accessToken, string(payload)); err != nil {
+			return fmt.Errorf("failed to create environment %s: %w", name, err)
+		}
+		fmt.Printf("created: environment %s for project %s\n", name, projectID)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// Export and import states reported by GitLab
+const (
+	exportStatusNone     = "none"
+	exportStatusFinished = "finished"
+	importStatusFinished = "finished"
+	importStatusFailed   = "failed"
+)
+
+// Polling intervals for export and import status checks
+const (
+	pollInitialInterval = 2 * time.Second
+	pollMaxInterval     = 30 * time.Second
+)
+
+// exportStatus is the response of GET /projects/:id/export
+type exportStatus struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	ExportStatus      string `json:"export_status"`
+	Links             struct {
+		APIURL string `json:"api_url"`
+		WebURL string `json:"web_url"`
+	} `json:"_links"`
+}
+
+// importStatus is the response of POST /projects/import and GET /projects/:id/import
+type importStatus struct {
+	ID                int64  `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	ImportStatus      string `json:"import_status"`
+	ImportError       string `json:"import_error"`
+}
+
+// scheduleProjectExport asks GitLab to start a native export of a project
+func scheduleProjectExport(baseURL, accessToken, projectID string) error {
+	exportURL := fmt.Sprintf("%s/api/v4/projects/%s/export", baseURL, url.PathEscape(projectID))
+	return makeGitLabAPIRequest("POST", exportURL, accessToken, "")
+}
+
+// getExportStatus returns the current export state of a project
+func getExportStatus(baseURL, accessToken, projectID string) (*exportStatus, error) {
+	var status exportStatus
+	statusURL := fmt.Sprintf("%s/api/v4/projects/%s/export", baseURL, url.PathEscape(projectID))
+	if err := getGitLabJSON(statusURL, accessToken, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// waitForExport polls the export state with a growing interval until it is
+// finished or timeout elapses, logging every state change
+func waitForExport(baseURL, accessToken, projectID string, timeout time.Duration) (*exportStatus, error) {
+	start := time.Now()
+	interval := pollInitialInterval
+	lastState := ""
+
+	for {
+		status, err := getExportStatus(baseURL, accessToken, projectID)
+		if err != nil {
+			return nil, fmt.Errorf("error checking export status: %v", err)
+		}
+
+		if status.ExportStatus != lastState {
+			log.Printf("Export of project %s: %s (%s elapsed)", projectID, status.ExportStatus, time.Since(start).Round(time.Second))
+			lastState = status.ExportStatus
+		}
+
+		switch status.ExportStatus {
+		case exportStatusFinished:
+			return status, nil
+		case exportStatusNone:
+			return nil, fmt.Errorf("no export has been scheduled for project %s", projectID)
+		}
+
+		if time.Since(start)+interval > timeout {
+			return nil, fmt.Errorf("export of project %s not finished after %s (last state: %s)", projectID, timeout, status.ExportStatus)
+		}
+		time.Sleep(interval)
+		interval = nextPollInterval(interval)
+	}
+}
+
+// nextPollInterval grows a polling interval by half, up to pollMaxInterval
+func nextPollInterval(interval time.Duration) time.Duration {
+	interval += interval / 2
+	if interval > pollMaxInterval {
+		return pollMaxInterval
+	}
+	return interval
+}
+
+// downloadExport streams the finished export archive of a project to filePath
+// and returns the number of bytes written
+func downloadExport(baseURL, accessToken, projectID, filePath string) (int64, error) {
+	downloadURL := fmt.Sprintf("%s/api/v4/projects/%s/export/download", baseURL, url.PathEscape(projectID))
+	req, err := http.NewRequest("GET", downloadURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", accessToken)
+
+	httpConfig := newHTTPClientConfig()
+	// Archives can be large, so the transfer is not bounded by the request timeout
+	httpConfig.Timeout = 0
+	client := utils.CreateHTTPClient(httpConfig)
+
+	resp, err := utils.DoWithRetry(client, req)
+	if err != nil {
+		return 0, fmt.Errorf("error downloading export: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("error downloading export: %s: %s", resp.Status, body)
+	}
+
+	return utils.DownloadToFile(resp.Body, resp.ContentLength, filePath, downloadProgress("Downloading export"))
+}
+
+// downloadProgress returns a progress printer for stderr, or nil when --quiet
+// is set or stderr is not a terminal
+func downloadProgress(label string) utils.ProgressFunc {
+	if quiet || !utils.IsTerminal(os.Stderr) {
+		return nil
+	}
+	return utils.NewProgressPrinter(os.Stderr, label)
+}
+
+// importProject uploads an export archive to create a project at namespace/path.
+// The archive is streamed from disk rather than loaded into memory.
+func importProject(baseURL, accessToken, archivePath, namespace, path, name, visibility string) (*importStatus, error) {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export archive: %w", err)
+	}
+	defer archive.Close()
+
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		fields := map[string]string{"path": path, "namespace": namespace}
+		if name != "" {
+			fields["name"] = name
+		}
+		if visibility != "" {
+			fields["override_params[visibility]"] = visibility
+		}
+		for field, value := range fields {
+			if err := form.WriteField(field, value); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		part, err := form.CreateFormFile("file", filepath.Base(archivePath))
+		if err != nil {
+			writer.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(part, archive); err != nil {
+			writer.CloseWithError(err)
+			return
+		}
+		writer.CloseWithError(form.Close())
+	}()
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/v4/projects/import", baseURL), body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", accessToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	httpConfig := newHTTPClientConfig()
+	httpConfig.Timeout = 0
+	client := utils.CreateHTTPClient(httpConfig)
+
+	resp, err := utils.DoWithRetry(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading export archive: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("error importing project: %s: %s", resp.Status, respBody)
+	}
+
+	var status importStatus
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return nil, fmt.Errorf("error parsing import response: %v", err)
+	}
+	return &status, nil
+}
+
+// waitForImport polls the import state of a project with a growing interval
+// until it is finished, has failed, or timeout elapses
+func waitForImport(baseURL, accessToken string, projectID int64, timeout time.Duration) (*importStatus, error) {
+	start := time.Now()
+	interval := pollInitialInterval
+	lastState := ""
+	statusURL := fmt.Sprintf("%s/api/v4/projects/%d/import", baseURL, projectID)
+
+	for {
+		var status importStatus
+		if err := getGitLabJSON(statusURL, accessToken, &status); err != nil {
+			return nil, fmt.Errorf("error checking import status: %v", err)
+		}
+
+		if status.ImportStatus != lastState {
+			log.Printf("Import of project %d: %s (%s elapsed)", projectID, status.ImportStatus, time.Since(start).Round(time.Second))
+			lastState = status.ImportStatus
+		}
+
+		switch status.ImportStatus {
+		case importStatusFinished:
+			return &status, nil
+		case importStatusFailed:
+			return nil, fmt.Errorf("import of project %d failed: %s", projectID, status.ImportError)
+		}
+
+		if time.Since(start)+interval > timeout {
+			return nil, fmt.Errorf("import of project %d not finished after %s (last state: %s)", projectID, timeout, status.ImportStatus)
+		}
+		time.Sleep(interval)
+		interval = nextPollInterval(interval)
+	}
+}
+
+// getGitLabJSON performs an authenticated GET and decodes the JSON response into out
+func getGitLabJSON(url, accessToken string, out interface{}) error {
+	return newGitLabClient("", accessToken).Get(url, out)
+}
+
+// postGitLabJSON POSTs payload as JSON to url and decodes the created resource
+// into out, unless out is nil
+func postGitLabJSON(url, accessToken string, payload interface{}, out interface{}) error {
+	return newGitLabClient("", accessToken).Post(url, payload, out)
+}
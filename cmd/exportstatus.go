@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var waitForFinish bool
+var exportWaitTimeout time.Duration
+var downloadPath string
+
+// getExportStatusCmd reports the state of a project's native export
+var getExportStatusCmd = &cobra.Command{
+	Use:   "export-status",
+	Short: "Report the status of a project's native export",
+	Long: `Report the state of a GitLab project export (none, queued, started,
+regeneration_in_progress or finished) and the download URL once it is finished.
+
+Use --wait to poll until the export is finished, up to --wait-timeout, and
+--download to stream the finished archive to a file. Exports are scheduled by
+"migrate project --native".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if projectID == "" {
+			return fmt.Errorf("--project must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL := config.SourceBaseURL
+		accessToken := config.SourceAccessToken
+		if isDestination {
+			baseURL = config.DestinationBaseURL
+			accessToken = config.DestinationAccessToken
+		}
+
+		var status *exportStatus
+		if waitForFinish {
+			status, err = waitForExport(baseURL, accessToken, projectID, exportWaitTimeout)
+		} else {
+			status, err = getExportStatus(baseURL, accessToken, projectID)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Project: %s (ID: %d)\n", status.PathWithNamespace, status.ID)
+		fmt.Printf("Export status: %s\n", status.ExportStatus)
+		if status.ExportStatus == exportStatusFinished && status.Links.APIURL != "" {
+			fmt.Printf("Download URL: %s\n", status.Links.APIURL)
+		}
+
+		if downloadPath == "" {
+			return nil
+		}
+		if status.ExportStatus != exportStatusFinished {
+			return fmt.Errorf("export is %s, it can only be downloaded once finished (use --wait)", status.ExportStatus)
+		}
+
+		written, err := downloadExport(baseURL, accessToken, projectID, downloadPath)
+		if err != nil {
+			return err
+		}
+		log.Printf("Successfully downloaded %d bytes to %s", written, downloadPath)
+		return nil
+	},
+}
+
+func init() {
+	getExportStatusCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to check the export of")
+	getExportStatusCmd.Flags().BoolVar(&waitForFinish, "wait", false, "Wait until the export is finished")
+	getExportStatusCmd.Flags().DurationVar(&exportWaitTimeout, "wait-timeout", 30*time.Minute, "Maximum time to wait with --wait")
+	getExportStatusCmd.Flags().StringVar(&downloadPath, "download", "", "Stream the finished export archive to this file")
+	getCmd.AddCommand(getExportStatusCmd)
+}
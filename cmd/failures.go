@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// defaultFailuresFile is where migrate variables lists the variables it could not migrate
+func defaultFailuresFile() string {
+	return filepath.Join(utils.DataDir, "failures.json")
+}
+
+var retryFrom string
+
+// failuresFile is the format of the failures file written after a migration
+// and read back by --retry-from. A retry writes the same format, so it can be
+// repeated until nothing fails.
+type failuresFile struct {
+	SourceBaseURL      string           `json:"source_base_url"`
+	DestinationBaseURL string           `json:"destination_base_url"`
+	Failures           []failedVariable `json:"failures"`
+}
+
+// failedVariable is a variable that failed or was not applied. Type is
+// "project" or "group", and applies to both the source and the destination.
+type failedVariable struct {
+	Type             string `json:"type"`
+	SourceID         string `json:"source_id"`
+	DestinationID    string `json:"destination_id"`
+	Key              string `json:"key"`
+	EnvironmentScope string `json:"environment_scope"`
+	Reason           string `json:"reason"`
+}
+
+// writeFailures writes the failed and not applied variables of the summary to
+// filePath. sources maps each summary target ("project 12") to the ID it was
+// migrated from. When nothing failed, a failures file left by an earlier run is
+// removed so it can't be retried by mistake.
+func writeFailures(config *utils.Config, sources map[string]string, filePath string) error {
+	failures := failuresFile{
+		SourceBaseURL:      config.SourceBaseURL,
+		DestinationBaseURL: config.DestinationBaseURL,
+	}
+
+	for _, r := range variablesSummary.unfinished() {
+		kind, destinationID, _ := strings.Cut(r.Target, " ")
+		reason := r.Detail
+		if r.Outcome == outcomeNotApplied {
+			reason = "not applied, the run stopped early"
+		}
+		failures.Failures = append(failures.Failures, failedVariable{
+			Type:             kind,
+			SourceID:         sources[r.Target],
+			DestinationID:    destinationID,
+			Key:              r.Key,
+			EnvironmentScope: r.Scope,
+			Reason:           reason,
+		})
+	}
+
+	if len(failures.Failures) == 0 {
+		if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %v", filePath, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(failures, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal failures: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(filePath), err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filePath, err)
+	}
+	log.Printf("%d variables were not migrated, retry them with --retry-from %s", len(failures.Failures), filePath)
+	return nil
+}
+
+// readFailures loads a failures file and checks it was written for the
+// instances in config
+func readFailures(config *utils.Config, filePath string) (*failuresFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read failures file: %v", err)
+	}
+
+	var failures failuresFile
+	if err := json.Unmarshal(data, &failures); err != nil {
+		return nil, fmt.Errorf("failed to parse failures file %s: %v", filePath, err)
+	}
+
+	if !utils.SameBaseURL(failures.SourceBaseURL, config.SourceBaseURL) || !utils.SameBaseURL(failures.DestinationBaseURL, config.DestinationBaseURL) {
+		return nil, fmt.Errorf("%s was written for %s -> %s, but the migration runs %s -> %s",
+			filePath, failures.SourceBaseURL, failures.DestinationBaseURL, config.SourceBaseURL, config.DestinationBaseURL)
+	}
+
+	for i, failure := range failures.Failures {
+		if (failure.Type != "project" && failure.Type != "group") || failure.SourceID == "" || failure.DestinationID == "" || failure.Key == "" {
+			return nil, fmt.Errorf("entry %d of %s needs a type (project or group), source_id, destination_id and key", i+1, filePath)
+		}
+	}
+	return &failures, nil
+}
+
+// retryFailures migrates the variables listed in a failures file again,
+// fetching their current values from the source. It returns the summary
+// target to source ID mapping for writeFailures.
+func retryFailures(ctx context.Context, config *utils.Config, failures *failuresFile) map[string]string {
+	type pair struct{ kind, sourceID, destinationID string }
+	var pairs []pair
+	wanted := make(map[pair]map[string]bool)
+	for _, failure := range failures.Failures {
+		p := pair{failure.Type, failure.SourceID, failure.DestinationID}
+		if wanted[p] == nil {
+			wanted[p] = make(map[string]bool)
+			pairs = append(pairs, p)
+		}
+		scope := failure.EnvironmentScope
+		if scope == "" {
+			scope = "*"
+		}
+		wanted[p][variableID(failure.Key, scope)] = true
+	}
+
+	sources := make(map[string]string)
+	for _, p := range pairs {
+		if ctx.Err() != nil {
+			break
+		}
+		target := p.kind + " " + p.destinationID
+		sources[target] = p.sourceID
+
+		var sourceVars []map[string]interface{}
+		var err error
+		if p.kind == "group" {
+			sourceVars, err = getVariablesForGroup(config, p.sourceID)
+		} else {
+			sourceVars, err = getVariablesForProject(config, p.sourceID)
+		}
+		if err != nil {
+			for id := range wanted[p] {
+				key, scope, _ := strings.Cut(id, "@")
+				variablesSummary.add(variableResult{Target: target, Key: key, Scope: scope, Outcome: outcomeFailed, Detail: err.Error()})
+			}
+			continue
+		}
+
+		var variables []interface{}
+		for _, variable := range sourceVars {
+			key, _ := variable["key"].(string)
+			id := variableID(key, variableScope(variable))
+			if wanted[p][id] {
+				variables = append(variables, variable)
+				delete(wanted[p], id)
+			}
+		}
+		for id := range wanted[p] {
+			key, scope, _ := strings.Cut(id, "@")
+			variablesSummary.add(variableResult{Target: target, Key: key, Scope: scope, Outcome: outcomeFailed,
+				Detail: fmt.Sprintf("no longer exists on source %s %s", p.kind, p.sourceID)})
+		}
+
+		utils.WithFields(targetFields(target)).Infof("Retrying %d variables from %s %s for %s", len(variables), p.kind, p.sourceID, target)
+		withProjectTimeout(ctx, target, func(ctx context.Context) {
+			if p.kind == "group" {
+				createVariablesForGroup(ctx, config, p.destinationID, variables)
+			} else {
+				createVariablesForProject(ctx, config, p.destinationID, variables)
+			}
+		})
+	}
+	return sources
+}
@@ -1,14 +1,22 @@
 package cmd
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
@@ -18,9 +26,78 @@ import (
 
 // Constants for API and pagination
 const (
-	defaultPerPage = 100
-	maxRetries     = 3
-	retryDelay     = 2 * time.Second
+	defaultPerPage = utils.DefaultPerPage
+	// defaultMaxRetries is the default --max-retries
+	defaultMaxRetries = 3
+	// retryDelay and maxRetryDelay are the default --retry-base-delay and --retry-max-delay
+	retryDelay    = 2 * time.Second
+	maxRetryDelay = 30 * time.Second
+	// keysetThreshold is the project count above which keyset pagination is
+	// used; offset pages slow down badly past GitLab's 10,000 item count limit
+	keysetThreshold = 10000
+	// keysetOrderBy is the only ordering GitLab supports for keyset pagination of projects
+	keysetOrderBy = "id"
+)
+
+// Output formats supported by the get commands
+const (
+	outputFormatJSON      = "json"
+	outputFormatTable     = "table"
+	outputFormatEnvExport = "env-export"
+)
+
+// Keys for recursive variable exports
+const (
+	keyByID   = "id"
+	keyByPath = "path"
+)
+
+// Project orderings accepted by --sort-projects for recursive variable runs
+const (
+	sortProjectsByID   = "id"
+	sortProjectsByPath = "path"
+	sortProjectsByName = "name"
+)
+
+// Server-side orderings accepted by --order-by
+var (
+	projectOrderFields = []string{"id", "name", "path", "created_at", "updated_at", "last_activity_at"}
+	groupOrderFields   = []string{"id", "name", "path"}
+)
+
+// Values accepted by --sort-order
+const (
+	sortAscending  = "asc"
+	sortDescending = "desc"
+)
+
+var outputFormat string
+var sortProjectsBy string
+var orderBy string
+var sortOrder string
+var compactOutput bool
+var gzipOutput bool
+var allowEmptyOutput bool
+var perPage int
+
+// variablesConcurrency is the number of projects or groups whose variables are
+// fetched at the same time by recursive and list runs
+var variablesConcurrency = snapshotConcurrency
+
+// gzipExtension marks compressed output and input files
+const gzipExtension = ".gz"
+
+var keyBy string
+var tableSort string
+var tableColumns string
+
+// Default table columns for each list type
+var (
+	groupColumns           = []string{"id", "full_path", "name", "visibility"}
+	projectColumns         = []string{"id", "path_with_namespace", "name", "visibility"}
+	variableColumns        = []string{"key", "environment_scope", "variable_type", "protected", "masked"}
+	projectVariableColumns = []string{"project_id", "project_name", "key", "environment_scope", "protected", "masked"}
+	groupVariableColumns   = []string{"group_id", "full_path", "key", "environment_scope", "protected", "masked"}
 )
 
 // getCmd is the parent command for "get" operations
@@ -38,107 +115,181 @@ var getProjectsCmd = &cobra.Command{
 	Short: "Retrieve GitLab projects",
 	Long: `Retrieve a list of GitLab projects based on your configuration.
 This command will fetch all accessible projects from the specified GitLab instance.
-The results can be saved to a file using the --output flag.`,
-	Run: func(cmd *cobra.Command, args []string) {
+The results can be saved to a file using the --output flag.
+
+Projects are ordered by the server using --order-by and --sort-order
+(id ascending by default), so repeated exports diff cleanly.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if err := validateListOrder(projectOrderFields); err != nil {
+			return err
+		}
+
 		config, err := loadConfig()
 		if err != nil {
-			log.Printf("Error loading config: %v", err)
-			return
+			return fmt.Errorf("failed to load config: %v", err)
 		}
 
-		var projects interface{}
+		var projects []map[string]interface{}
 		if groupID != "" {
-			projects = getProjectsForGroup(config, groupID)
+			projects, err = getProjectsForGroup(config, groupID)
 		} else {
-			projects = executeGitLabAPIRequest(config.SourceBaseURL, config.SourceAccessToken, "projects")
+			projects, err = getAllProjects(config.SourceBaseURL, config.SourceAccessToken)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch projects: %w", err)
 		}
 
-		if err := utils.EnsureDataDir(); err != nil {
-			log.Printf("Error: %v", err)
-			return
+		if outputFormat == outputFormatTable {
+			// Keep the server's --order-by order unless --sort is given
+			return printTable(projects, projectColumns, "")
 		}
 
 		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
 			outputFile = utils.GenerateOutputFileName("projects", groupID, "", isDestination, false)
 		}
 
 		if err := saveOutputToFile(projects, outputFile); err != nil {
-			log.Printf("Error saving output to file: %v", err)
-			return
+			return fmt.Errorf("failed to save output: %v", err)
 		}
+		return nil
 	},
 }
 
+// stdoutOutput is the --output value that writes the JSON to standard output
+const stdoutOutput = "-"
+
+// saveOutputToFile writes the output of a get command to filePath, see
+// writeOutputFile. Nil or empty data is refused unless --allow-empty is set,
+// so a fetch that came back with nothing doesn't replace a good export.
 func saveOutputToFile(data interface{}, filePath string) error {
+	if !allowEmptyOutput && isEmptyOutput(data) {
+		return fmt.Errorf("nothing to write to %s (use --allow-empty to write empty output)", filePath)
+	}
+	return writeOutputFile(data, filePath)
+}
+
+// isEmptyOutput reports whether data is nil or an empty slice or map
+func isEmptyOutput(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	value := reflect.ValueOf(data)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
+// writeOutputFile writes data as JSON to filePath, gzip-compressed when the
+// path ends in .gz or --gzip is set (which adds the extension). A filePath of
+// "-" writes to standard output instead, e.g. for piping into jq.
+func writeOutputFile(data interface{}, filePath string) error {
+	if filePath == stdoutOutput {
+		return writeOutput(os.Stdout, data, gzipOutput)
+	}
+
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	if gzipOutput && !strings.HasSuffix(filePath, gzipExtension) {
+		filePath += gzipExtension
+	}
+
 	f, err := os.Create(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer f.Close()
 
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to encode data: %w", err)
+	if err := writeOutput(f, data, strings.HasSuffix(filePath, gzipExtension)); err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
 	}
 
 	log.Printf("Successfully saved output to %s", filePath)
 	return nil
 }
 
+// writeOutput encodes data as JSON to w, indented unless --compact is set.
+// Output is compressed while it is written, so a large export is never held
+// in memory uncompressed.
+func writeOutput(w io.Writer, data interface{}, compress bool) error {
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+
+	encoder := json.NewEncoder(w)
+	if !compactOutput {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to compress: %w", err)
+		}
+	}
+	return nil
+}
+
 // getProjectsForGroup retrieves projects for a specific group
-func getProjectsForGroup(config *utils.Config, groupID string) []map[string]interface{} {
+func getProjectsForGroup(config *utils.Config, groupID string) ([]map[string]interface{}, error) {
 	var url string
 	var accessToken string
 	if isDestination {
-		url = fmt.Sprintf("%s/api/v4/groups/%s/projects", config.DestinationBaseURL, groupID)
+		url = fmt.Sprintf("%s/api/v4/groups/%s/projects?%s", config.DestinationBaseURL, groupID, listOrderQuery())
 		accessToken = config.DestinationAccessToken
 	} else {
-		url = fmt.Sprintf("%s/api/v4/groups/%s/projects", config.SourceBaseURL, groupID)
+		url = fmt.Sprintf("%s/api/v4/groups/%s/projects?%s", config.SourceBaseURL, groupID, listOrderQuery())
 		accessToken = config.SourceAccessToken
 	}
-	req, err := http.NewRequest("GET", url, nil)
+	projects, err := paginateCached(newGitLabClient("", accessToken), url)
 	if err != nil {
-		log.Printf("Error creating request for group %s: %v", groupID, err)
-		return nil
+		return nil, notFoundError(fmt.Errorf("error fetching projects for group %s: %w", groupID, err), "group", groupID)
 	}
-	req.Header.Set("PRIVATE-TOKEN", accessToken)
 
-	httpConfig := utils.NewDefaultConfig()
-	httpConfig.SkipTLSVerification = true
-	client := utils.CreateHTTPClient(httpConfig)
+	return projects, nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error fetching projects for group %s: %v", groupID, err)
-		return nil
-	}
-	defer resp.Body.Close()
+// getAllProjects retrieves every project visible on an instance, switching to
+// keyset pagination when the instance has more than keysetThreshold projects.
+// GitLab only supports keyset pagination of projects ordered by id, so another
+// --order-by falls back to id then, with a warning.
+func getAllProjects(baseURL, accessToken string) ([]map[string]interface{}, error) {
+	listURL := fmt.Sprintf("%s/api/v4/projects?%s", baseURL, listOrderQuery())
+	client := utils.CreateHTTPClient(newHTTPClientConfig())
 
-	body, err := io.ReadAll(resp.Body)
+	total, known, err := utils.CountItems(client, listURL, accessToken)
 	if err != nil {
-		log.Printf("Error reading projects response for group %s: %v", groupID, err)
-		return nil
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Error fetching projects for group %s: %s", groupID, body)
-		return nil
+		return nil, err
 	}
-
-	var projects []map[string]interface{}
-	if err := json.Unmarshal(body, &projects); err != nil {
-		log.Printf("Error parsing projects JSON for group %s: %v", groupID, err)
-		return nil
+	if !known || total > keysetThreshold {
+		log.Printf("Large project list, using keyset pagination")
+		if orderBy != keysetOrderBy {
+			utils.Warnf("--order-by %s isn't supported with keyset pagination, ordering projects by %s instead", orderBy, keysetOrderBy)
+			query := url.Values{"order_by": {keysetOrderBy}, "sort": {sortOrder}}.Encode()
+			listURL = fmt.Sprintf("%s/api/v4/projects?%s", baseURL, query)
+		}
+		return utils.PaginateKeyset(client, listURL, accessToken, pageSize())
 	}
-
-	return projects
+	return paginateCached(newGitLabClient("", accessToken), listURL)
 }
 
 // getGroupsCmd retrieves groups
@@ -147,12 +298,21 @@ var getGroupsCmd = &cobra.Command{
 	Short: "Retrieve GitLab groups",
 	Long: `Retrieve a list of GitLab groups based on your configuration.
 This command will fetch all accessible groups from the specified GitLab instance.
-The results can be saved to a file using the --output flag.`,
-	Run: func(cmd *cobra.Command, args []string) {
+The results can be saved to a file using the --output flag.
+
+Groups are ordered by the server using --order-by and --sort-order
+(id ascending by default).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if err := validateListOrder(groupOrderFields); err != nil {
+			return err
+		}
+
 		config, err := loadConfig()
 		if err != nil {
-			log.Printf("Error loading config: %v", err)
-			return
+			return fmt.Errorf("failed to load config: %v", err)
 		}
 
 		var accessToken string
@@ -165,21 +325,26 @@ The results can be saved to a file using the --output flag.`,
 			baseURL = config.SourceBaseURL
 		}
 
-		groups := executeGitLabAPIRequest(baseURL, accessToken, "groups")
+		groups, err := executeGitLabAPIRequest(baseURL, accessToken, "groups?"+listOrderQuery())
+		if err != nil {
+			return fmt.Errorf("failed to fetch groups: %w", err)
+		}
 
-		if err := utils.EnsureDataDir(); err != nil {
-			log.Printf("Error: %v", err)
-			return
+		if outputFormat == outputFormatTable {
+			return printTable(groups, groupColumns, "")
 		}
 
 		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
 			outputFile = utils.GenerateOutputFileName("groups", "", "", isDestination, false)
 		}
 
 		if err := saveOutputToFile(groups, outputFile); err != nil {
-			log.Printf("Error saving output to file: %v", err)
-			return
+			return fmt.Errorf("failed to save output: %v", err)
 		}
+		return nil
 	},
 }
 
@@ -192,247 +357,682 @@ This command can fetch variables from:
 - A specific group (using --group-id)
 - A specific project (using --project-id)
 - All projects within a group (using --group-id with --recursive)
-The results can be saved to a file using the --output flag.`,
-	Run: func(cmd *cobra.Command, args []string) {
+The results can be saved to a file using the --output flag.
+
+Recursive output is keyed by project ID by default. IDs differ between
+instances, so use --key-by path to key it by path_with_namespace instead.
+Every entry includes the project ID, name and path either way.
+
+--project-ids fetches an explicit list of projects instead, given as
+"12,34,56" or "@file" with one ID per line, and uses the recursive format.
+--group-ids does the same for groups; entries are keyed by group ID.
+--concurrency sets how many projects or groups are fetched at the same time
+(default 5); the output is the same whatever the value.
+
+--output-format env-export prints the variables of a project or group as
+"export KEY='VALUE'" lines that can be sourced by a shell. Only variables for
+all environments (scope *) are exported; scoped and file variables are listed
+in comments without their values.
+
+--mask-check lists the masked variables whose values GitLab can't mask (too
+short, multi-line or with unsupported characters) instead of saving them, so
+they can be fixed before a migration. Only keys are printed, never values.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(outputFormatEnvExport); err != nil {
+			return err
+		}
+
 		config, err := loadConfig()
 		if err != nil {
-			log.Printf("Error loading config: %v", err)
-			return
+			return fmt.Errorf("failed to load config: %v", err)
 		}
 
-		if groupID == "" && projectID == "" {
-			log.Println("Error: Either --group or --project must be provided.")
-			return
+		if groupID == "" && projectID == "" && projectIDList == "" && groupIDList == "" {
+			return fmt.Errorf("either --group, --project, --project-ids or --group-ids must be provided")
 		}
 
-		if err := utils.EnsureDataDir(); err != nil {
-			log.Printf("Error: %v", err)
-			return
+		projectIDs, groupIDs, err := parseIDListFlags()
+		if err != nil {
+			return err
 		}
 
-		if outputFile == "" {
-			outputFile = utils.GenerateOutputFileName("variables", groupID, projectID, isDestination, recursive)
+		if keyBy != keyByID && keyBy != keyByPath {
+			return fmt.Errorf("unsupported --key-by value %q (use %s or %s)", keyBy, keyByID, keyByPath)
 		}
 
-		if groupID != "" {
+		if err := validateProjectSort(sortProjectsBy); err != nil {
+			return err
+		}
+
+		if variablesConcurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+
+		if err := setupKeyFilter(); err != nil {
+			return err
+		}
+
+		if projectID != "" && groupID == "" && recursive {
+			return fmt.Errorf("recursive mode is not supported for individual projects")
+		}
+
+		if outputFormat == outputFormatEnvExport && (recursive || len(projectIDs) > 0 || len(groupIDs) > 0) {
+			return fmt.Errorf("--output-format %s writes the variables of a single project or group", outputFormatEnvExport)
+		}
+
+		var variables interface{}
+		var columns []string
+		if len(projectIDs) > 0 || len(groupIDs) > 0 {
+			var variablesByOwner map[string]map[string]interface{}
+			if len(projectIDs) > 0 {
+				variablesByOwner, err = getAllVariablesForProjects(config, projectIDs, keyBy)
+				columns = projectVariableColumns
+			} else {
+				variablesByOwner, err = getAllVariablesForGroups(config, groupIDs)
+				columns = groupVariableColumns
+			}
+			if err != nil {
+				return err
+			}
+			if outputFormat == outputFormatTable {
+				variables = flattenVariableEntries(variablesByOwner)
+			} else {
+				variables = variablesByOwner
+			}
+		} else if groupID != "" {
 			if recursive {
-				variablesByProject := getAllVariablesForGroupProjects(config, groupID)
-				if err := saveOutputToFile(variablesByProject, outputFile); err != nil {
-					log.Printf("Error saving output to file: %v", err)
-					return
+				variablesByProject, err := getAllVariablesForGroupProjects(config, groupID, keyBy)
+				if err != nil {
+					return err
+				}
+				if outputFormat == outputFormatTable {
+					variables = flattenVariableEntries(variablesByProject)
+					columns = projectVariableColumns
+				} else {
+					variables = variablesByProject
 				}
 			} else {
-				variables := getVariablesForGroup(config, groupID)
-				if err := saveOutputToFile(variables, outputFile); err != nil {
-					log.Printf("Error saving output to file: %v", err)
-					return
+				if variables, err = getVariablesForGroup(config, groupID); err != nil {
+					return err
 				}
+				columns = variableColumns
 			}
-		} else if projectID != "" {
-			if recursive {
-				log.Println("Error: Recursive mode is not supported for individual projects.")
-				return
+		} else {
+			if variables, err = getVariablesForProject(config, projectID); err != nil {
+				return err
 			}
-			variables := getVariablesForProject(config, projectID)
-			if err := saveOutputToFile(variables, outputFile); err != nil {
-				log.Printf("Error saving output to file: %v", err)
-				return
+			columns = variableColumns
+		}
+
+		if maskCheck {
+			owner := "project " + projectID
+			if groupID != "" {
+				owner = "group " + groupID
 			}
+			printMaskCheck(variables, owner)
+			return nil
 		}
+
+		if outputFormat == outputFormatTable {
+			return printTable(variables, columns, "key")
+		}
+
+		if outputFormat == outputFormatEnvExport {
+			vars, _ := variables.([]map[string]interface{})
+			if err := writeEnvExport(os.Stdout, vars); err != nil {
+				return fmt.Errorf("failed to write variables: %v", err)
+			}
+			return nil
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("variables", groupID, projectID, isDestination, recursive)
+		}
+
+		if err := saveOutputToFile(variables, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
 	},
 }
 
-// getAllVariablesForGroupProjects retrieves variables for all projects in a group
-func getAllVariablesForGroupProjects(config *utils.Config, groupID string) map[string]map[string]interface{} {
-	projects := getProjectsForGroup(config, groupID)
+// flattenVariableEntries turns a recursive export into one row per variable,
+// tagged with the fields of the project or group entry it belongs to
+func flattenVariableEntries(variablesByOwner map[string]map[string]interface{}) []map[string]interface{} {
+	var rows []map[string]interface{}
+	for _, entry := range variablesByOwner {
+		variables, _ := entry["variables"].([]map[string]interface{})
+		for _, variable := range variables {
+			row := make(map[string]interface{})
+			for k, v := range entry {
+				if k != "variables" {
+					row[k] = v
+				}
+			}
+			for k, v := range variable {
+				row[k] = v
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// parseIDListFlags parses --project-ids and --group-ids, which can't be combined
+// with each other or with --project and --group
+func parseIDListFlags() (projectIDs, groupIDs []string, err error) {
+	if projectIDList == "" && groupIDList == "" {
+		return nil, nil, nil
+	}
+	if projectIDList != "" && groupIDList != "" {
+		return nil, nil, fmt.Errorf("--project-ids and --group-ids can't be combined")
+	}
+	if projectID != "" || groupID != "" || recursive {
+		return nil, nil, fmt.Errorf("--project-ids and --group-ids replace --project, --group and --recursive")
+	}
+	if projectIDList != "" {
+		projectIDs, err = utils.ParseIDList(projectIDList)
+		return projectIDs, nil, err
+	}
+	groupIDs, err = utils.ParseIDList(groupIDList)
+	return nil, groupIDs, err
+}
+
+// validateOutputFormat checks the --output-format flag value; extra lists the
+// formats a command supports besides json and table
+func validateOutputFormat(extra ...string) error {
+	supported := append([]string{outputFormatJSON, outputFormatTable}, extra...)
+	if slices.Contains(supported, outputFormat) {
+		return nil
+	}
+	last := len(supported) - 1
+	return fmt.Errorf("unsupported output format %q (use %s or %s)", outputFormat, strings.Join(supported[:last], ", "), supported[last])
+}
 
+// validateListOrder checks the --order-by and --sort-order flag values
+func validateListOrder(fields []string) error {
+	if !slices.Contains(fields, orderBy) {
+		return fmt.Errorf("unsupported --order-by value %q (use %s)", orderBy, strings.Join(fields, ", "))
+	}
+	if sortOrder != sortAscending && sortOrder != sortDescending {
+		return fmt.Errorf("unsupported --sort-order value %q (use %s or %s)", sortOrder, sortAscending, sortDescending)
+	}
+	return nil
+}
+
+// listOrderQuery returns the order_by and sort query parameters for --order-by and --sort-order
+func listOrderQuery() string {
+	return url.Values{"order_by": {orderBy}, "sort": {sortOrder}}.Encode()
+}
+
+// printTable renders list output as an aligned table on stdout, applying
+// the --columns and --sort overrides on top of the given defaults
+func printTable(data interface{}, defaultColumns []string, defaultSort string) error {
+	rows, err := utils.ToRows(data)
+	if err != nil {
+		return err
+	}
+
+	columns := defaultColumns
+	if tableColumns != "" {
+		columns = utils.ParseColumns(tableColumns)
+	}
+	sortBy := defaultSort
+	if tableSort != "" {
+		sortBy = tableSort
+	}
+
+	return utils.RenderTable(os.Stdout, rows, utils.TableOptions{
+		Columns:  columns,
+		SortBy:   sortBy,
+		MaxWidth: utils.TerminalWidth(),
+	})
+}
+
+// getAllVariablesForGroupProjects retrieves variables for all projects in a group.
+// The result is keyed by project ID, or by path_with_namespace when keyBy is
+// keyByPath; each entry carries both along with the project name.
+func getAllVariablesForGroupProjects(config *utils.Config, groupID string, keyBy string) (map[string]map[string]interface{}, error) {
+	projects, err := getProjectsForGroup(config, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return variablesByProject(config, projects, keyBy)
+}
+
+// getAllVariablesForProjects retrieves variables for an explicit list of
+// projects, in the format of getAllVariablesForGroupProjects. The projects are
+// looked up several at a time; those that don't exist are logged and left out.
+func getAllVariablesForProjects(config *utils.Config, projectIDs []string, keyBy string) (map[string]map[string]interface{}, error) {
+	baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+	if isDestination {
+		baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+	}
+
+	found := make([]map[string]interface{}, len(projectIDs))
+	err := forEachUntilError(len(projectIDs), variablesConcurrency, func(i int) error {
+		var project map[string]interface{}
+		if err := getGitLabJSON(fmt.Sprintf("%s/api/v4/projects/%s", baseURL, projectIDs[i]), accessToken, &project); err != nil {
+			if !errors.Is(err, utils.ErrNotFound) {
+				return fmt.Errorf("error fetching project %s: %w", projectIDs[i], err)
+			}
+			newScopedLogger("project "+projectIDs[i], utils.Fields{"project_id": projectIDs[i]}, false).Printf("Project not found, leaving it out")
+			return nil
+		}
+		found[i] = project
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []map[string]interface{}
+	for _, project := range found {
+		if project != nil {
+			projects = append(projects, project)
+		}
+	}
+	return variablesByProject(config, projects, keyBy)
+}
+
+// getAllVariablesForGroups retrieves the variables of an explicit list of
+// groups, several at a time, keyed by group ID. Each entry carries the group
+// ID and full path. An error is returned when the variables of any group
+// can't be fetched.
+func getAllVariablesForGroups(config *utils.Config, groupIDs []string) (map[string]map[string]interface{}, error) {
+	baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+	if isDestination {
+		baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+	}
+
+	var mu sync.Mutex
+	variablesByGroup := make(map[string]map[string]interface{})
+	err := forEachUntilError(len(groupIDs), variablesConcurrency, func(i int) error {
+		id := groupIDs[i]
+		logger := newScopedLogger("group "+id, utils.Fields{"group_id": id}, true)
+		defer logger.Flush()
+
+		var group namespaceInfo
+		if err := getGitLabJSON(fmt.Sprintf("%s/api/v4/groups/%s", baseURL, id), accessToken, &group); err != nil {
+			return notFoundError(fmt.Errorf("error fetching group %s: %w", id, err), "group", id)
+		}
+		variables, err := getVariablesForGroup(config, id)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Fetched %d variables of %s", len(variables), group.FullPath)
+
+		mu.Lock()
+		defer mu.Unlock()
+		variablesByGroup[id] = map[string]interface{}{
+			"group_id":  group.ID,
+			"full_path": group.FullPath,
+			"variables": variables,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return variablesByGroup, nil
+}
+
+// variablesByProject fetches the variables of each project, see
+// getAllVariablesForGroupProjects. An error is returned when the variables of
+// any project can't be fetched, rather than exporting them as missing.
+func variablesByProject(config *utils.Config, projects []map[string]interface{}, keyBy string) (map[string]map[string]interface{}, error) {
 	var variablesByProject = make(map[string]map[string]interface{})
-	for _, project := range projects {
+	sortProjectList(projects, sortProjectsBy)
+
+	// Fetch the variables of several projects at a time, each into its own
+	// slot, so the map below is built in project order whatever finishes first
+	fetched := make([][]map[string]interface{}, len(projects))
+	err := forEachUntilError(len(projects), variablesConcurrency, func(i int) error {
+		projectID := int(math.Round(projects[i]["id"].(float64)))
+		var err error
+		fetched[i], err = getVariablesForProject(config, fmt.Sprintf("%d", projectID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, project := range projects {
 		projectID := int(math.Round(project["id"].(float64)))
 		projectName := project["name"].(string)
+		projectPath, _ := project["path_with_namespace"].(string)
+		variables := fetched[i]
+
+		key := fmt.Sprintf("%d", projectID)
+		if keyBy == keyByPath {
+			key = projectPath
+		}
+
+		// Create an entry combining the project details and its variables
+		variablesByProject[key] = map[string]interface{}{
+			"project_id":          projectID,
+			"project_name":        projectName,
+			"path_with_namespace": projectPath,
+			"variables":           variables,
+		}
+	}
+	return variablesByProject, nil
+}
+
+// validateProjectSort checks the --sort-projects flag value
+func validateProjectSort(by string) error {
+	switch by {
+	case sortProjectsByID, sortProjectsByPath, sortProjectsByName:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --sort-projects value %q (use %s, %s or %s)", by, sortProjectsByID, sortProjectsByPath, sortProjectsByName)
+	}
+}
+
+// sortProjectList orders projects as returned by the API in place by id,
+// path_with_namespace or name, falling back to the ID for equal names
+func sortProjectList(projects []map[string]interface{}, by string) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projectLess(projectSortValues(projects[i], "id", "path_with_namespace", "name"),
+			projectSortValues(projects[j], "id", "path_with_namespace", "name"), by)
+	})
+}
 
-		// Fetch variables for the project
-		variables := getVariablesForProject(config, fmt.Sprintf("%d", projectID))
+// sortedProjectKeys returns the keys of entries built by variablesByProject,
+// ordered like sortProjectList
+func sortedProjectKeys(entries map[string]map[string]interface{}, by string) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return projectLess(projectSortValues(entries[keys[i]], "project_id", "path_with_namespace", "project_name"),
+			projectSortValues(entries[keys[j]], "project_id", "path_with_namespace", "project_name"), by)
+	})
+	return keys
+}
 
-		// Create an entry combining the project name and its variables
-		variablesByProject[fmt.Sprintf("%d", projectID)] = map[string]interface{}{
-			"project_name": projectName,
-			"variables":    variables,
+// projectSortFields holds the values a project can be sorted by
+type projectSortFields struct {
+	id   float64
+	path string
+	name string
+}
+
+// projectSortValues reads the ID, path and name of a project from the given fields
+func projectSortValues(project map[string]interface{}, idField, pathField, nameField string) projectSortFields {
+	var fields projectSortFields
+	switch id := project[idField].(type) {
+	case float64:
+		fields.id = id
+	case int:
+		fields.id = float64(id)
+	}
+	fields.path, _ = project[pathField].(string)
+	fields.name, _ = project[nameField].(string)
+	return fields
+}
+
+func projectLess(a, b projectSortFields, by string) bool {
+	switch by {
+	case sortProjectsByPath:
+		if a.path != b.path {
+			return a.path < b.path
+		}
+	case sortProjectsByName:
+		if a.name != b.name {
+			return a.name < b.name
 		}
 	}
-	return variablesByProject
+	return a.id < b.id
 }
 
 // loadConfig loads the configuration from the specified or default location
 func loadConfig() (*utils.Config, error) {
 	if configPath == "" {
-		homeDir, err := os.UserHomeDir()
+		path, err := defaultConfigPath()
 		if err != nil {
-			return nil, fmt.Errorf("unable to find home directory: %v", err)
+			return nil, err
 		}
-		configPath = filepath.Join(homeDir, "config.yaml")
+		configPath = path
 	}
 
-	config, err := utils.LoadConfig(configPath)
+	config, err := utils.LoadConfigWithInstances(configPath, sourceInstance, destinationInstance)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config from %s: %v", configPath, err)
 	}
+	resolveInsecure(config)
+	resolveDataDir(config)
+	applyRateLimitRetries()
 	return config, nil
 }
 
-// executeGitLabAPIRequest makes a request to the GitLab API for a specific resource
-func executeGitLabAPIRequest(baseURL, token, resource string) interface{} {
-	client := &http.Client{Timeout: 30 * time.Second}
+// defaultConfigPath returns the config file to use without --config. A config
+// left at $HOME/config.yaml by an earlier version is used as long as none
+// exists at the current default.
+func defaultConfigPath() (string, error) {
+	path, err := utils.DefaultConfigPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
 
-	for retry := 0; retry < maxRetries; retry++ {
-		if retry > 0 {
-			log.Printf("Retrying request (attempt %d/%d)...", retry+1, maxRetries)
-			time.Sleep(retryDelay)
-		}
+	legacyPath, err := utils.LegacyConfigPath()
+	if err != nil {
+		return path, nil
+	}
+	if _, err := os.Stat(legacyPath); err == nil {
+		utils.Debugf("No config at %s, using %s of an earlier version", path, legacyPath)
+		return legacyPath, nil
+	}
+	return path, nil
+}
 
-		url := fmt.Sprintf("%s/api/v4/%s?per_page=%d", baseURL, resource, defaultPerPage)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			log.Printf("Error creating request: %v", err)
-			continue
-		}
+// pageSize returns the --per-page value to request list pages with. Values
+// over GitLab's maximum of 100 are clamped, and values below 1 fall back to
+// the default, since GitLab would otherwise apply its own default of 20.
+func pageSize() int {
+	switch {
+	case perPage < 1:
+		warnOnce(fmt.Sprintf("--per-page %d is not a valid page size, using %d", perPage, defaultPerPage))
+		return defaultPerPage
+	case perPage > utils.MaxPerPage:
+		warnOnce(fmt.Sprintf("--per-page %d is over GitLab's maximum, using %d per request", perPage, utils.MaxPerPage))
+		return utils.MaxPerPage
+	}
+	return perPage
+}
+
+// executeGitLabAPIRequest makes a request to the GitLab API for a specific
+// resource. List resources are followed page by page through X-Next-Page and
+// returned as one merged []interface{}; an error is returned when any page
+// fails, so callers never see a truncated list.
+func executeGitLabAPIRequest(baseURL, token, resource string) (interface{}, error) {
+	client := utils.CreateHTTPClient(newHTTPClientConfig())
 
-		req.Header.Set("PRIVATE-TOKEN", token)
-		resp, err := client.Do(req)
+	separator := "?"
+	if strings.Contains(resource, "?") {
+		separator = "&"
+	}
+
+	size := pageSize()
+	var items []interface{}
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/api/v4/%s%sper_page=%d&page=%d", baseURL, resource, separator, size, page)
+		result, header, err := requestGitLabAPIPage(client, url, token, resource)
 		if err != nil {
-			log.Printf("Error making request: %v", err)
-			continue
+			return nil, err
 		}
-		defer resp.Body.Close()
+		pageItems, isList := result.([]interface{})
+		if !isList {
+			return result, nil
+		}
+		items = append(items, pageItems...)
 
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			log.Printf("API request failed with status %d: %s", resp.StatusCode, string(body))
-			if retry == maxRetries-1 {
-				return nil
-			}
-			continue
+		// GitLab sends an empty X-Next-Page on the last page; when the header is
+		// missing, a short page means there is nothing more to fetch
+		nextPage, hasNextHeader := header["X-Next-Page"]
+		if hasNextHeader && (len(nextPage) == 0 || nextPage[0] == "") {
+			break
 		}
+		if (!hasNextHeader && len(pageItems) < size) || len(pageItems) == 0 {
+			break
+		}
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+	return items, nil
+}
 
-		var result interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			log.Printf("Error decoding response: %v", err)
-			continue
+// requestGitLabAPIPage fetches a single page for executeGitLabAPIRequest.
+// Network errors and 5xx responses are retried up to --max-retries times.
+// Rate limits and maintenance pages are already retried by utils.DoWithRetry,
+// and other error statuses are returned right away, as they won't go away.
+func requestGitLabAPIPage(client *http.Client, url, token, resource string) (interface{}, http.Header, error) {
+	attempts := maxRetries + 1
+	var lastErr error
+	for retry := 0; retry < attempts; retry++ {
+		if retry > 0 {
+			delay := retryBackoff().Delay(retry).Round(time.Millisecond)
+			log.Printf("Retrying request in %s (attempt %d/%d)...", delay, retry+1, attempts)
+			time.Sleep(delay)
 		}
 
-		return result
+		result, header, retryable, err := fetchGitLabAPIPage(client, url, token, resource)
+		if err == nil {
+			return result, header, nil
+		}
+		if !retryable {
+			return nil, nil, err
+		}
+		utils.Errorf("%v", err)
+		lastErr = err
 	}
 
-	log.Printf("Failed to execute GitLab API request after %d attempts", maxRetries)
-	return nil
+	return nil, nil, fmt.Errorf("request failed after %d attempts: %w", attempts, lastErr)
 }
 
-// getVariablesForGroup retrieves variables for a specific GitLab group
-func getVariablesForGroup(config *utils.Config, groupID string) []map[string]interface{} {
-	var url string
-	var accessToken string
-	if isDestination {
-		url = fmt.Sprintf("%s/api/v4/groups/%s/variables", config.DestinationBaseURL, groupID)
-		accessToken = config.DestinationAccessToken
-	} else {
-		url = fmt.Sprintf("%s/api/v4/groups/%s/variables", config.SourceBaseURL, groupID)
-		accessToken = config.SourceAccessToken
-	}
+// fetchGitLabAPIPage sends the request of one attempt of requestGitLabAPIPage.
+// retryable reports whether the error may go away when the request is sent
+// again.
+func fetchGitLabAPIPage(client *http.Client, url, token, resource string) (result interface{}, header http.Header, retryable bool, err error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		log.Printf("Error creating request for group %s: %v", groupID, err)
-		return nil
+		return nil, nil, false, fmt.Errorf("error creating request: %v", err)
 	}
-	req.Header.Set("PRIVATE-TOKEN", accessToken)
-
-	httpConfig := utils.NewDefaultConfig()
-	httpConfig.SkipTLSVerification = true
-	client := utils.CreateHTTPClient(httpConfig)
 
-	resp, err := client.Do(req)
+	req.Header.Set("PRIVATE-TOKEN", token)
+	resp, err := utils.DoWithRetry(client, req)
 	if err != nil {
-		log.Printf("Error fetching variables for group %s: %v", groupID, err)
-		return nil
+		// Maintenance has already been retried with a longer backoff, and a
+		// rejected token won't start working again
+		if errors.Is(err, utils.ErrMaintenance) || errors.Is(err, utils.ErrUnauthorized) {
+			return nil, nil, false, err
+		}
+		return nil, nil, true, fmt.Errorf("error making request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading variables response for group %s: %v", groupID, err)
-		return nil
+	// A missing group or project won't appear by retrying
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, false, fmt.Errorf("%s %w", strings.SplitN(resource, "?", 2)[0], utils.ErrNotFound)
 	}
-
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("Error fetching variables for group %s: %s", groupID, body)
-		return nil
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, resp.StatusCode >= 500, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
-	var variables []map[string]interface{}
-	if err := json.Unmarshal(body, &variables); err != nil {
-		log.Printf("Error parsing variables JSON for group %s: %v", groupID, err)
-		return nil
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, true, fmt.Errorf("error decoding response: %v", err)
 	}
-
-	return variables
+	return result, resp.Header, false, nil
 }
 
-// getVariablesForProject retrieves variables for a specific GitLab project
-func getVariablesForProject(config *utils.Config, projectID string) []map[string]interface{} {
+// notFoundError turns a 404 for the given group or project into a plain
+// "<kind> <id> not found" error, rather than treating it like an empty
+// result. It still matches utils.ErrNotFound; other errors, such as a
+// rejected token matching utils.ErrUnauthorized, are returned as they are.
+func notFoundError(err error, kind, id string) error {
+	if errors.Is(err, utils.ErrNotFound) {
+		return fmt.Errorf("%s %s %w", kind, id, utils.ErrNotFound)
+	}
+	return err
+}
 
+// getVariablesForGroup retrieves variables for a specific GitLab group
+func getVariablesForGroup(config *utils.Config, groupID string) ([]map[string]interface{}, error) {
 	var url string
 	var accessToken string
 	if isDestination {
-		url = fmt.Sprintf("%s/api/v4/projects/%s/variables", config.DestinationBaseURL, projectID)
+		url = fmt.Sprintf("%s/api/v4/groups/%s/variables", config.DestinationBaseURL, groupID)
 		accessToken = config.DestinationAccessToken
 	} else {
-		url = fmt.Sprintf("%s/api/v4/projects/%s/variables", config.SourceBaseURL, projectID)
+		url = fmt.Sprintf("%s/api/v4/groups/%s/variables", config.SourceBaseURL, groupID)
 		accessToken = config.SourceAccessToken
 	}
-	req, err := http.NewRequest("GET", url, nil)
+	variables, err := newGitLabClient("", accessToken).GetPaginated(url)
 	if err != nil {
-		log.Printf("Error creating request for project %s: %v", projectID, err)
-		return nil
+		return nil, notFoundError(fmt.Errorf("error fetching variables for group %s: %w", groupID, err), "group", groupID)
 	}
-	req.Header.Set("PRIVATE-TOKEN", accessToken)
-
-	httpConfig := utils.NewDefaultConfig()
-	httpConfig.SkipTLSVerification = true
-	client := utils.CreateHTTPClient(httpConfig)
 
-	resp, err := client.Do(req)
+	decoded, err := decodeVariables(variables)
 	if err != nil {
-		log.Printf("Error fetching variables for project %s: %v", projectID, err)
-		return nil
+		return nil, fmt.Errorf("error reading variables for group %s: %w", groupID, err)
 	}
-	defer resp.Body.Close()
+	return keyFilter.filterVariables(decoded), nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading variables response for project %s: %v", projectID, err)
-		return nil
+// getVariablesForProject retrieves variables for a specific GitLab project
+func getVariablesForProject(config *utils.Config, projectID string) ([]map[string]interface{}, error) {
+	var url string
+	var accessToken string
+	if isDestination {
+		url = fmt.Sprintf("%s/api/v4/projects/%s/variables", config.DestinationBaseURL, projectID)
+		accessToken = config.DestinationAccessToken
+	} else {
+		url = fmt.Sprintf("%s/api/v4/projects/%s/variables", config.SourceBaseURL, projectID)
+		accessToken = config.SourceAccessToken
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Error fetching variables for project %s: %s", projectID, body)
-		return nil
+	variables, err := newGitLabClient("", accessToken).GetPaginated(url)
+	if err != nil {
+		// Recursive runs call this once per project, see forEachUntilError
+		// for how they stop on a dead token
+		return nil, notFoundError(fmt.Errorf("error fetching variables for project %s: %w", projectID, err), "project", projectID)
 	}
 
-	var variables []map[string]interface{}
-	if err := json.Unmarshal(body, &variables); err != nil {
-		log.Printf("Error parsing variables JSON for project %s: %v", projectID, err)
-		return nil
+	decoded, err := decodeVariables(variables)
+	if err != nil {
+		return nil, fmt.Errorf("error reading variables for project %s: %w", projectID, err)
 	}
-
-	return variables
+	return keyFilter.filterVariables(decoded), nil
 }
 
 func init() {
 	// print the output to a file
-	getCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "Path to save the output as a JSON file")
+	getCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "Path to save the output as a JSON file, or - to write it to standard output")
 	// get from destination rather than source
 	getCmd.PersistentFlags().BoolVarP(&isDestination, "destination", "d", false, "Uses the destination config instead of the source")
+	// print a table to stdout instead of saving JSON
+	getCmd.PersistentFlags().StringVar(&outputFormat, "output-format", outputFormatJSON, "Output format: json (saved to a file), table (printed to stdout) or, for get variables, env-export (export KEY='VALUE' lines)")
+	getCmd.PersistentFlags().BoolVar(&compactOutput, "compact", false, "Write JSON output without indentation (smaller files for large exports)")
+	getCmd.PersistentFlags().IntVar(&perPage, "per-page", defaultPerPage, "Items requested per page of a list, at most 100; every page is still fetched")
+	getCmd.PersistentFlags().BoolVar(&gzipOutput, "gzip", false, "Write JSON output gzip-compressed to a .json.gz file")
+	getCmd.PersistentFlags().BoolVar(&allowEmptyOutput, "allow-empty", false, "Write the output even when nothing was found, e.g. a project without variables")
+	getCmd.PersistentFlags().StringVar(&tableSort, "sort", "", "Field to sort table rows by, e.g. id, name or key")
+	getCmd.PersistentFlags().StringVar(&tableColumns, "columns", "", "Comma-separated list of table columns (variable values are hidden unless \"value\" is listed)")
 	// filter projects by group
 	getProjectsCmd.Flags().StringVarP(&groupID, "group", "g", "", "The GitLab group ID to retrieve projects for")
+	// order lists on the server
+	getProjectsCmd.Flags().StringVar(&orderBy, "order-by", "id", "Order projects by "+strings.Join(projectOrderFields, ", "))
+	getProjectsCmd.Flags().StringVar(&sortOrder, "sort-order", sortAscending, "Sort order for --order-by: asc or desc")
+	getGroupsCmd.Flags().StringVar(&orderBy, "order-by", "id", "Order groups by "+strings.Join(groupOrderFields, ", "))
+	getGroupsCmd.Flags().StringVar(&sortOrder, "sort-order", sortAscending, "Sort order for --order-by: asc or desc")
 	// filter variables by project
 	getVariablesCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to retrieve variables for")
 	// filter variables by group
@@ -440,6 +1040,15 @@ func init() {
 
 	// recursively retrieve variables from all projects
 	getVariablesCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively retrieve variables from all projects in a group")
+	getVariablesCmd.Flags().StringVar(&projectIDList, "project-ids", "", "Comma-separated project IDs, or @file with one ID per line, to retrieve variables for")
+	getVariablesCmd.Flags().StringVar(&groupIDList, "group-ids", "", "Comma-separated group IDs, or @file with one ID per line, to retrieve variables for")
+	getVariablesCmd.Flags().StringVar(&keyBy, "key-by", keyByID, "Key recursive output by project id or path (path_with_namespace, portable across instances)")
+	getVariablesCmd.Flags().BoolVar(&maskCheck, "mask-check", false, "Report masked variables GitLab can't mask instead of saving the variables")
+	getVariablesCmd.Flags().IntVar(&variablesConcurrency, "concurrency", snapshotConcurrency, "Number of projects or groups whose variables are fetched at the same time")
+	getVariablesCmd.Flags().StringVar(&sortProjectsBy, "sort-projects", sortProjectsByID, "Order in which recursive runs fetch projects: id, path or name")
+	getVariablesCmd.Flags().StringArrayVar(&filterKeys, "filter-key", nil, "Only retrieve variables whose key matches this pattern: a glob like PROD_*, or a regular expression between slashes like /^PROD_/ (repeatable)")
+	getVariablesCmd.Flags().StringArrayVar(&excludeKeys, "exclude-key", nil, "Leave out variables whose key matches this glob or /regular expression/ (repeatable, wins over --filter-key)")
+	getVariablesCmd.Flags().StringVar(&filterEnvironmentScope, "environment-scope", "", "Only retrieve variables of this environment scope, e.g. production (* or empty for every scope)")
 
 	// Register subcommands
 	getCmd.AddCommand(getGroupsCmd)
@@ -0,0 +1,467 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// writeTestConfig writes a config pointing both instances at baseURL and
+// returns its path
+func writeTestConfig(t *testing.T, baseURL string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := fmt.Sprintf(`version: 1
+source_base_url: %[1]s
+source_access_token: srctoken
+destination_base_url: %[1]s
+destination_access_token: dsttoken
+`, baseURL)
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// executeCommand runs the root command with args and resets every flag it
+// set afterwards, so the flag globals don't leak into the next test
+func executeCommand(t *testing.T, args ...string) error {
+	t.Helper()
+	t.Cleanup(func() {
+		resetFlags(rootCmd)
+		rootCmd.SetArgs(nil)
+	})
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// captureStdout returns what fn writes to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+	fn()
+	writer.Close()
+	return <-output
+}
+
+func resetFlags(cmd *cobra.Command) {
+	resetFlagSet(cmd.PersistentFlags())
+	resetFlagSet(cmd.Flags())
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+func TestGetFailedFetchWritesNoOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"message":"500 Internal Server Error"}`)
+	}))
+	t.Cleanup(server.Close)
+	config := writeTestConfig(t, server.URL)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"groups", []string{"get", "groups"}},
+		{"projects", []string{"get", "projects", "-g", "1"}},
+		{"variables", []string{"get", "variables", "-p", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.json")
+			args := append([]string{"-c", config, "-q", "--max-retries", "0"}, tt.args...)
+			args = append(args, "-o", out)
+
+			if err := executeCommand(t, args...); err == nil {
+				t.Fatal("Execute returned no error, so the command would exit 0")
+			}
+			if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("output file exists after a failed fetch (stat error: %v)", err)
+			}
+		})
+	}
+}
+
+func TestGetUnauthorizedOnLaterPageWritesNoOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"401 Unauthorized"}`)
+			return
+		}
+		w.Header().Set("X-Next-Page", "2")
+		fmt.Fprint(w, `[{"id":1,"name":"api","path_with_namespace":"group/api"}]`)
+	}))
+	t.Cleanup(server.Close)
+	out := filepath.Join(t.TempDir(), "out.json")
+
+	// Execute exits with status 1 on any returned error
+	err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "--no-cache", "get", "projects", "-g", "1", "-o", out)
+	if !errors.Is(err, utils.ErrUnauthorized) {
+		t.Errorf("error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("output file exists after a 401 on page 2 (stat error: %v)", err)
+	}
+}
+
+// setRetryFlags sets --max-retries and --timeout and makes retries wait 1ms
+func setRetryFlags(t *testing.T, retries int, timeout time.Duration) {
+	maxRetries, requestTimeout = retries, timeout
+	retryBaseDelay, retryMaxDelay = time.Millisecond, time.Millisecond
+	t.Cleanup(func() {
+		maxRetries, requestTimeout = defaultMaxRetries, utils.DefaultTimeout
+		retryBaseDelay, retryMaxDelay = retryDelay, maxRetryDelay
+	})
+}
+
+func TestRequestGitLabAPIPageRetriesMaxRetriesTimes(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, `{"message":"502 Bad Gateway"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	for _, retries := range []int{0, 1, 3} {
+		t.Run(fmt.Sprintf("--max-retries %d", retries), func(t *testing.T) {
+			attempts.Store(0)
+			setRetryFlags(t, retries, utils.DefaultTimeout)
+
+			_, _, err := requestGitLabAPIPage(utils.CreateHTTPClient(newHTTPClientConfig()), server.URL+"/api/v4/groups", "srctoken", "groups")
+			if err == nil {
+				t.Fatal("requestGitLabAPIPage succeeded against a failing server")
+			}
+			if got := int(attempts.Load()); got != retries+1 {
+				t.Errorf("sent %d requests, want %d", got, retries+1)
+			}
+		})
+	}
+}
+
+func TestRequestGitLabAPIPageHonorsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	setRetryFlags(t, 1, 50*time.Millisecond)
+
+	start := time.Now()
+	_, _, err := requestGitLabAPIPage(utils.CreateHTTPClient(newHTTPClientConfig()), server.URL+"/api/v4/groups", "srctoken", "groups")
+	if err == nil {
+		t.Fatal("requestGitLabAPIPage succeeded against a server that never answers")
+	}
+	// Two attempts of 50ms each, far below the 5s the server would take
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("requestGitLabAPIPage took %s, the --timeout of 50ms wasn't applied", elapsed)
+	}
+}
+
+func TestDefaultConfigPathFallsBackToLegacyPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	current := filepath.Join(home, ".config", "gitlab-migrate", "config.yaml")
+	legacy := filepath.Join(home, "config.yaml")
+
+	check := func(want string) {
+		t.Helper()
+		path, err := defaultConfigPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != want {
+			t.Errorf("defaultConfigPath() = %s, want %s", path, want)
+		}
+	}
+
+	// Neither exists: init writes to the current default
+	check(current)
+
+	if err := os.WriteFile(legacy, []byte("version: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check(legacy)
+
+	if err := os.MkdirAll(filepath.Dir(current), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(current, []byte("version: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check(current)
+}
+
+func TestGetNotFoundIsReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+	}))
+	t.Cleanup(server.Close)
+	out := filepath.Join(t.TempDir(), "out.json")
+
+	err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "get", "variables", "-p", "999", "-o", out)
+	if err == nil || err.Error() != "project 999 not found" {
+		t.Errorf("error = %v, want \"project 999 not found\"", err)
+	}
+	if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("output file exists after a 404 (stat error: %v)", err)
+	}
+}
+
+func TestSaveOutputToFileRefusesEmptyOutput(t *testing.T) {
+	var nilSlice []map[string]interface{}
+	tests := []struct {
+		name string
+		data interface{}
+	}{
+		{"nil", nil},
+		{"nil slice", nilSlice},
+		{"empty slice", []map[string]interface{}{}},
+		{"empty map", map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.json")
+			if err := saveOutputToFile(tt.data, out); err == nil {
+				t.Error("saveOutputToFile accepted empty output")
+			}
+			if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("output file was written (stat error: %v)", err)
+			}
+		})
+	}
+}
+
+func TestSaveOutputToFileAllowEmpty(t *testing.T) {
+	allowEmptyOutput = true
+	t.Cleanup(func() { allowEmptyOutput = false })
+
+	out := filepath.Join(t.TempDir(), "out.json")
+	if err := saveOutputToFile([]map[string]interface{}{}, out); err != nil {
+		t.Fatalf("saveOutputToFile: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("output file was not written: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "[]" {
+		t.Errorf("output = %q, want []", got)
+	}
+}
+
+func TestSaveOutputToFileWritesOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	if err := saveOutputToFile([]map[string]interface{}{{"key": "A"}}, out); err != nil {
+		t.Fatalf("saveOutputToFile: %v", err)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("output file was not written: %v", err)
+	}
+}
+
+func TestVariablesByProjectFetchesConcurrentlyInOrder(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		var id int
+		if _, err := fmt.Sscanf(r.URL.Path, "/api/v4/projects/%d/variables", &id); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		// Later projects answer first, so finishing order differs from project order
+		time.Sleep(time.Duration(10-id) * 5 * time.Millisecond)
+		fmt.Fprintf(w, `[{"key":"KEY_%d","value":"%d"}]`, id, id)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := variablesConcurrency
+	variablesConcurrency = 3
+	t.Cleanup(func() { variablesConcurrency = previous })
+
+	var projects []map[string]interface{}
+	for id := 8; id >= 1; id-- {
+		projects = append(projects, map[string]interface{}{"id": float64(id), "name": fmt.Sprintf("p%d", id), "path_with_namespace": fmt.Sprintf("group/p%d", id)})
+	}
+	config := &utils.Config{SourceBaseURL: server.URL, SourceAccessToken: "srctoken"}
+
+	entries, err := variablesByProject(config, projects, keyByID)
+	if err != nil {
+		t.Fatalf("variablesByProject: %v", err)
+	}
+	if got := maxInFlight.Load(); got < 2 || got > 3 {
+		t.Errorf("%d requests in flight at most, want 2 to 3 with --concurrency 3", got)
+	}
+
+	keys := sortedProjectKeys(entries, sortProjectsByID)
+	if want := "1 2 3 4 5 6 7 8"; strings.Join(keys, " ") != want {
+		t.Errorf("keys = %v, want %s", keys, want)
+	}
+	for _, key := range keys {
+		variables := entries[key]["variables"].([]map[string]interface{})
+		if len(variables) != 1 || variables[0]["key"] != "KEY_"+key {
+			t.Errorf("project %s has variables %v, want KEY_%s", key, variables, key)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	t.Cleanup(func() { perPage = defaultPerPage })
+	tests := []struct {
+		perPage int
+		want    int
+	}{
+		{50, 50},
+		{1, 1},
+		{utils.MaxPerPage, utils.MaxPerPage},
+		{250, utils.MaxPerPage},
+		{0, defaultPerPage},
+		{-5, defaultPerPage},
+	}
+	for _, tt := range tests {
+		perPage = tt.perPage
+		if got := pageSize(); got != tt.want {
+			t.Errorf("pageSize() with --per-page %d = %d, want %d", tt.perPage, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteGitLabAPIRequestSendsPerPage(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if got := query.Get("per_page"); got != "100" {
+			t.Errorf("per_page = %q on page %s, want 100", got, query.Get("page"))
+		}
+		if got := query.Get("include_subgroups"); got != "true" {
+			t.Errorf("include_subgroups = %q, want the query of the resource kept", got)
+		}
+		pages = append(pages, query.Get("page"))
+		next := "2"
+		if query.Get("page") == "2" {
+			next = ""
+		}
+		w.Header().Set("X-Next-Page", next)
+		fmt.Fprintf(w, `[{"id":%s}]`, query.Get("page"))
+	}))
+	t.Cleanup(server.Close)
+
+	result, err := executeGitLabAPIRequest(server.URL, "srctoken", "groups/1/projects?include_subgroups=true")
+	if err != nil {
+		t.Fatalf("executeGitLabAPIRequest: %v", err)
+	}
+	if items, _ := result.([]interface{}); len(items) != 2 {
+		t.Errorf("result = %v, want the items of both pages", result)
+	}
+	if strings.Join(pages, " ") != "1 2" {
+		t.Errorf("pages requested: %v, want 1 2", pages)
+	}
+}
+
+func TestGetVariablesTellsNotFoundFromEmpty(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantNotFound bool
+	}{
+		{"missing project", http.StatusNotFound, `{"message":"404 Project Not Found"}`, true},
+		{"no variables", http.StatusOK, `[]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+			out := filepath.Join(t.TempDir(), "out.json")
+
+			err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "get", "variables", "-p", "5", "--allow-empty", "-o", out)
+			if errors.Is(err, utils.ErrNotFound) != tt.wantNotFound {
+				t.Fatalf("error = %v, want not found: %v", err, tt.wantNotFound)
+			}
+			if tt.wantNotFound {
+				if _, err := os.Stat(out); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("output file exists for a missing project (stat error: %v)", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("get variables: %v", err)
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("output file was not written: %v", err)
+			}
+			if got := strings.TrimSpace(string(data)); got != "[]" {
+				t.Errorf("output = %q, want []", got)
+			}
+		})
+	}
+}
+
+func TestGetWritesOutputToStdout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"key":"A","value":"1","environment_scope":"*"}]`)
+	}))
+	t.Cleanup(server.Close)
+	dataDir := filepath.Join(t.TempDir(), "data")
+
+	var err error
+	output := captureStdout(t, func() {
+		err = executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "--data-dir", dataDir, "get", "variables", "-p", "5", "-o", "-")
+	})
+	if err != nil {
+		t.Fatalf("get variables: %v", err)
+	}
+
+	var variables []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &variables); err != nil {
+		t.Fatalf("stdout is not the JSON output: %v\n%s", err, output)
+	}
+	if len(variables) != 1 || variables[0]["key"] != "A" {
+		t.Errorf("variables = %v, want A", variables)
+	}
+	if _, err := os.Stat(dataDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("data directory created with --output - (stat error: %v)", err)
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// snapshotConcurrency is the number of groups fetched at the same time by "get all"
+const snapshotConcurrency = 5
+
+// getAllCmd retrieves groups, their projects and all variables in one pass
+var getAllCmd = &cobra.Command{
+	Use:   "all",
+	Short: "Retrieve groups, projects and variables in a single snapshot",
+	Long: `Retrieve a full snapshot of a GitLab instance in one JSON file.
+The snapshot lists every group with its variables and its projects, and each
+project with its variables. Use --group to limit the snapshot to one group and
+its subgroups. This is useful as a single backup artifact before a migration.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat != outputFormatJSON {
+			return fmt.Errorf("get all only supports the %s output format", outputFormatJSON)
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL := config.SourceBaseURL
+		accessToken := config.SourceAccessToken
+		if isDestination {
+			baseURL = config.DestinationBaseURL
+			accessToken = config.DestinationAccessToken
+		}
+
+		groups, err := fetchSnapshotGroups(baseURL, accessToken, groupID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch groups: %v", err)
+		}
+
+		snapshot, err := buildSnapshot(config, groups)
+		if err != nil {
+			return err
+		}
+		snapshot["instance"] = baseURL
+		snapshot["generated_at"] = time.Now().UTC().Format(time.RFC3339)
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("all", groupID, "", isDestination, false)
+		}
+
+		if err := saveOutputToFile(snapshot, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// fetchSnapshotGroups lists the groups of the snapshot: groupID and its
+// descendants, or every group when groupID is empty
+func fetchSnapshotGroups(baseURL, accessToken, groupID string) ([]map[string]interface{}, error) {
+	if groupID == "" {
+		groups, err := executeGitLabAPIRequest(baseURL, accessToken, "groups")
+		if err != nil {
+			return nil, err
+		}
+		return utils.ToRows(groups)
+	}
+
+	group, err := executeGitLabAPIRequest(baseURL, accessToken, "groups/"+groupID)
+	if err != nil {
+		return nil, notFoundError(err, "group", groupID)
+	}
+	descendants, err := executeGitLabAPIRequest(baseURL, accessToken, "groups/"+groupID+"/descendant_groups")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := utils.ToRows([]interface{}{group})
+	if err != nil {
+		return nil, err
+	}
+	subgroups, err := utils.ToRows(descendants)
+	if err != nil {
+		return nil, err
+	}
+	return append(groups, subgroups...), nil
+}
+
+// buildSnapshot fetches the variables and projects (with their variables) of
+// every group, several groups at a time, and prints a summary of the totals.
+// The progress lines of each group are prefixed with its path and grouped.
+// An error is returned when anything fails to fetch, so a snapshot is never
+// missing part of the instance.
+func buildSnapshot(config *utils.Config, groups []map[string]interface{}) (map[string]interface{}, error) {
+	var mu sync.Mutex
+	var projectCount, groupVariableCount, projectVariableCount int
+
+	err := forEachUntilError(len(groups), snapshotConcurrency, func(i int) error {
+		group := groups[i]
+		id := fmt.Sprintf("%.0f", group["id"].(float64))
+
+		// Each group's lines are written together once it is done
+		logger := newScopedLogger(fmt.Sprint(group["full_path"]), utils.Fields{"group_id": id}, true)
+		defer logger.Flush()
+
+		groupVariables, err := getVariablesForGroup(config, id)
+		if err != nil {
+			return err
+		}
+		projects, err := getProjectsForGroup(config, id)
+		if err != nil {
+			return err
+		}
+
+		variableCount := 0
+		for _, project := range projects {
+			projectVariables, err := getVariablesForProject(config, fmt.Sprintf("%.0f", project["id"].(float64)))
+			if err != nil {
+				return err
+			}
+			project["variables"] = projectVariables
+			variableCount += len(projectVariables)
+			logger.With(utils.Fields{"project_id": project["id"]}).Infof("Fetched project %v: %d variables", project["path_with_namespace"], len(projectVariables))
+		}
+
+		group["variables"] = groupVariables
+		group["projects"] = projects
+		logger.Printf("Fetched group: %d variables, %d projects", len(groupVariables), len(projects))
+
+		mu.Lock()
+		defer mu.Unlock()
+		projectCount += len(projects)
+		groupVariableCount += len(groupVariables)
+		projectVariableCount += variableCount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Snapshot: %d groups, %d projects, %d group variables, %d project variables",
+		len(groups), projectCount, groupVariableCount, projectVariableCount)
+
+	return map[string]interface{}{"groups": groups}, nil
+}
+
+// forEachUntilError is forEachConcurrently for calls that can fail. Once a
+// call fails because the access token was rejected, the calls not started yet
+// are skipped. The first error is returned, along with how many others failed.
+func forEachUntilError(count, workers int, fn func(i int) error) error {
+	var mu sync.Mutex
+	var first error
+	var failed int
+	var unauthorized atomic.Bool
+	forEachConcurrently(count, workers, func(i int) {
+		if unauthorized.Load() {
+			return
+		}
+		err := fn(i)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, utils.ErrUnauthorized) {
+			unauthorized.Store(true)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if first == nil {
+			first = err
+		}
+		failed++
+	})
+	if failed > 1 {
+		return fmt.Errorf("%w (and %d more errors)", first, failed-1)
+	}
+	return first
+}
+
+// forEachConcurrently calls fn for every index in [0, count) using at most
+// workers goroutines, and returns once all calls have finished
+func forEachConcurrently(count, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+func init() {
+	getAllCmd.Flags().StringVarP(&groupID, "group", "g", "", "The GitLab group ID to limit the snapshot to")
+	getCmd.AddCommand(getAllCmd)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultGroupSettings are copied unless --fields selects others. They are
+// independent of the instance and safe to apply to an existing group.
+var defaultGroupSettings = []string{
+	"description",
+	"project_creation_level",
+	"subgroup_creation_level",
+	"default_branch_protection",
+	"shared_runners_setting",
+}
+
+// optionalGroupSettings may be selected with --fields but are not copied by default
+var optionalGroupSettings = []string{
+	"visibility",
+	"request_access_enabled",
+	"lfs_enabled",
+	"auto_devops_enabled",
+	"emails_disabled",
+	"mentions_disabled",
+}
+
+var groupSettingsFields string
+var includeVisibility bool
+var groupSettingsDryRun bool
+
+// migrateGroupSettingsCmd copies general and CI/CD settings between groups
+var migrateGroupSettingsCmd = &cobra.Command{
+	Use:   "group-settings",
+	Short: "Copy group settings from a source group to a destination group",
+	Long: `Copy a safe subset of group settings from a source group (-g) to a
+destination group (-G) using PUT /groups/:id. By default these are copied:
+  ` + strings.Join(defaultGroupSettings, ", ") + `
+
+Use --fields to choose the settings to copy instead. Besides the defaults it
+accepts: ` + strings.Join(optionalGroupSettings, ", ") + `.
+Visibility is never changed unless it is listed in --fields or
+--include-visibility is set, since it can expose a group. Instance-specific
+settings (paths, runners tokens, LDAP, SAML, ...) are not supported.
+
+Only settings that differ are sent. Use --dry-run to list them without
+changing the destination group.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if groupID == "" || destinationGroupID == "" {
+			return fmt.Errorf("the source group (-g) and destination group (-G) must be provided")
+		}
+
+		fields, err := selectGroupSettings(groupSettingsFields, includeVisibility)
+		if err != nil {
+			return err
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if err := checkSameInstance(config, "group", groupID, destinationGroupID); err != nil {
+			return err
+		}
+
+		return migrateGroupSettings(config, groupID, destinationGroupID, fields)
+	},
+}
+
+// selectGroupSettings returns the settings to copy for the --fields value
+func selectGroupSettings(fieldList string, withVisibility bool) ([]string, error) {
+	fields := defaultGroupSettings
+	if fieldList != "" {
+		fields = utils.ParseColumns(fieldList)
+		for _, field := range fields {
+			if !slices.Contains(defaultGroupSettings, field) && !slices.Contains(optionalGroupSettings, field) {
+				return nil, fmt.Errorf("unsupported group setting %q (supported: %s, %s)", field,
+					strings.Join(defaultGroupSettings, ", "), strings.Join(optionalGroupSettings, ", "))
+			}
+		}
+	}
+	if withVisibility && !slices.Contains(fields, "visibility") {
+		fields = append(append([]string{}, fields...), "visibility")
+	}
+	return fields, nil
+}
+
+// migrateGroupSettings copies the given fields from the source group to the
+// destination group, sending only those that differ
+func migrateGroupSettings(config *utils.Config, sourceID, destinationID string, fields []string) error {
+	var sourceGroup, destinationGroup map[string]interface{}
+	sourceURL := fmt.Sprintf("%s/api/v4/groups/%s", config.SourceBaseURL, url.PathEscape(sourceID))
+	if err := getGitLabJSON(sourceURL, config.SourceAccessToken, &sourceGroup); err != nil {
+		return fmt.Errorf("failed to fetch source group %s: %v", sourceID, err)
+	}
+	destinationURL := fmt.Sprintf("%s/api/v4/groups/%s", config.DestinationBaseURL, url.PathEscape(destinationID))
+	if err := getGitLabJSON(destinationURL, config.DestinationAccessToken, &destinationGroup); err != nil {
+		return fmt.Errorf("failed to fetch destination group %s: %v", destinationID, err)
+	}
+
+	changes := make(map[string]interface{})
+	for _, field := range fields {
+		value, ok := sourceGroup[field]
+		if !ok {
+			log.Printf("Warning: the source instance does not report %s, it will not be copied", field)
+			continue
+		}
+		if diffValue(value) == diffValue(destinationGroup[field]) {
+			continue
+		}
+		changes[field] = value
+		fmt.Printf("  %s: %s -> %s\n", field, diffValue(destinationGroup[field]), diffValue(value))
+	}
+
+	if len(changes) == 0 {
+		log.Printf("Group %s already has the settings of group %s", destinationID, sourceID)
+		return nil
+	}
+	if groupSettingsDryRun {
+		log.Printf("Dry run: %d settings of group %s would be updated", len(changes), destinationID)
+		return nil
+	}
+
+	payload, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("error marshaling group settings: %v", err)
+	}
+	if err := makeGitLabAPIRequest("PUT", destinationURL, config.DestinationAccessToken, string(payload)); err != nil {
+		return fmt.Errorf("failed to update group %s: %v", destinationID, err)
+	}
+
+	log.Printf("Successfully updated %d settings of group %s", len(changes), destinationID)
+	return nil
+}
+
+func init() {
+	migrateGroupSettingsCmd.Flags().StringVarP(&groupID, "group", "g", "", "Source group ID")
+	migrateGroupSettingsCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "Destination group ID")
+	migrateGroupSettingsCmd.Flags().StringVar(&groupSettingsFields, "fields", "", "Comma-separated settings to copy instead of the default set")
+	migrateGroupSettingsCmd.Flags().BoolVar(&includeVisibility, "include-visibility", false, "Also copy the group visibility")
+	migrateGroupSettingsCmd.Flags().BoolVar(&groupSettingsDryRun, "dry-run", false, "List the settings that would change without updating the destination group")
+
+	migrateCmd.AddCommand(migrateGroupSettingsCmd)
+}
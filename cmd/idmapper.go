@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// idMapper looks up the destination IDs of users, groups and protected
+// branches by username, full path and name. Every lookup is made once; an ID
+// of 0 means there is no match on the destination.
+type idMapper struct {
+	baseURL, accessToken string
+	protectedBranchesURL string
+
+	users    map[string]int64
+	groups   map[string]int64
+	branches map[string]int64
+}
+
+// newIDMapper returns a mapper for the instance at baseURL. protectedBranchesURL
+// is the protected branches endpoint branchID looks names up in, if needed.
+func newIDMapper(baseURL, accessToken, protectedBranchesURL string) *idMapper {
+	return &idMapper{
+		baseURL:              baseURL,
+		accessToken:          accessToken,
+		protectedBranchesURL: protectedBranchesURL,
+		users:                make(map[string]int64),
+		groups:               make(map[string]int64),
+	}
+}
+
+// userID returns the ID of the destination user with username
+func (m *idMapper) userID(username string) (int64, error) {
+	if id, ok := m.users[username]; ok {
+		return id, nil
+	}
+	var users []struct {
+		ID int64 `json:"id"`
+	}
+	usersURL := fmt.Sprintf("%s/api/v4/users?username=%s", m.baseURL, url.QueryEscape(username))
+	if err := getGitLabJSON(usersURL, m.accessToken, &users); err != nil {
+		return 0, fmt.Errorf("failed to look up user %s: %w", username, err)
+	}
+	var id int64
+	if len(users) > 0 {
+		id = users[0].ID
+	}
+	m.users[username] = id
+	return id, nil
+}
+
+// groupID returns the ID of the destination group at fullPath
+func (m *idMapper) groupID(fullPath string) (int64, error) {
+	if id, ok := m.groups[fullPath]; ok {
+		return id, nil
+	}
+	var group struct {
+		ID int64 `json:"id"`
+	}
+	groupURL := fmt.Sprintf("%s/api/v4/groups/%s", m.baseURL, url.PathEscape(fullPath))
+	if err := getGitLabJSON(groupURL, m.accessToken, &group); err != nil && !errors.Is(err, utils.ErrNotFound) {
+		return 0, fmt.Errorf("failed to look up group %s: %w", fullPath, err)
+	}
+	m.groups[fullPath] = group.ID
+	return group.ID, nil
+}
+
+// branchID returns the ID of the destination protected branch called name
+func (m *idMapper) branchID(name string) (int64, error) {
+	if m.branches == nil {
+		branches, err := fetchProtectedBranches(m.protectedBranchesURL, m.accessToken)
+		if err != nil {
+			return 0, err
+		}
+		m.branches = make(map[string]int64, len(branches))
+		for _, branch := range branches {
+			branchName, _ := branch["name"].(string)
+			id, _ := branch["id"].(float64)
+			m.branches[branchName] = int64(id)
+		}
+	}
+	return m.branches[name], nil
+}
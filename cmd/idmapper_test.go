@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+func TestIDMapperUserID(t *testing.T) {
+	lookups := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/users" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		username := r.URL.Query().Get("username")
+		lookups[username]++
+		switch username {
+		case "alice":
+			fmt.Fprint(w, `[{"id":42,"username":"alice"}]`)
+		case "j.doe+ci":
+			fmt.Fprint(w, `[{"id":7,"username":"j.doe+ci"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	mapper := newIDMapper(server.URL, "dsttoken", "")
+	tests := []struct {
+		username string
+		want     int64
+	}{
+		{"alice", 42},
+		{"j.doe+ci", 7},
+		{"nobody", 0},
+		{"alice", 42},
+		{"nobody", 0},
+	}
+	for _, tt := range tests {
+		got, err := mapper.userID(tt.username)
+		if err != nil {
+			t.Fatalf("userID(%q): %v", tt.username, err)
+		}
+		if got != tt.want {
+			t.Errorf("userID(%q) = %d, want %d", tt.username, got, tt.want)
+		}
+	}
+	for username, count := range lookups {
+		if count != 1 {
+			t.Errorf("looked up %q %d times, want once", username, count)
+		}
+	}
+}
+
+func TestIDMapperUserIDUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	if _, err := newIDMapper(server.URL, "expired", "").userID("alice"); !errors.Is(err, utils.ErrUnauthorized) {
+		t.Errorf("error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -3,77 +3,199 @@ package cmd
 import (
 	"bufio"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v3"
 )
 
+var nonInteractive bool
+var initSourceURL string
+var initSourceToken string
+var initDestURL string
+var initDestToken string
+
 // initCmd defines the "init" subcommand
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize configuration by creating a config.yaml file",
-	Run: func(cmd *cobra.Command, args []string) {
-		reader := bufio.NewReader(os.Stdin)
-
-		// If --config is not provided, default to the home directory
+	Long: `Initialize configuration by creating a config.yaml file, by default
+$XDG_CONFIG_HOME/gitlab-migrate/config.yaml (~/.config/gitlab-migrate/config.yaml
+when XDG_CONFIG_HOME isn't set).
+By default the values are prompted for interactively, including the optional
+username and password the mirror command uses, which can be left blank. The
+password isn't echoed. With --non-interactive, or when stdin is not a
+terminal, they are read from flags, falling back to environment variables:
+  --source-url    GITLAB_MIGRATE_SOURCE_URL
+  --source-token  GITLAB_MIGRATE_SOURCE_TOKEN
+  --dest-url      GITLAB_MIGRATE_DEST_URL
+  --dest-token    GITLAB_MIGRATE_DEST_TOKEN
+Giving all four flags implies --non-interactive. The configuration is
+validated before it is written.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// If --config is not provided, write to the default config path
 		if configPath == "" {
-			homeDir, err := os.UserHomeDir()
+			path, err := utils.DefaultConfigPath()
 			if err != nil {
-				fmt.Println("Error finding home directory:", err)
-				return
+				return err
 			}
-			configPath = filepath.Join(homeDir, "config.yaml")
-			fmt.Println("Defaulting to home directory:", configPath)
+			configPath = path
+			log.Printf("Defaulting to: %s", configPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+			return fmt.Errorf("failed to create config directory: %v", err)
+		}
 
-		} else if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-			fmt.Printf("Error creating config directory: %v\n", err)
-			return
+		// Nothing is left to prompt for when every value comes from a flag
+		if initSourceURL != "" && initSourceToken != "" && initDestURL != "" && initDestToken != "" {
+			nonInteractive = true
 		}
 
-		// Ask the user for configuration values
-		fmt.Print("Enter Source Base URL: ")
-		sourceBaseURL, _ := reader.ReadString('\n')
-		sourceBaseURL = sanitizeInput(sourceBaseURL)
-
-		fmt.Print("Enter Source Access Token: ")
-		sourceAccessToken, _ := reader.ReadString('\n')
-		sourceAccessToken = sanitizeInput(sourceAccessToken)
-
-		fmt.Print("Enter Destination Base URL: ")
-		destinationBaseURL, _ := reader.ReadString('\n')
-		destinationBaseURL = sanitizeInput(destinationBaseURL)
-
-		fmt.Print("Enter Destination Access Token: ")
-		destinationAccessToken, _ := reader.ReadString('\n')
-		destinationAccessToken = sanitizeInput(destinationAccessToken)
-
-		// Create a Config struct
-		config := &utils.Config{
-			SourceBaseURL:          sourceBaseURL,
-			SourceAccessToken:      sourceAccessToken,
-			DestinationBaseURL:     destinationBaseURL,
-			DestinationAccessToken: destinationAccessToken,
+		// Prompts can't be answered without a terminal, e.g. in CI or with piped
+		// stdin, so the values must come from flags or the environment there
+		promptable := stdinIsTerminal()
+		if !nonInteractive && !promptable {
+			log.Printf("stdin is not a terminal, reading the values from flags and environment variables")
+			nonInteractive = true
+		}
+
+		var config *utils.Config
+		if nonInteractive {
+			var err error
+			config, err = configFromFlags()
+			if err != nil {
+				if !promptable {
+					err = fmt.Errorf("%v (stdin is not a terminal, so they can't be prompted for)", err)
+				}
+				// Provisioning scripts rely on the exit status
+				return err
+			}
+		} else {
+			config = promptForConfig(bufio.NewReader(os.Stdin))
 		}
 
 		// Write the configuration to the specified file
 		if err := writeConfigToFile(config, configPath); err != nil {
-			fmt.Printf("Error writing config file: %v\n", err)
-			return
+			return err
 		}
 
-		fmt.Printf("Configuration saved successfully to %s\n", configPath)
+		log.Printf("Configuration saved successfully to %s", configPath)
+		return nil
 	},
 }
 
-// Helper function to sanitize input
+// promptForConfig asks the user for each configuration value on stdin
+func promptForConfig(reader *bufio.Reader) *utils.Config {
+	fmt.Print("Enter Source Base URL: ")
+	sourceBaseURL, _ := reader.ReadString('\n')
+	sourceBaseURL = sanitizeInput(sourceBaseURL)
+
+	fmt.Print("Enter Source Access Token: ")
+	sourceAccessToken, _ := reader.ReadString('\n')
+	sourceAccessToken = sanitizeInput(sourceAccessToken)
+
+	fmt.Print("Enter Destination Base URL: ")
+	destinationBaseURL, _ := reader.ReadString('\n')
+	destinationBaseURL = sanitizeInput(destinationBaseURL)
+
+	fmt.Print("Enter Destination Access Token: ")
+	destinationAccessToken, _ := reader.ReadString('\n')
+	destinationAccessToken = sanitizeInput(destinationAccessToken)
+
+	// The mirror command needs these and asks for them on its first run otherwise
+	fmt.Print("Enter mirror username (leave blank to skip): ")
+	authUser, _ := reader.ReadString('\n')
+	authUser = sanitizeInput(authUser)
+
+	var authPassword string
+	if authUser != "" {
+		authPassword = promptSecret(reader, "Enter mirror password: ")
+	}
+
+	return &utils.Config{
+		Version:                utils.CurrentConfigVersion,
+		SourceBaseURL:          sourceBaseURL,
+		SourceAccessToken:      sourceAccessToken,
+		DestinationBaseURL:     destinationBaseURL,
+		DestinationAccessToken: destinationAccessToken,
+		AuthUser:               authUser,
+		AuthPassword:           authPassword,
+	}
+}
+
+// promptSecret prints prompt and reads a line without echoing it when stdin
+// is a terminal. Input that is piped, or was typed ahead and is already
+// buffered by reader, is read from reader as it is.
+func promptSecret(reader *bufio.Reader, prompt string) string {
+	fmt.Print(prompt)
+	if reader.Buffered() > 0 || !stdinIsTerminal() {
+		line, _ := reader.ReadString('\n')
+		return sanitizeInput(line)
+	}
+
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	// The newline typed by the user wasn't echoed either
+	fmt.Println()
+	if err != nil {
+		return ""
+	}
+	return sanitizeInput(string(secret))
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal rather than
+// a pipe, file or /dev/null
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// configFromFlags builds the configuration from init flags, falling back to
+// environment variables, and fails if a required value is missing or invalid
+func configFromFlags() (*utils.Config, error) {
+	var missing []string
+	value := func(flagValue, flagName, envName string) string {
+		if flagValue == "" {
+			flagValue = os.Getenv(envName)
+		}
+		if flagValue == "" {
+			missing = append(missing, fmt.Sprintf("--%s (or $%s)", flagName, envName))
+		}
+		return flagValue
+	}
+
+	config := &utils.Config{
+		Version:                utils.CurrentConfigVersion,
+		SourceBaseURL:          value(initSourceURL, "source-url", "GITLAB_MIGRATE_SOURCE_URL"),
+		SourceAccessToken:      value(initSourceToken, "source-token", "GITLAB_MIGRATE_SOURCE_TOKEN"),
+		DestinationBaseURL:     value(initDestURL, "dest-url", "GITLAB_MIGRATE_DEST_URL"),
+		DestinationAccessToken: value(initDestToken, "dest-token", "GITLAB_MIGRATE_DEST_TOKEN"),
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required values: %s", strings.Join(missing, ", "))
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	return config, nil
+}
+
+// sanitizeInput removes the line ending from a line read from stdin. The line
+// may be empty or lack the newline at EOF, and may end in \r\n on Windows.
 func sanitizeInput(input string) string {
-	return input[:len(input)-1] // Remove newline character
+	return strings.TrimRight(input, "\r\n")
 }
 
+// configFileMode is the permission of a written config file
+const configFileMode = 0600
+
 // Helper function to write configuration to a file
 func writeConfigToFile(config *utils.Config, filePath string) error {
 	data, err := yaml.Marshal(config)
@@ -81,7 +203,13 @@ func writeConfigToFile(config *utils.Config, filePath string) error {
 		return fmt.Errorf("failed to marshal config to yaml: %v", err)
 	}
 
-	err = os.WriteFile(filePath, data, 0644)
+	// The file holds access tokens and possibly the mirror password, so it is
+	// only readable by its owner. WriteFile keeps the mode of an existing
+	// file, which is tightened first.
+	if err := os.Chmod(filePath, configFileMode); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restrict config file permissions: %v", err)
+	}
+	err = os.WriteFile(filePath, data, configFileMode)
 	if err != nil {
 		return fmt.Errorf("failed to write config file: %v", err)
 	}
@@ -90,5 +218,11 @@ func writeConfigToFile(config *utils.Config, filePath string) error {
 }
 
 func init() {
+	initCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Read all values from flags or environment variables instead of prompting")
+	initCmd.Flags().StringVar(&initSourceURL, "source-url", "", "Source GitLab base URL")
+	initCmd.Flags().StringVar(&initSourceToken, "source-token", "", "Source GitLab access token")
+	initCmd.Flags().StringVar(&initDestURL, "dest-url", "", "Destination GitLab base URL")
+	initCmd.Flags().StringVar(&initDestToken, "dest-token", "", "Destination GitLab access token")
+
 	rootCmd.AddCommand(initCmd)
 }
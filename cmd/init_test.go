@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+func TestSanitizeInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"\n", ""},
+		{"token\n", "token"},
+		{"token\r\n", "token"},
+		{"token", "token"},
+		{"  spaced value \n", "  spaced value "},
+		{"pass\rword\n", "pass\rword"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeInput(tt.input); got != tt.want {
+			t.Errorf("sanitizeInput(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestInitConfigsHaveCurrentVersion(t *testing.T) {
+	t.Setenv("GITLAB_MIGRATE_SOURCE_URL", "https://source.example.com")
+	t.Setenv("GITLAB_MIGRATE_SOURCE_TOKEN", "srctoken")
+	t.Setenv("GITLAB_MIGRATE_DEST_URL", "https://destination.example.com")
+	t.Setenv("GITLAB_MIGRATE_DEST_TOKEN", "dsttoken")
+
+	config, err := configFromFlags()
+	if err != nil {
+		t.Fatalf("configFromFlags: %v", err)
+	}
+	if config.Version != utils.CurrentConfigVersion {
+		t.Errorf("configFromFlags version = %d, want %d", config.Version, utils.CurrentConfigVersion)
+	}
+
+	input := "https://source.example.com\nsrctoken\nhttps://destination.example.com\ndsttoken\n\n"
+	config = promptForConfig(bufio.NewReader(strings.NewReader(input)))
+	if config.Version != utils.CurrentConfigVersion {
+		t.Errorf("promptForConfig version = %d, want %d", config.Version, utils.CurrentConfigVersion)
+	}
+	if config.DestinationAccessToken != "dsttoken" {
+		t.Errorf("destination token = %q, want dsttoken", config.DestinationAccessToken)
+	}
+}
+
+func TestPromptedMirrorCredentialsAreWritten(t *testing.T) {
+	input := "https://source.example.com\nsrctoken\nhttps://destination.example.com\ndsttoken\nmirror-bot\ns3cret:pass\n"
+	config := promptForConfig(bufio.NewReader(strings.NewReader(input)))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := writeConfigToFile(config, path); err != nil {
+		t.Fatalf("writeConfigToFile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"auth_user: mirror-bot", "auth_password: s3cret:pass"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config file lacks %q:\n%s", want, data)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != configFileMode {
+		t.Errorf("config file mode = %o, want %o", mode, configFileMode)
+	}
+}
+
+func TestConfigFromFlagsPrefersFlagsOverEnv(t *testing.T) {
+	t.Setenv("GITLAB_MIGRATE_SOURCE_URL", "https://env-source.example.com")
+	t.Setenv("GITLAB_MIGRATE_SOURCE_TOKEN", "envsrctoken")
+	t.Setenv("GITLAB_MIGRATE_DEST_URL", "https://env-destination.example.com")
+	t.Setenv("GITLAB_MIGRATE_DEST_TOKEN", "envdsttoken")
+	initSourceURL, initDestToken = "https://flag-source.example.com", "flagdsttoken"
+	t.Cleanup(func() { initSourceURL, initDestToken = "", "" })
+
+	config, err := configFromFlags()
+	if err != nil {
+		t.Fatalf("configFromFlags: %v", err)
+	}
+	want := utils.Config{
+		Version:                utils.CurrentConfigVersion,
+		SourceBaseURL:          "https://flag-source.example.com",
+		SourceAccessToken:      "envsrctoken",
+		DestinationBaseURL:     "https://env-destination.example.com",
+		DestinationAccessToken: "flagdsttoken",
+	}
+	if config.SourceBaseURL != want.SourceBaseURL || config.SourceAccessToken != want.SourceAccessToken ||
+		config.DestinationBaseURL != want.DestinationBaseURL || config.DestinationAccessToken != want.DestinationAccessToken {
+		t.Errorf("config = %+v, want %+v", *config, want)
+	}
+}
+
+func TestConfigFromFlagsReportsMissingValues(t *testing.T) {
+	for _, name := range []string{"GITLAB_MIGRATE_SOURCE_URL", "GITLAB_MIGRATE_SOURCE_TOKEN", "GITLAB_MIGRATE_DEST_URL", "GITLAB_MIGRATE_DEST_TOKEN"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GITLAB_MIGRATE_SOURCE_TOKEN", "srctoken")
+	initDestURL = "https://destination.example.com"
+	t.Cleanup(func() { initDestURL = "" })
+
+	_, err := configFromFlags()
+	want := "missing required values: --source-url (or $GITLAB_MIGRATE_SOURCE_URL), --dest-token (or $GITLAB_MIGRATE_DEST_TOKEN)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestInitReturnsWriteError(t *testing.T) {
+	// A directory in place of the config file can't be written
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	err := executeCommand(t, "-c", path, "init", "--source-url", "https://source.example.com", "--source-token", "srctoken",
+		"--dest-url", "https://destination.example.com", "--dest-token", "dsttoken")
+	if err == nil || !strings.Contains(err.Error(), "failed to write config file") {
+		t.Errorf("error = %v, want the failed write", err)
+	}
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the set variables input files
+const (
+	inputFormatJSON     = "json"
+	inputFormatDotenv   = "dotenv"
+	inputFormatGitLabCI = "gitlab-ci"
+)
+
+var inputFormat string
+
+// variableKeyName matches the keys GitLab accepts for CI/CD variables
+var variableKeyName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// validateInputFormat checks the --format flag value
+func validateInputFormat(format string) error {
+	switch format {
+	case inputFormatJSON, inputFormatDotenv, inputFormatGitLabCI:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --format value %q (use %s, %s or %s)", format, inputFormatJSON, inputFormatDotenv, inputFormatGitLabCI)
+	}
+}
+
+// parseVariablesInput parses the contents of a non-recursive input file in
+// the given format into variable payloads
+func parseVariablesInput(data []byte, format string) ([]interface{}, error) {
+	switch format {
+	case inputFormatDotenv:
+		return parseDotenv(data)
+	case inputFormatGitLabCI:
+		return parseGitLabCIVariables(data)
+	}
+
+	var parsedData []interface{}
+	if err := json.Unmarshal(data, &parsedData); err != nil {
+		return nil, fmt.Errorf("could not parse JSON: %v", err)
+	}
+	return parsedData, nil
+}
+
+// parseDotenv reads KEY=VALUE lines. Blank lines and lines starting with #
+// are skipped and an "export " prefix is allowed, so the output of
+// "get variables --output-format env-export" can be read back. Values may be
+// single-quoted (taken literally, with an embedded quote closed, escaped and
+// reopened as a shell would write it), double-quoted (with Go escapes such as
+// \n) or bare, where a " #" starts a comment.
+func parseDotenv(data []byte) ([]interface{}, error) {
+	var variables []interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+
+		key, value, found := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !found || !variableKeyName.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE with a key of letters, digits and underscores", line)
+		}
+
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", line, key, err)
+		}
+		variables = append(variables, map[string]interface{}{"key": key, "value": value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read dotenv input: %v", err)
+	}
+	return variables, nil
+}
+
+// dotenvValue unquotes the value part of a dotenv line
+func dotenvValue(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], `'\''`, "'"), nil
+	case strings.HasPrefix(value, "'"):
+		return "", fmt.Errorf("unterminated single-quoted value")
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted value")
+		}
+		return unquoted, nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// parseGitLabCIVariables reads the top-level variables: block of a
+// .gitlab-ci.yml. Each entry is either KEY: value or the expanded form with
+// value, description and expand (expand: false creates a raw variable).
+// Job-level variables and the rest of the pipeline configuration are ignored.
+func parseGitLabCIVariables(data []byte) ([]interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("could not parse YAML: %v", err)
+	}
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("the YAML document is empty")
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: the top level of a GitLab CI configuration must be a mapping", root.Line)
+	}
+
+	var block *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "variables" {
+			block = root.Content[i+1]
+		}
+	}
+	if block == nil {
+		return nil, fmt.Errorf("no top-level variables: block found")
+	}
+	if block.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: variables: must be a mapping of names to values", block.Line)
+	}
+
+	var variables []interface{}
+	for i := 0; i+1 < len(block.Content); i += 2 {
+		keyNode, valueNode := block.Content[i], block.Content[i+1]
+		key := keyNode.Value
+		if keyNode.Kind != yaml.ScalarNode || !variableKeyName.MatchString(key) {
+			return nil, fmt.Errorf("line %d: variable name %q must consist of letters, digits and underscores", keyNode.Line, key)
+		}
+
+		variable := map[string]interface{}{"key": key}
+		switch valueNode.Kind {
+		case yaml.ScalarNode:
+			variable["value"] = valueNode.Value
+		case yaml.MappingNode:
+			if err := expandedCIVariable(variable, valueNode); err != nil {
+				return nil, fmt.Errorf("variable %s: %v", key, err)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: variable %s must be a value or a mapping with value and description", valueNode.Line, key)
+		}
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
+
+// expandedCIVariable fills variable from the expanded form of a CI variable:
+// value, description, expand and options (the latter only offers values in
+// the pipeline form and is not stored)
+func expandedCIVariable(variable map[string]interface{}, node *yaml.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		field, value := node.Content[i].Value, node.Content[i+1]
+		switch field {
+		case "value", "description":
+			if value.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: %s must be a string", value.Line, field)
+			}
+			variable[field] = value.Value
+		case "expand":
+			var expand bool
+			if err := value.Decode(&expand); err != nil {
+				return fmt.Errorf("line %d: expand must be true or false", value.Line)
+			}
+			variable["raw"] = !expand
+		case "options":
+		default:
+			return fmt.Errorf("line %d: unknown field %q (use value, description, expand or options)", node.Content[i].Line, field)
+		}
+	}
+	if _, ok := variable["value"]; !ok {
+		return fmt.Errorf("line %d: the expanded form needs a value", node.Line)
+	}
+	return nil
+}
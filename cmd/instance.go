@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// versionResult is a cached /version lookup for one instance
+type versionResult struct {
+	version *utils.GitLabVersion
+	err     error
+}
+
+var allowSameInstance bool
+
+var (
+	instanceVersionsMu sync.Mutex
+	instanceVersions   = make(map[string]versionResult)
+	featureWarnings    = make(map[string]bool)
+)
+
+// variableFeature is a variable attribute only understood by newer GitLab versions
+type variableFeature struct {
+	attribute string
+	major     int
+	minor     int
+}
+
+// variableFeatures lists variable attributes and the GitLab version that introduced them
+var variableFeatures = []variableFeature{
+	{attribute: "raw", major: 15, minor: 7},
+	{attribute: "description", major: 16, minor: 2},
+}
+
+// getInstanceVersion returns the GitLab version of the instance at baseURL.
+// The result (including a failure) is cached for the rest of the run.
+func getInstanceVersion(baseURL, accessToken string) (*utils.GitLabVersion, error) {
+	instanceVersionsMu.Lock()
+	defer instanceVersionsMu.Unlock()
+
+	if cached, ok := instanceVersions[baseURL]; ok {
+		return cached.version, cached.err
+	}
+
+	version, err := fetchInstanceVersion(baseURL, accessToken)
+	instanceVersions[baseURL] = versionResult{version: version, err: err}
+	return version, err
+}
+
+// fetchInstanceVersion queries GET /version
+func fetchInstanceVersion(baseURL, accessToken string) (*utils.GitLabVersion, error) {
+	var result struct {
+		Version string `json:"version"`
+	}
+	if err := newGitLabClient(baseURL, accessToken).Get("version", &result); err != nil {
+		return nil, fmt.Errorf("error fetching version: %v", err)
+	}
+	return utils.ParseGitLabVersion(result.Version)
+}
+
+// checkSameInstance refuses to run when the source and destination are the same
+// group or project on the same instance, which would duplicate variables or
+// mirror a project onto itself. With --allow-same-instance it only warns.
+func checkSameInstance(config *utils.Config, kind, sourceID, destinationID string) error {
+	if sourceID == "" || sourceID != destinationID || !utils.SameBaseURL(config.SourceBaseURL, config.DestinationBaseURL) {
+		return nil
+	}
+
+	problem := fmt.Sprintf("the source and destination are the same %s (%s) on %s", kind, sourceID, config.SourceBaseURL)
+	if allowSameInstance {
+		log.Printf("Warning: %s", problem)
+		return nil
+	}
+	return fmt.Errorf("%s, use --allow-same-instance if this is intended", problem)
+}
+
+// warnOnce prints a warning the first time it is seen during the run
+func warnOnce(message string) {
+	instanceVersionsMu.Lock()
+	defer instanceVersionsMu.Unlock()
+
+	if featureWarnings[message] {
+		return
+	}
+	featureWarnings[message] = true
+	log.Println("Warning: " + message)
+}
+
+// gateVariableFeatures returns the variable without attributes the target
+// version does not support. An empty description is never sent. A nil version
+// (unknown) leaves the rest of the payload untouched.
+func gateVariableFeatures(variable map[string]interface{}, version *utils.GitLabVersion) map[string]interface{} {
+	gated := variable
+	copied := false
+	if description, ok := variable["description"]; ok && (description == nil || description == "") {
+		gated = copyVariable(variable)
+		copied = true
+		delete(gated, "description")
+	}
+
+	if version == nil {
+		return gated
+	}
+
+	for _, feature := range variableFeatures {
+		if _, ok := gated[feature.attribute]; !ok || version.AtLeast(feature.major, feature.minor) {
+			continue
+		}
+
+		if !copied {
+			gated = copyVariable(variable)
+			copied = true
+		}
+		delete(gated, feature.attribute)
+		warnOnce(fmt.Sprintf("GitLab %s does not support the variable attribute %q (added in %d.%d), it will not be sent",
+			version, feature.attribute, feature.major, feature.minor))
+	}
+	return gated
+}
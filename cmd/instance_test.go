@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+func TestCheckSameInstance(t *testing.T) {
+	tests := []struct {
+		name               string
+		sourceURL, destURL string
+		sourceID, destID   string
+		allow              bool
+		wantErr            bool
+	}{
+		{"same project", "https://gitlab.example.com", "https://gitlab.example.com", "1", "1", false, true},
+		{"same project, URLs spelled differently", "https://GitLab.example.com:443/", "https://gitlab.example.com", "1", "1", false, true},
+		{"allowed", "https://gitlab.example.com", "https://gitlab.example.com", "1", "1", true, false},
+		{"other project", "https://gitlab.example.com", "https://gitlab.example.com", "1", "2", false, false},
+		{"other instance", "https://old.example.com", "https://new.example.com", "1", "1", false, false},
+		{"no source", "https://gitlab.example.com", "https://gitlab.example.com", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowSameInstance = tt.allow
+			t.Cleanup(func() { allowSameInstance = false })
+
+			config := &utils.Config{SourceBaseURL: tt.sourceURL, DestinationBaseURL: tt.destURL}
+			err := checkSameInstance(config, "project", tt.sourceID, tt.destID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSameInstance error = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
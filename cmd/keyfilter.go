@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var filterKeys []string
+var excludeKeys []string
+var filterEnvironmentScope string
+
+// keyFilter holds the compiled --filter-key and --exclude-key patterns and the
+// --environment-scope. It is nil when none of them is given, which selects
+// every variable.
+var keyFilter *variableKeyFilter
+
+// variableKeyFilter selects variables by key and environment scope: a variable
+// is selected when its key matches one of the include patterns, or there are
+// none, and none of the exclude patterns, and its scope is scope, unless that
+// is empty
+type variableKeyFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	scope   string
+}
+
+// compileKeyPattern compiles a --filter-key or --exclude-key pattern. A pattern
+// between slashes, like /^PROD_/, is a regular expression matched anywhere in
+// the key; anything else is a glob matching the whole key, where * matches any
+// characters and ? a single one.
+func compileKeyPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid key pattern %s: %v", pattern, err)
+		}
+		return re, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// setupKeyFilter compiles the --filter-key and --exclude-key patterns, so an
+// invalid one is reported before anything is fetched or written. An
+// --environment-scope of * selects every scope, like leaving it out.
+func setupKeyFilter() error {
+	keyFilter = nil
+	scope := strings.TrimSpace(filterEnvironmentScope)
+	if scope == "*" {
+		scope = ""
+	}
+	if len(filterKeys) == 0 && len(excludeKeys) == 0 && scope == "" {
+		return nil
+	}
+
+	filter := &variableKeyFilter{scope: scope}
+	for _, pattern := range filterKeys {
+		re, err := compileKeyPattern(pattern)
+		if err != nil {
+			return fmt.Errorf("--filter-key: %v", err)
+		}
+		filter.include = append(filter.include, re)
+	}
+	for _, pattern := range excludeKeys {
+		re, err := compileKeyPattern(pattern)
+		if err != nil {
+			return fmt.Errorf("--exclude-key: %v", err)
+		}
+		filter.exclude = append(filter.exclude, re)
+	}
+	keyFilter = filter
+	return nil
+}
+
+// selects reports whether variable passes the filter
+func (f *variableKeyFilter) selects(variable map[string]interface{}) bool {
+	if f == nil {
+		return true
+	}
+	if f.scope != "" && variableScope(variable) != f.scope {
+		return false
+	}
+	key, _ := variable["key"].(string)
+	for _, re := range f.exclude {
+		if re.MatchString(key) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterVariables returns the variables that pass the filter
+func (f *variableKeyFilter) filterVariables(variables []map[string]interface{}) []map[string]interface{} {
+	if f == nil {
+		return variables
+	}
+	selected := make([]map[string]interface{}, 0, len(variables))
+	for _, variable := range variables {
+		if f.selects(variable) {
+			selected = append(selected, variable)
+		}
+	}
+	return selected
+}
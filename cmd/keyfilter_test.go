@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// setKeyFilterFlags sets the filter flags and compiles them for one test
+func setKeyFilterFlags(t *testing.T, include, exclude []string, scope string) {
+	t.Helper()
+	filterKeys, excludeKeys, filterEnvironmentScope = include, exclude, scope
+	t.Cleanup(func() {
+		filterKeys, excludeKeys, filterEnvironmentScope, keyFilter = nil, nil, "", nil
+	})
+	if err := setupKeyFilter(); err != nil {
+		t.Fatalf("setupKeyFilter: %v", err)
+	}
+}
+
+func TestCompileKeyPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"PROD_*", "PROD_TOKEN", true},
+		{"PROD_*", "NOT_PROD_TOKEN", false},
+		{"*_TOKEN", "API_TOKEN", true},
+		{"DB_?", "DB_1", true},
+		{"DB_?", "DB_10", false},
+		{"API.KEY", "API.KEY", true},
+		{"API.KEY", "APIXKEY", false},
+		{"/^PROD_/", "PROD_TOKEN", true},
+		{"/TOKEN/", "API_TOKEN_2", true},
+		{"/^PROD_/", "STAGING_TOKEN", false},
+		{"/", "/", true},
+	}
+	for _, tt := range tests {
+		re, err := compileKeyPattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("compileKeyPattern(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.key); got != tt.want {
+			t.Errorf("pattern %q matches %q: %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+
+	if _, err := compileKeyPattern("/[/"); err == nil {
+		t.Error("compileKeyPattern accepted an invalid regular expression")
+	}
+}
+
+func TestKeyFilterIncludeAndExclude(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             string
+	}{
+		{"no patterns", nil, nil, "PROD_TOKEN PROD_DEBUG STAGING_TOKEN DEBUG"},
+		{"include", []string{"PROD_*"}, nil, "PROD_TOKEN PROD_DEBUG"},
+		{"several includes", []string{"PROD_*", "/^STAGING/"}, nil, "PROD_TOKEN PROD_DEBUG STAGING_TOKEN"},
+		{"exclude", nil, []string{"*DEBUG"}, "PROD_TOKEN STAGING_TOKEN"},
+		{"exclude wins over include", []string{"PROD_*"}, []string{"/DEBUG$/"}, "PROD_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKeyFilterFlags(t, tt.include, tt.exclude, "")
+			variables := []map[string]interface{}{{"key": "PROD_TOKEN"}, {"key": "PROD_DEBUG"}, {"key": "STAGING_TOKEN"}, {"key": "DEBUG"}}
+			if got := selectedKeys(keyFilter.filterVariables(variables)); got != tt.want {
+				t.Errorf("selected %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// selectedKeys joins the keys of variables with spaces
+func selectedKeys(variables []map[string]interface{}) string {
+	keys := make([]string, 0, len(variables))
+	for _, variable := range variables {
+		keys = append(keys, variable["key"].(string))
+	}
+	return strings.Join(keys, " ")
+}
+
+func TestKeyFilterEnvironmentScope(t *testing.T) {
+	variables := []map[string]interface{}{
+		{"key": "TOKEN", "environment_scope": "production"},
+		{"key": "TOKEN", "environment_scope": "*"},
+		{"key": "DEBUG", "environment_scope": "production"},
+		{"key": "URL"},
+	}
+	tests := []struct {
+		name    string
+		include []string
+		scope   string
+		want    []string
+	}{
+		{"every scope", nil, "", []string{"TOKEN@production", "TOKEN@*", "DEBUG@production", "URL@*"}},
+		{"all scopes as *", nil, " * ", []string{"TOKEN@production", "TOKEN@*", "DEBUG@production", "URL@*"}},
+		{"one scope", nil, "production", []string{"TOKEN@production", "DEBUG@production"}},
+		{"scope and key", []string{"TOKEN"}, "production", []string{"TOKEN@production"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKeyFilterFlags(t, tt.include, nil, tt.scope)
+			var got []string
+			for _, variable := range keyFilter.filterVariables(variables) {
+				got = append(got, variable["key"].(string)+"@"+variableScope(variable))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
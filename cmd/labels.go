@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// labelFields are the label attributes copied to the destination
+var labelFields = []string{"name", "color", "description", "priority"}
+
+var labelsDryRun bool
+
+// migrateLabelsCmd copies labels between projects or groups
+var migrateLabelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Migrate labels between GitLab projects or groups",
+	Long: `Copy the labels of a source project (-p) or group (-g) to a destination
+project (-P) or group (-G), keeping their name, color, description and
+priority. Priorities only exist on project labels and are dropped for group
+destinations.
+
+Only labels defined on the source itself are copied, not those inherited from
+parent groups. Labels whose name already exists on the destination are skipped,
+so the command can be re-run safely. Use --dry-run to list what would be
+created without changing the destination.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (groupID == "") == (projectID == "") || (destinationGroupID == "") == (destinationProjectID == "") {
+			return fmt.Errorf("provide one source (-g or -p) and one destination (--destination-group or --destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "group", groupID, destinationGroupID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migrateLabels(config)
+	},
+}
+
+// namespacedEndpoint returns a description and the URL of a group or project
+// collection such as labels or milestones
+func namespacedEndpoint(baseURL, groupID, projectID, resource string) (string, string) {
+	if groupID != "" {
+		return "group " + groupID, fmt.Sprintf("%s/api/v4/groups/%s/%s", baseURL, url.PathEscape(groupID), resource)
+	}
+	return "project " + projectID, fmt.Sprintf("%s/api/v4/projects/%s/%s", baseURL, url.PathEscape(projectID), resource)
+}
+
+// fetchLabels lists the labels defined on a group or project, without those
+// inherited from ancestor groups
+func fetchLabels(collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	labels, err := newGitLabClient("", accessToken).GetPaginated(collectionURL + "?include_ancestor_groups=false")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching labels: %w", err)
+	}
+	return labels, nil
+}
+
+// migrateLabels creates the source labels missing on the destination
+func migrateLabels(config *utils.Config) error {
+	sourceTarget, sourceURL := namespacedEndpoint(config.SourceBaseURL, groupID, projectID, "labels")
+	destinationTarget, destinationURL := namespacedEndpoint(config.DestinationBaseURL, destinationGroupID, destinationProjectID, "labels")
+
+	sourceLabels, err := fetchLabels(sourceURL, config.SourceAccessToken)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	destinationLabels, err := fetchLabels(destinationURL, config.DestinationAccessToken)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]bool, len(destinationLabels))
+	for _, label := range destinationLabels {
+		name, _ := label["name"].(string)
+		existing[name] = true
+	}
+
+	log.Printf("Migrating %d labels from %s to %s", len(sourceLabels), sourceTarget, destinationTarget)
+	created, skipped, failed := 0, 0, 0
+	for _, label := range sourceLabels {
+		name, _ := label["name"].(string)
+		if existing[name] {
+			fmt.Printf("skipped: label %s (already exists)\n", name)
+			skipped++
+			continue
+		}
+
+		payload := make(map[string]interface{})
+		for _, field := range labelFields {
+			if value, ok := label[field]; ok && value != nil {
+				payload[field] = value
+			}
+		}
+		if destinationGroupID != "" {
+			delete(payload, "priority")
+		}
+
+		if labelsDryRun {
+			fmt.Printf("[dry run] created: label %s\n", name)
+			created++
+			continue
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("error marshaling label payload: %v", err)
+		}
+		if err := makeGitLabAPIRequest("POST", destinationURL, config.DestinationAccessToken, string(body)); err != nil {
+			if errors.Is(err, utils.ErrUnauthorized) {
+				return err
+			}
+			fmt.Printf("failed: label %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("created: label %s\n", name)
+		existing[name] = true
+		created++
+	}
+
+	if labelsDryRun {
+		log.Printf("Dry run: %d labels would be created, %d skipped (already exist)", created, skipped)
+		return nil
+	}
+	log.Printf("Created %d labels, skipped %d (already exist), %d failed", created, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d labels could not be created", failed)
+	}
+	return nil
+}
+
+func init() {
+	migrateLabelsCmd.Flags().StringVarP(&groupID, "group", "g", "", "Source group ID")
+	migrateLabelsCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateLabelsCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "Destination group ID")
+	migrateLabelsCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migrateLabelsCmd.Flags().BoolVar(&labelsDryRun, "dry-run", false, "List the labels that would be created without changing the destination")
+
+	migrateCmd.AddCommand(migrateLabelsCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// newScopedLogger returns a logger for scope, e.g. a project path, that
+// prefixes every line with it so the output of concurrent workers stays
+// attributable. fields are added to the JSON logs. Lines are written right
+// away unless buffered is set, in which case they are kept until Flush and
+// then written as one block.
+func newScopedLogger(scope string, fields utils.Fields, buffered bool) *utils.Logger {
+	scoped := utils.Fields{"scope": scope}
+	for key, value := range fields {
+		scoped[key] = value
+	}
+	return utils.NewLogger(scoped, buffered)
+}
+
+// targetFields returns the log fields of a target such as "project 42" or
+// "group 7"
+func targetFields(target string) utils.Fields {
+	kind, id, ok := strings.Cut(target, " ")
+	if !ok {
+		return utils.Fields{"target": target}
+	}
+	return utils.Fields{kind + "_id": id}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+var maskCheck bool
+
+// unmaskableVariable is a masked variable whose value GitLab can't mask
+type unmaskableVariable struct {
+	Owner   string
+	Key     string
+	Scope   string
+	Problem string
+}
+
+// findUnmaskable returns the masked variables of a get variables result whose
+// values GitLab can't mask, ordered by owner, key and scope. owner labels the
+// variables of a single project or group; recursive results carry their own.
+func findUnmaskable(variables interface{}, owner string) (found []unmaskableVariable, masked int) {
+	var rows []map[string]interface{}
+	switch v := variables.(type) {
+	case []map[string]interface{}:
+		rows = v
+	case map[string]map[string]interface{}:
+		rows = flattenVariableEntries(v)
+	}
+
+	for _, row := range rows {
+		if row["masked"] != true {
+			continue
+		}
+		masked++
+		value, _ := row["value"].(string)
+		problem := utils.MaskProblem(value)
+		if problem == "" {
+			continue
+		}
+		rowOwner := owner
+		if path, ok := row["path_with_namespace"].(string); ok && path != "" {
+			rowOwner = path
+		} else if path, ok := row["full_path"].(string); ok && path != "" {
+			rowOwner = path
+		}
+		key, _ := row["key"].(string)
+		found = append(found, unmaskableVariable{Owner: rowOwner, Key: key, Scope: variableScope(row), Problem: problem})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Owner != found[j].Owner {
+			return found[i].Owner < found[j].Owner
+		}
+		if found[i].Key != found[j].Key {
+			return found[i].Key < found[j].Key
+		}
+		return found[i].Scope < found[j].Scope
+	})
+	return found, masked
+}
+
+// printMaskCheck reports the keys of masked variables GitLab can't mask. Values
+// are never printed.
+func printMaskCheck(variables interface{}, owner string) {
+	found, masked := findUnmaskable(variables, owner)
+	for _, v := range found {
+		fmt.Printf("%s: %s (%s): %s\n", v.Owner, v.Key, v.Scope, v.Problem)
+	}
+	if len(found) == 0 {
+		fmt.Printf("All %d masked variables can be masked by GitLab\n", masked)
+		return
+	}
+	fmt.Printf("%d of %d masked variables can't be masked by GitLab, fix them or see --unmaskable of set and migrate\n", len(found), masked)
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// Membership kinds reported by get members
+const (
+	membershipDirect    = "direct"
+	membershipInherited = "inherited"
+)
+
+// accessLevelNames are GitLab's role names by access level
+var accessLevelNames = map[int]string{
+	0:  "No access",
+	5:  "Minimal access",
+	10: "Guest",
+	15: "Planner",
+	20: "Reporter",
+	30: "Developer",
+	40: "Maintainer",
+	50: "Owner",
+}
+
+var includeInherited bool
+var membersDryRun bool
+
+// memberColumns are the default table columns for members
+var memberColumns = []string{"username", "name", "access_level", "access_level_name", "membership", "expires_at"}
+
+// getMembersCmd lists the members of a project or group
+var getMembersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Retrieve the members of a GitLab project or group",
+	Long: `Retrieve the members of a project (-p) or group (-g).
+
+By default only direct members are listed. --include-inherited (or --all)
+lists the effective members instead, including those inherited from parent
+groups, using /members/all. Each member is listed once with its highest
+access level across direct and inherited grants, and "membership" tells
+whether that level is granted directly or inherited. Members whose direct
+level is lower than an inherited one also carry it as direct_access_level.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if (projectID == "") == (groupID == "") {
+			return fmt.Errorf("either --project or --group must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+		if isDestination {
+			baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+		}
+		kind, id := "projects", projectID
+		if groupID != "" {
+			kind, id = "groups", groupID
+		}
+
+		members, err := getMembers(baseURL, accessToken, kind, id, includeInherited)
+		if err != nil {
+			return notFoundError(fmt.Errorf("failed to fetch members: %w", err), kind[:len(kind)-1], id)
+		}
+
+		if outputFormat == outputFormatTable {
+			return printTable(members, memberColumns, "")
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("members", groupID, projectID, isDestination, includeInherited)
+		}
+
+		if err := saveOutputToFile(members, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// migrateMembersCmd copies direct memberships between projects or groups
+var migrateMembersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Migrate members between GitLab projects or groups",
+	Long: `Add the direct members of a source project (-p) or group (-g) to a destination
+project (-P) or group (-G) with the same access level and expiry date.
+
+Users are matched by username, so they must already exist on the
+destination. Members without a matching user are listed at the end instead
+of stopping the migration. Users who already are members of the destination,
+directly or inherited from a parent group, are skipped, whatever their
+access level. Use --dry-run to list what would
+be added without changing the destination.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (groupID == "") == (projectID == "") || (destinationGroupID == "") == (destinationProjectID == "") {
+			return fmt.Errorf("provide one source (-g or -p) and one destination (--destination-group or --destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "group", groupID, destinationGroupID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migrateMembers(config)
+	},
+}
+
+// getMembers lists the members of a project or group (kind is "projects" or
+// "groups"), ordered by username. With inherited, the effective members are
+// listed, each with its highest access level and whether it is granted
+// directly or inherited.
+func getMembers(baseURL, accessToken, kind, id string, inherited bool) ([]map[string]interface{}, error) {
+	client := utils.CreateHTTPClient(newHTTPClientConfig())
+	membersURL := fmt.Sprintf("%s/api/v4/%s/%s/members", baseURL, kind, url.PathEscape(id))
+
+	direct, err := utils.Paginate(client, membersURL, accessToken, defaultPerPage)
+	if err != nil {
+		return nil, err
+	}
+	directLevels := make(map[string]int, len(direct))
+	for _, member := range direct {
+		directLevels[diffValue(member["id"])] = memberAccessLevel(member)
+	}
+
+	members := direct
+	if inherited {
+		all, err := utils.Paginate(client, membersURL+"/all", accessToken, defaultPerPage)
+		if err != nil {
+			return nil, err
+		}
+		members = highestAccessPerMember(all)
+	}
+
+	for _, member := range members {
+		level := memberAccessLevel(member)
+		member["access_level_name"] = accessLevelName(level)
+		member["membership"] = membershipDirect
+		if directLevel, ok := directLevels[diffValue(member["id"])]; !ok {
+			member["membership"] = membershipInherited
+		} else if directLevel < level {
+			member["membership"] = membershipInherited
+			member["direct_access_level"] = directLevel
+		}
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		return diffValue(members[i]["username"]) < diffValue(members[j]["username"])
+	})
+	return members, nil
+}
+
+// migrateMembers adds the direct source members to the destination, matching
+// users by username
+func migrateMembers(config *utils.Config) error {
+	sourceKind, sourceID := "projects", projectID
+	if groupID != "" {
+		sourceKind, sourceID = "groups", groupID
+	}
+	destinationKind, destinationID := "projects", destinationProjectID
+	if destinationGroupID != "" {
+		destinationKind, destinationID = "groups", destinationGroupID
+	}
+	sourceTarget := fmt.Sprintf("%s %s", sourceKind[:len(sourceKind)-1], sourceID)
+	destinationTarget := fmt.Sprintf("%s %s", destinationKind[:len(destinationKind)-1], destinationID)
+
+	sourceMembers, err := getMembers(config.SourceBaseURL, config.SourceAccessToken, sourceKind, sourceID, false)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	// Members inherited from a parent group already have access, and GitLab
+	// rejects adding them directly with the same or a lower access level
+	destinationMembers, err := getMembers(config.DestinationBaseURL, config.DestinationAccessToken, destinationKind, destinationID, true)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]string, len(destinationMembers))
+	for _, member := range destinationMembers {
+		existing[diffValue(member["username"])] = diffValue(member["membership"])
+	}
+
+	prefix := ""
+	if membersDryRun {
+		prefix = "[dry run] "
+	}
+
+	membersURL := fmt.Sprintf("%s/api/v4/%s/%s/members", config.DestinationBaseURL, destinationKind, url.PathEscape(destinationID))
+	mapper := newIDMapper(config.DestinationBaseURL, config.DestinationAccessToken, "")
+	log.Printf("Migrating %d members from %s to %s", len(sourceMembers), sourceTarget, destinationTarget)
+	var unresolved []string
+	added, skipped, failed := 0, 0, 0
+	for _, member := range sourceMembers {
+		username := diffValue(member["username"])
+		level := memberAccessLevel(member)
+		if membership, ok := existing[username]; ok {
+			if membership == membershipInherited {
+				fmt.Printf("skipped: member %s (already a member through a parent group)\n", username)
+			} else {
+				fmt.Printf("skipped: member %s (already a member)\n", username)
+			}
+			skipped++
+			continue
+		}
+
+		userID, err := mapper.userID(username)
+		if err != nil {
+			if errors.Is(err, utils.ErrUnauthorized) {
+				return err
+			}
+			fmt.Printf("failed: member %s: %v\n", username, err)
+			failed++
+			continue
+		}
+		if userID == 0 {
+			unresolved = append(unresolved, username)
+			continue
+		}
+
+		if !membersDryRun {
+			payload := map[string]interface{}{"user_id": userID, "access_level": level}
+			if expiresAt, ok := member["expires_at"].(string); ok && expiresAt != "" {
+				payload["expires_at"] = expiresAt
+			}
+			if err := postGitLabJSON(membersURL, config.DestinationAccessToken, payload, nil); err != nil {
+				if errors.Is(err, utils.ErrUnauthorized) {
+					return err
+				}
+				fmt.Printf("failed: member %s: %v\n", username, err)
+				failed++
+				continue
+			}
+			existing[username] = membershipDirect
+		}
+		fmt.Printf("%sadded: member %s as %s\n", prefix, username, accessLevelName(level))
+		added++
+	}
+
+	if len(unresolved) > 0 {
+		log.Printf("Warning: %d members have no user with the same username on the destination and were not added: %s", len(unresolved), strings.Join(unresolved, ", "))
+	}
+	if membersDryRun {
+		log.Printf("Dry run: %d members would be added, %d skipped (already members), %d without a destination user", added, skipped, len(unresolved))
+		return nil
+	}
+	log.Printf("Added %d members, skipped %d (already members), %d without a destination user, %d failed", added, skipped, len(unresolved), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d members could not be added", failed)
+	}
+	return nil
+}
+
+// highestAccessPerMember keeps one entry per user, the one with the highest
+// access level. Older GitLab versions list a user once per membership.
+func highestAccessPerMember(members []map[string]interface{}) []map[string]interface{} {
+	var unique []map[string]interface{}
+	index := make(map[string]int, len(members))
+	for _, member := range members {
+		id := diffValue(member["id"])
+		if i, ok := index[id]; ok {
+			if memberAccessLevel(member) > memberAccessLevel(unique[i]) {
+				unique[i] = member
+			}
+			continue
+		}
+		index[id] = len(unique)
+		unique = append(unique, member)
+	}
+	return unique
+}
+
+// memberAccessLevel returns the access level of a member as decoded from JSON
+func memberAccessLevel(member map[string]interface{}) int {
+	level, _ := member["access_level"].(float64)
+	return int(level)
+}
+
+// accessLevelName returns GitLab's role name for an access level
+func accessLevelName(level int) string {
+	if name, ok := accessLevelNames[level]; ok {
+		return name
+	}
+	return fmt.Sprintf("Custom (%d)", level)
+}
+
+func init() {
+	getMembersCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to list the members of")
+	getMembersCmd.Flags().StringVarP(&groupID, "group", "g", "", "The GitLab group ID to list the members of")
+	getMembersCmd.Flags().BoolVar(&includeInherited, "include-inherited", false, "List effective members, including those inherited from parent groups")
+	getMembersCmd.Flags().BoolVar(&includeInherited, "all", false, "Same as --include-inherited (GitLab's members/all)")
+	getCmd.AddCommand(getMembersCmd)
+
+	migrateMembersCmd.Flags().StringVarP(&groupID, "group", "g", "", "Source group ID")
+	migrateMembersCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateMembersCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "Destination group ID")
+	migrateMembersCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migrateMembersCmd.Flags().BoolVar(&membersDryRun, "dry-run", false, "List the members that would be added without changing the destination")
+	migrateCmd.AddCommand(migrateMembersCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+func TestMigrateMembersSkipsInheritedMembers(t *testing.T) {
+	var mu sync.Mutex
+	var added []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/groups/1/members":
+			fmt.Fprint(w, `[
+				{"id":11,"username":"alice","access_level":30},
+				{"id":12,"username":"bob","access_level":40},
+				{"id":13,"username":"carol","access_level":30}
+			]`)
+		case "GET /api/v4/groups/2/members":
+			fmt.Fprint(w, `[{"id":21,"username":"alice","access_level":30}]`)
+		case "GET /api/v4/groups/2/members/all":
+			fmt.Fprint(w, `[
+				{"id":21,"username":"alice","access_level":30},
+				{"id":22,"username":"bob","access_level":50}
+			]`)
+		case "GET /api/v4/users":
+			if r.URL.Query().Get("username") != "carol" {
+				t.Errorf("looked up user %q, want only carol", r.URL.Query().Get("username"))
+			}
+			fmt.Fprint(w, `[{"id":23}]`)
+		case "POST /api/v4/groups/2/members":
+			body, _ := io.ReadAll(r.Body)
+			var payload map[string]interface{}
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Errorf("invalid member payload %s: %v", body, err)
+			}
+			added = append(added, payload)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	groupID, destinationGroupID = "1", "2"
+	t.Cleanup(func() { groupID, destinationGroupID = "", "" })
+	config := &utils.Config{
+		SourceBaseURL:          server.URL,
+		SourceAccessToken:      "srctoken",
+		DestinationBaseURL:     server.URL,
+		DestinationAccessToken: "dsttoken",
+	}
+
+	if err := migrateMembers(config); err != nil {
+		t.Fatalf("migrateMembers: %v", err)
+	}
+	want := []map[string]interface{}{{"user_id": float64(23), "access_level": float64(30)}}
+	if !reflect.DeepEqual(added, want) {
+		t.Errorf("added %v, want only carol: %v", added, want)
+	}
+}
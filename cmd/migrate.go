@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
@@ -20,6 +25,11 @@ This command helps you transfer various GitLab resources including:
 Use the appropriate subcommand to specify what you want to migrate.`,
 }
 
+var reverseMigration bool
+var stripNamespacePrefix bool
+var variablesMatchBy string
+var parallelProjects int
+
 var migrateVariablesCmd = &cobra.Command{
 	Use:   "variables",
 	Short: "Migrate variables between GitLab instances",
@@ -31,64 +41,289 @@ This command supports:
 
 Required flags:
 - Source: Use either -g (group ID) or -p (project ID)
-- Destination: Use either --destination-group or --destination-project`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if (groupID == "" && projectID == "") || (destinationGroupID == "" && destinationProjectID == "") {
-			log.Println("Error: Source and destination IDs must be provided using one of:")
-			log.Println("  - Source group (-g) and destination group (--destination-group)")
-			log.Println("  - Source project (-p) and destination project (--destination-project)")
-			return
+- Destination: Use either --destination-group or --destination-project
+  (a project ID or full path such as group/subgroup/project)
+
+Existing destination variables are handled according to --on-conflict
+(skip, update, fail or rename), see "set variables --help".
+
+Use --key to migrate a single variable, adding --env-scope when the key is
+defined for several environment scopes. --key can't be combined with -r.
+
+--reverse swaps the roles of the source and destination instances for the
+run, e.g. to backport variables edited on the new instance. The IDs given
+with -g/-p are then read from the destination instance and -G/-P are written
+on the source instance. Use --dry-run to report what would be created or
+updated without changing anything.
+
+--parallel-projects sets how many destination projects are written at the
+same time with -r or --project-ids (default 1, one after the other). The
+variables of a project are always written one request at a time, so about
+that many requests are in flight on the destination at once, and
+--max-conns-per-host caps the connections regardless. GitLab rate-limits API
+requests per user and all projects share the same token: raise the value
+gradually, since requests over the limit fail with 429 Too Many Requests and
+are reported as failed.
+
+Variables that fail, or are not applied because the run stopped, are listed
+in data/failures.json. --retry-from data/failures.json migrates only those
+again, with their current source values; combine it with --on-conflict update
+for variables that were partially written. A retry rewrites the file with
+whatever still fails, and removes it once everything succeeded.
+
+--project-ids migrates an explicit list of source projects, given as
+"12,34,56" or "@file" with one ID per line, into the projects of the
+destination group -G. They are paired as --match-by selects: by their path
+relative to -g and -G when the source group -g is given, otherwise (or with
+--match-by name) by name.
+
+A recursive run pairs the projects, including those in subgroups, by their
+path relative to the source and destination groups, so same-named projects
+in different subgroups are told apart. The relative path is
+path_with_namespace with the full path of the -g group (on the source) or
+the -G group (on the destination) and the slash after it removed: migrating
+-g old-org into -G new-org pairs old-org/backend/api with new-org/backend/api
+as "backend/api". Source projects without a destination project at the same
+relative path are reported. --match-by name pairs the projects of -g by name
+instead, as older versions did. --strip-namespace-prefix is the same as
+--match-by path.
+
+--since-export prev.json migrates only the variables that were added or
+changed since a previous export, as compared by "diff variables"; unchanged
+variables are not sent again. The previous export can be a "get variables"
+file or the source backup a migration writes to data/, which makes each run
+the baseline of the next. Changed variables are updated unless --on-conflict
+is given. Variables removed from the source are reported but not deleted.
+
+Projects of a recursive run are migrated in a stable order, by ID unless
+--sort-projects selects path or name, so logs of two runs can be compared.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if retryFrom != "" {
+			if groupID != "" || projectID != "" || destinationGroupID != "" || destinationProjectID != "" || projectIDList != "" || variableKey != "" || sinceExport != "" {
+				return fmt.Errorf("--retry-from takes the groups, projects and keys from the failures file, don't combine it with -g, -p, -G, -P, --key or --since-export")
+			}
+		} else if projectIDList != "" {
+			if destinationGroupID == "" || destinationProjectID != "" {
+				return fmt.Errorf("--project-ids migrates into the projects of a destination group (-G), paired with them as --match-by selects")
+			}
+		} else if (groupID == "" && projectID == "") || (destinationGroupID == "" && destinationProjectID == "") {
+			return fmt.Errorf("source and destination IDs must be provided using one of:\n" +
+				"  - Source group (-g) and destination group (--destination-group)\n" +
+				"  - Source project (-p) and destination project (--destination-project)")
+		}
+
+		// Changed variables already exist on the destination and need updating
+		if sinceExport != "" && !cmd.Flags().Changed("on-conflict") {
+			onConflict = conflictUpdate
+		}
+
+		if parallelProjects < 1 {
+			return fmt.Errorf("--parallel-projects must be at least 1")
+		}
+
+		if err := validateConflictStrategy(onConflict); err != nil {
+			return err
+		}
+
+		if err := validateUnmaskableStrategy(unmaskable); err != nil {
+			return err
+		}
+
+		if err := setupKeyFilter(); err != nil {
+			return err
+		}
+
+		if err := validateProjectSort(sortProjectsBy); err != nil {
+			return err
+		}
+
+		projectIDs, _, err := parseIDListFlags()
+		if err != nil {
+			return err
+		}
+
+		if variableKey != "" && (recursive || len(projectIDs) > 0) {
+			return fmt.Errorf("--key selects a variable of a single group or project and can't be used with -r or --project-ids")
+		}
+		if stripNamespacePrefix && (!recursive || groupID == "" || destinationGroupID == "") {
+			return fmt.Errorf("--strip-namespace-prefix pairs the projects of a recursive group migration (-g, -G and -r)")
+		}
+		matchBy, err := parseMatchBy(variablesMatchBy)
+		if err != nil {
+			return err
+		}
+		if stripNamespacePrefix && matchBy == matchByName {
+			return fmt.Errorf("--strip-namespace-prefix pairs projects by path and can't be combined with --match-by name")
+		}
+		// Relative paths need the source group the projects are under
+		if len(projectIDs) > 0 && groupID == "" && matchBy == matchByPath && cmd.Flags().Changed("match-by") {
+			return fmt.Errorf("--match-by path pairs --project-ids by their path relative to the source group, give it with -g")
+		}
+		pairByPath := (recursive || len(projectIDs) > 0) && groupID != "" && matchBy == matchByPath
+		if variableEnvScope != "" && variableKey == "" {
+			return fmt.Errorf("--env-scope can only be used with --key, use --environment-scope to migrate every variable of a scope")
 		}
 
 		// Load configuration
 		config, err := loadConfig()
 		if err != nil {
-			log.Printf("Error loading config: %v", err)
-			return
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+		if reverseMigration {
+			reversed := config.Reversed()
+			config = &reversed
+			log.Printf("REVERSE MIGRATION: reading from the destination %s and writing to the source %s", config.SourceBaseURL, config.DestinationBaseURL)
+		} else {
+			log.Printf("Migrating from %s to %s", config.SourceBaseURL, config.DestinationBaseURL)
+		}
+		if variablesDryRun {
+			log.Println("Dry run: no variables will be changed")
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+
+		if err := checkSameInstance(config, "group", groupID, destinationGroupID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
 		}
 
 		if err := utils.EnsureDataDir(); err != nil {
-			log.Printf("Error: %v", err)
-			return
+			return err
+		}
+
+		if retryFrom != "" {
+			failures, err := readFailures(config, retryFrom)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := interruptContext()
+			defer stop()
+			sources := retryFailures(ctx, config, failures)
+			variablesSummary.print()
+			if !variablesDryRun {
+				if err := writeFailures(config, sources, defaultFailuresFile()); err != nil {
+					log.Printf("Error: %v", err)
+				}
+			}
+			exitIfInterrupted(ctx)
+			return nil
 		}
 
 		// Get source variables
 		var sourceVars interface{}
-		if groupID != "" {
-			if recursive {
-				sourceVars = getAllVariablesForGroupProjects(config, groupID)
+		if len(projectIDs) > 0 {
+			sourceVars, err = getAllVariablesForProjects(config, projectIDs, keyByID)
+		} else if groupID != "" {
+			if pairByPath {
+				projects, err := fetchProjectsWithSubgroups(config.SourceBaseURL, config.SourceAccessToken, groupID)
+				if err != nil {
+					return fmt.Errorf("failed to fetch source projects: %v", err)
+				}
+				sourceVars, err = variablesByProject(config, projects, keyByID)
+				if err != nil {
+					return fmt.Errorf("failed to fetch source variables: %v", err)
+				}
+			} else if recursive {
+				sourceVars, err = getAllVariablesForGroupProjects(config, groupID, keyByID)
 			} else {
-				sourceVars = getVariablesForGroup(config, groupID)
+				sourceVars, err = getVariablesForGroup(config, groupID)
 			}
 		} else {
-			sourceVars = getVariablesForProject(config, projectID)
+			sourceVars, err = getVariablesForProject(config, projectID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch source variables: %v", err)
 		}
 
-		// Save source variables to file (for reference)
-		sourceFile := utils.GenerateOutputFileName("variables", groupID, projectID, false, recursive)
-		if err := saveOutputToFile(sourceVars, sourceFile); err != nil {
-			log.Printf("Error saving source variables: %v", err)
-			return
+		if variableKey != "" {
+			vars, _ := sourceVars.([]map[string]interface{})
+			selected, err := selectVariable(vars, variableKey, variableEnvScope)
+			if err != nil {
+				return err
+			}
+			sourceVars = selected
 		}
 
+		// Read the previous export first, it may be the backup about to be overwritten
+		var previousExport interface{}
+		if sinceExport != "" {
+			if previousExport, err = readPreviousExport(sinceExport); err != nil {
+				return err
+			}
+		}
+
+		// Save source variables to file (for reference), even when there are
+		// none, as the record of what the migration started from
+		sourceFile := utils.GenerateOutputFileName("variables", groupID, projectID, reverseMigration, recursive)
+		if err := writeOutputFile(sourceVars, sourceFile); err != nil {
+			return fmt.Errorf("failed to save source variables: %v", err)
+		}
+
+		// The backup above holds every source variable, so it can serve as the
+		// previous export of the next incremental run
+		if sinceExport != "" {
+			if sourceVars, err = filterSinceExport(sourceVars, previousExport); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		// sources maps each summary target to the ID it is migrated from, for the failures file
+		sources := make(map[string]string)
+
 		// Create variables in destination
-		if groupID != "" {
-			if recursive {
-				log.Printf("Migrating variables recursively from group %s to group %s", groupID, destinationGroupID)
+		if groupID != "" || len(projectIDs) > 0 {
+			if recursive || len(projectIDs) > 0 {
+				if len(projectIDs) > 0 {
+					log.Printf("Migrating variables of %d projects to group %s", len(projectIDs), destinationGroupID)
+				} else {
+					log.Printf("Migrating variables recursively from group %s to group %s", groupID, destinationGroupID)
+				}
 				sourceVarsMap, ok := sourceVars.(map[string]map[string]interface{})
 				if !ok {
-					log.Printf("Error: Invalid source variables format")
-					return
+					return fmt.Errorf("invalid source variables format")
 				}
 
-				// Get destination projects to map names to IDs
-				destProjects, err := fetchAllProjects(config)
-				if err != nil {
-					log.Printf("Error fetching destination projects: %v", err)
-					return
+				// Destination projects are looked up by their path relative to -G,
+				// unless they are matched by name. Either way they are indexed once,
+				// not searched for every source project.
+				var sourceGroupPath string
+				var destProjectsByPath map[string]int64
+				var destIndex *projectIndex
+				if pairByPath {
+					if sourceGroupPath, err = groupFullPath(config.SourceBaseURL, config.SourceAccessToken, groupID); err != nil {
+						return err
+					}
+					if destProjectsByPath, err = projectsByRelativePath(config.DestinationBaseURL, config.DestinationAccessToken, destinationGroupID); err != nil {
+						return err
+					}
+				} else {
+					destProjects, err := fetchAllProjects(config)
+					if err != nil {
+						return fmt.Errorf("failed to fetch destination projects: %v", err)
+					}
+					destIndex = newProjectIndex(destProjects)
 				}
 
-				for sourceProjectID, projectData := range sourceVarsMap {
+				// Pair the projects first, then write up to --parallel-projects of them at once
+				type projectJob struct {
+					n             int
+					name          string
+					sourceID      string
+					destinationID string
+					variables     []interface{}
+				}
+				var jobs []projectJob
+				projectKeys := sortedProjectKeys(sourceVarsMap, sortProjectsBy)
+				for n, sourceProjectID := range projectKeys {
+					projectData := sourceVarsMap[sourceProjectID]
 					projectName, ok := projectData["project_name"].(string)
 					if !ok {
 						log.Printf("Error: Project name not found for project %s", sourceProjectID)
@@ -96,10 +331,24 @@ Required flags:
 					}
 
 					// Find the corresponding project in destination
-					destProjectID := findProjectIDByExactName(destProjects, projectName)
-					if destProjectID == 0 {
-						log.Printf("Warning: Project %s not found in destination group", projectName)
-						continue
+					var destProjectID int64
+					if pairByPath {
+						projectPath, _ := projectData["path_with_namespace"].(string)
+						relativePath, ok := relativeProjectPath(projectPath, sourceGroupPath)
+						if !ok {
+							log.Printf("Warning: Project %s is not under source group %s", projectPath, sourceGroupPath)
+							continue
+						}
+						if destProjectID = destProjectsByPath[relativePath]; destProjectID == 0 {
+							log.Printf("Warning: Project %s has no destination project at relative path %s", projectPath, relativePath)
+							continue
+						}
+					} else {
+						destProjectID = destIndex.byName(projectName)
+						if destProjectID == 0 {
+							log.Printf("Warning: Project %s not found in destination group", projectName)
+							continue
+						}
 					}
 
 					vars, ok := projectData["variables"].([]map[string]interface{})
@@ -114,39 +363,68 @@ Required flags:
 						interfaceVars[i] = v
 					}
 
-					log.Printf("Migrating variables for project %s (ID: %d)", projectName, destProjectID)
-					createVariablesForProject(config, strconv.FormatInt(destProjectID, 10), interfaceVars)
+					jobs = append(jobs, projectJob{n: n + 1, name: projectName, sourceID: sourceProjectID,
+						destinationID: strconv.FormatInt(destProjectID, 10), variables: interfaceVars})
 				}
+
+				var sourcesMu sync.Mutex
+				forEachConcurrently(len(jobs), parallelProjects, func(i int) {
+					if ctx.Err() != nil {
+						return
+					}
+					job := jobs[i]
+					target := "project " + job.destinationID
+					utils.WithFields(utils.Fields{"project_id": job.destinationID, "source_project_id": job.sourceID}).
+						Infof("[%d/%d] Migrating variables for project %s (ID: %s)", job.n, len(projectKeys), job.name, job.destinationID)
+					sourcesMu.Lock()
+					sources[target] = job.sourceID
+					sourcesMu.Unlock()
+					withProjectTimeout(ctx, target, func(ctx context.Context) {
+						createVariablesForProject(ctx, config, job.destinationID, job.variables)
+					})
+				})
 			} else {
 				log.Printf("Migrating variables from group %s to group %s", groupID, destinationGroupID)
 				vars, ok := sourceVars.([]map[string]interface{})
 				if !ok {
-					log.Printf("Error: Invalid source variables format")
-					return
+					return fmt.Errorf("invalid source variables format")
 				}
 				// Convert []map[string]interface{} to []interface{}
 				interfaceVars := make([]interface{}, len(vars))
 				for i, v := range vars {
 					interfaceVars[i] = v
 				}
-				createVariablesForGroup(config, destinationGroupID, interfaceVars)
+				sources["group "+destinationGroupID] = groupID
+				createVariablesForGroup(ctx, config, destinationGroupID, interfaceVars)
 			}
 		} else {
 			log.Printf("Migrating variables from project %s to project %s", projectID, destinationProjectID)
 			vars, ok := sourceVars.([]map[string]interface{})
 			if !ok {
-				log.Printf("Error: Invalid source variables format")
-				return
+				return fmt.Errorf("invalid source variables format")
 			}
 			// Convert []map[string]interface{} to []interface{}
 			interfaceVars := make([]interface{}, len(vars))
 			for i, v := range vars {
 				interfaceVars[i] = v
 			}
-			createVariablesForProject(config, destinationProjectID, interfaceVars)
+			sources["project "+destinationProjectID] = projectID
+			createVariablesForProject(ctx, config, destinationProjectID, interfaceVars)
 		}
 
+		variablesSummary.print()
+		if !variablesDryRun {
+			if err := writeFailures(config, sources, defaultFailuresFile()); err != nil {
+				log.Printf("Error: %v", err)
+			}
+		}
+		exitIfInterrupted(ctx)
+		if variablesDryRun {
+			log.Println("Dry run completed, no variables were changed")
+			return nil
+		}
 		log.Println("Variables migration completed successfully")
+		return nil
 	},
 }
 
@@ -154,12 +432,92 @@ func init() {
 	rootCmd.AddCommand(migrateCmd)
 	migrateCmd.AddCommand(migrateVariablesCmd)
 
+	migrateCmd.PersistentFlags().BoolVar(&allowSameInstance, "allow-same-instance", false, "Allow the source and destination to be the same group or project on the same instance")
+
 	// Add flags for source IDs
 	migrateVariablesCmd.Flags().StringVarP(&groupID, "group", "g", "", "Source group ID")
 	migrateVariablesCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
 	migrateVariablesCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively migrate variables from all projects in a group")
+	migrateVariablesCmd.Flags().BoolVar(&stripNamespacePrefix, "strip-namespace-prefix", false, "Pair recursive projects by path relative to -g and -G, the same as --match-by path")
+	migrateVariablesCmd.Flags().StringVar(&variablesMatchBy, "match-by", "path", "Pair recursive or --project-ids projects by path relative to -g and -G, including subgroups, or by name")
+	migrateVariablesCmd.Flags().StringVar(&sortProjectsBy, "sort-projects", sortProjectsByID, "Order in which recursive runs migrate projects: id, path or name")
+	migrateVariablesCmd.Flags().StringVar(&projectIDList, "project-ids", "", "Comma-separated source project IDs, or @file with one ID per line, to migrate into the destination group")
+	migrateVariablesCmd.Flags().BoolVar(&reverseMigration, "reverse", false, "Swap the source and destination instances, migrating from the destination back to the source")
+	migrateVariablesCmd.Flags().BoolVar(&variablesDryRun, "dry-run", false, "Report what would be created or updated without changing anything")
+	migrateVariablesCmd.Flags().StringVar(&sinceExport, "since-export", "", "Migrate only variables added or changed since this previous export, e.g. an earlier source backup")
+	migrateVariablesCmd.Flags().IntVar(&parallelProjects, "parallel-projects", 1, "Number of destination projects written at the same time with -r or --project-ids")
+	migrateVariablesCmd.Flags().DurationVar(&timeoutPerProject, "timeout-per-project", 0, "Give up on a project after this long when migrating several, recording its variables as timed out (0 means no limit)")
+	migrateVariablesCmd.Flags().StringVar(&retryFrom, "retry-from", "", "Migrate only the variables listed in a failures file, e.g. data/failures.json")
+	migrateVariablesCmd.Flags().StringVar(&variableKey, "key", "", "Migrate only the variable with this key")
+	migrateVariablesCmd.Flags().StringVar(&variableEnvScope, "env-scope", "", "Environment scope of the --key variable when it is defined for several scopes")
+	migrateVariablesCmd.Flags().StringArrayVar(&filterKeys, "filter-key", nil, "Only migrate variables whose key matches this pattern: a glob like PROD_*, or a regular expression between slashes like /^PROD_/ (repeatable)")
+	migrateVariablesCmd.Flags().StringArrayVar(&excludeKeys, "exclude-key", nil, "Leave out variables whose key matches this glob or /regular expression/ (repeatable, wins over --filter-key)")
+	migrateVariablesCmd.Flags().StringVar(&filterEnvironmentScope, "environment-scope", "", "Only migrate variables of this environment scope, e.g. production (* or empty for every scope); scopes are kept as they are")
 
 	// Add flags for destination IDs
 	migrateVariablesCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "Destination group ID")
-	migrateVariablesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID")
+	migrateVariablesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+
+	// Conflict handling for variables that already exist on the destination
+	migrateVariablesCmd.Flags().BoolVar(&compactOutput, "compact", false, "Write the source variables backup without indentation")
+	migrateVariablesCmd.Flags().BoolVar(&gzipOutput, "gzip", false, "Write the source variables backup gzip-compressed to a .json.gz file")
+	migrateVariablesCmd.Flags().StringVar(&onConflict, "on-conflict", conflictFail, "What to do when a variable key and scope already exist: skip, update, fail or rename")
+	migrateVariablesCmd.Flags().StringVar(&renameSuffix, "rename-suffix", "_MIGRATED", "Suffix appended to the key of conflicting variables with --on-conflict=rename")
+	migrateVariablesCmd.Flags().StringVar(&unmaskable, "unmaskable", unmaskableKeep, "Masked variables GitLab can't mask: keep (send as-is), unmask (create unmasked with a warning) or skip")
+	migrateVariablesCmd.Flags().BoolVar(&ensureEnvironments, "ensure-environments", false, "Create the environments named by variable scopes on destination projects that lack them")
+	migrateVariablesCmd.Flags().BoolVar(&unprotect, "unprotect", false, "Create protected variables as unprotected, e.g. when the destination has no protected branches yet")
+	migrateVariablesCmd.Flags().BoolVar(&sortKeys, "sort-keys", true, "Create variables sorted by key and environment scope for stable, comparable runs")
+}
+
+// relativeProjectPath returns projectPath relative to the group at groupPath:
+// the project's path_with_namespace without the group's full path and the
+// slash after it. ok is false when the project isn't under the group.
+func relativeProjectPath(projectPath, groupPath string) (string, bool) {
+	prefix := strings.Trim(groupPath, "/") + "/"
+	if !strings.HasPrefix(projectPath, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(projectPath, prefix), true
+}
+
+// groupFullPath fetches the full path of a group
+func groupFullPath(baseURL, accessToken, groupID string) (string, error) {
+	var group namespaceInfo
+	if err := getGitLabJSON(fmt.Sprintf("%s/api/v4/groups/%s", baseURL, url.PathEscape(groupID)), accessToken, &group); err != nil {
+		return "", fmt.Errorf("failed to fetch group %s: %w", groupID, err)
+	}
+	return group.FullPath, nil
+}
+
+// fetchProjectsWithSubgroups lists the projects of a group and all its subgroups
+func fetchProjectsWithSubgroups(baseURL, accessToken, groupID string) ([]map[string]interface{}, error) {
+	projectsURL := fmt.Sprintf("%s/api/v4/groups/%s/projects?include_subgroups=true", baseURL, url.PathEscape(groupID))
+	projects, err := paginateCached(newGitLabClient("", accessToken), projectsURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching projects of group %s: %w", groupID, err)
+	}
+	return projects, nil
+}
+
+// projectsByRelativePath maps the path of every project of a group and its
+// subgroups, relative to the group, to the project ID
+func projectsByRelativePath(baseURL, accessToken, groupID string) (map[string]int64, error) {
+	groupPath, err := groupFullPath(baseURL, accessToken, groupID)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := fetchProjectsWithSubgroups(baseURL, accessToken, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]int64, len(projects))
+	for _, project := range projects {
+		projectPath, _ := project["path_with_namespace"].(string)
+		id, ok := project["id"].(float64)
+		if relativePath, under := relativeProjectPath(projectPath, groupPath); under && ok {
+			byPath[relativePath] = int64(id)
+		}
+	}
+	return byPath, nil
 }
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// groupListingHandler serves the new-org group and the projects of it and
+// its subgroups, two of them named api in different subgroups
+func groupListingHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/groups/new-org":
+			fmt.Fprint(w, `{"id":1,"full_path":"new-org"}`)
+		case "/api/v4/groups/new-org/projects":
+			if r.URL.Query().Get("include_subgroups") != "true" {
+				t.Errorf("projects listed without include_subgroups: %s", r.URL)
+			}
+			fmt.Fprint(w, `[
+				{"id":10,"name":"web","path_with_namespace":"new-org/web"},
+				{"id":11,"name":"api","path_with_namespace":"new-org/backend/api"},
+				{"id":12,"name":"api","path_with_namespace":"new-org/frontend/api"}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestMatchByPathPairsSameNamedProjects(t *testing.T) {
+	server := httptest.NewServer(groupListingHandler(t))
+	t.Cleanup(server.Close)
+	noCache = true
+	t.Cleanup(func() { noCache = false })
+
+	byPath, err := projectsByRelativePath(server.URL, "dsttoken", "new-org")
+	if err != nil {
+		t.Fatalf("projectsByRelativePath: %v", err)
+	}
+
+	tests := []struct {
+		sourcePath string
+		wantUnder  bool
+		wantID     int64
+	}{
+		{"old-org/web", true, 10},
+		{"old-org/backend/api", true, 11},
+		{"old-org/frontend/api", true, 12},
+		{"old-org/api", true, 0},
+		{"old-org/backend/web", true, 0},
+		{"other-org/backend/api", false, 0},
+		{"old-org-2/web", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sourcePath, func(t *testing.T) {
+			relativePath, under := relativeProjectPath(tt.sourcePath, "old-org")
+			if under != tt.wantUnder {
+				t.Fatalf("relativeProjectPath(%q) under = %v, want %v", tt.sourcePath, under, tt.wantUnder)
+			}
+			if got := byPath[relativePath]; under && got != tt.wantID {
+				t.Errorf("destination of %s (%s) = %d, want %d", tt.sourcePath, relativePath, got, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var nativeMigration bool
+var destinationNamespace string
+var destinationPath string
+var archivePath string
+var migrationTimeout time.Duration
+
+// migrateProjectCmd migrates a whole project using GitLab's native export/import
+var migrateProjectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Migrate a project using GitLab's native export and import",
+	Long: `Migrate a complete project, including issues, merge requests, pipelines
+and wiki, using GitLab's native project export and import.
+
+With --native the command:
+1. Schedules an export of the source project (-p)
+2. Polls until the export is finished
+3. Streams the export archive to disk (--archive, default under data/)
+4. Uploads the archive to create the project on the destination
+5. Polls until the import is finished
+
+The destination project is created in --destination-namespace (defaults to
+the source project's namespace path) with --destination-path (defaults to
+the source project's path). The archive is kept after the import.
+
+The project keeps the source visibility unless --target-visibility forces
+private, internal or public, e.g. for instances that don't allow public
+projects. GitLab lowers a visibility the destination namespace doesn't allow;
+such downgrades are reported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !nativeMigration {
+			log.Println("Error: only native export/import is supported, use --native")
+			return
+		}
+
+		if projectID == "" {
+			log.Println("Error: the source project (-p) must be provided")
+			return
+		}
+
+		if err := validateTargetVisibility(targetVisibility); err != nil {
+			log.Printf("Error: %v", err)
+			return
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			log.Printf("Error loading config: %v", err)
+			return
+		}
+
+		if err := migrateProjectNative(config, projectID); err != nil {
+			log.Printf("Error: %v", err)
+			return
+		}
+	},
+}
+
+// migrateProjectNative exports sourceID from the source instance and imports
+// the archive into the destination instance
+func migrateProjectNative(config *utils.Config, sourceID string) error {
+	if err := scheduleProjectExport(config.SourceBaseURL, config.SourceAccessToken, sourceID); err != nil {
+		return fmt.Errorf("failed to schedule export of project %s: %v", sourceID, err)
+	}
+	log.Printf("Scheduled export of project %s", sourceID)
+
+	status, err := waitForExport(config.SourceBaseURL, config.SourceAccessToken, sourceID, migrationTimeout)
+	if err != nil {
+		return err
+	}
+
+	namespace := destinationNamespace
+	if namespace == "" {
+		if i := strings.LastIndex(status.PathWithNamespace, "/"); i != -1 {
+			namespace = status.PathWithNamespace[:i]
+		}
+	}
+	path := destinationPath
+	if path == "" {
+		path = status.Path
+	}
+
+	archive := archivePath
+	if archive == "" {
+		if err := utils.EnsureDataDir(); err != nil {
+			return err
+		}
+		archive = filepath.Join(utils.DataDir, fmt.Sprintf("export_p-%s.tar.gz", sourceID))
+	}
+
+	log.Printf("Downloading export of %s to %s", status.PathWithNamespace, archive)
+	written, err := downloadExport(config.SourceBaseURL, config.SourceAccessToken, sourceID, archive)
+	if err != nil {
+		return err
+	}
+	log.Printf("Downloaded %d bytes", written)
+
+	// The archive carries the source visibility; --target-visibility overrides it
+	var sourceProject struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := getGitLabJSON(fmt.Sprintf("%s/api/v4/projects/%s", config.SourceBaseURL, sourceID), config.SourceAccessToken, &sourceProject); err != nil {
+		log.Printf("Warning: Could not read the visibility of project %s: %v", sourceID, err)
+	}
+	requested := createVisibility(sourceProject.Visibility)
+
+	log.Printf("Importing %s as %s/%s", archive, namespace, path)
+	imported, err := importProject(config.DestinationBaseURL, config.DestinationAccessToken, archive, namespace, path, status.Name, targetVisibility)
+	if err != nil {
+		return err
+	}
+
+	imported, err = waitForImport(config.DestinationBaseURL, config.DestinationAccessToken, imported.ID, migrationTimeout)
+	if err != nil {
+		return err
+	}
+
+	// GitLab lowers the visibility to what the namespace and instance allow
+	// instead of failing the import, so report when that happened
+	var destinationProject struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := getGitLabJSON(fmt.Sprintf("%s/api/v4/projects/%d", config.DestinationBaseURL, imported.ID), config.DestinationAccessToken, &destinationProject); err == nil &&
+		requested != "" && destinationProject.Visibility != "" && destinationProject.Visibility != requested {
+		log.Printf("Warning: visibility of %s was downgraded from %s to %s by the destination", imported.PathWithNamespace, requested, destinationProject.Visibility)
+	}
+
+	log.Printf("Successfully migrated project %s to %s (ID: %d)", status.PathWithNamespace, imported.PathWithNamespace, imported.ID)
+	return nil
+}
+
+func init() {
+	migrateProjectCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateProjectCmd.Flags().BoolVar(&nativeMigration, "native", false, "Use GitLab's native project export and import")
+	migrateProjectCmd.Flags().StringVar(&destinationNamespace, "destination-namespace", "", "Destination namespace path (defaults to the source namespace)")
+	migrateProjectCmd.Flags().StringVar(&destinationPath, "destination-path", "", "Destination project path (defaults to the source path)")
+	migrateProjectCmd.Flags().StringVar(&targetVisibility, "target-visibility", "", "Visibility of the imported project: private, internal or public (default: the source project's)")
+	migrateProjectCmd.Flags().StringVar(&archivePath, "archive", "", "Where to store the export archive (defaults to data/export_p-<id>.tar.gz)")
+	migrateProjectCmd.Flags().DurationVar(&migrationTimeout, "wait-timeout", 30*time.Minute, "Maximum time to wait for the export and for the import to finish")
+
+	migrateCmd.AddCommand(migrateProjectCmd)
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// milestoneClosed is the state GitLab reports for closed milestones
+const milestoneClosed = "closed"
+
+// milestoneFields are the milestone attributes copied to the destination
+var milestoneFields = []string{"title", "description", "due_date", "start_date"}
+
+// milestoneColumns are the default table columns for milestones
+var milestoneColumns = []string{"id", "title", "state", "start_date", "due_date"}
+
+var milestonesDryRun bool
+
+// getMilestonesCmd lists the milestones of a project or group
+var getMilestonesCmd = &cobra.Command{
+	Use:   "milestones",
+	Short: "Retrieve the milestones of a GitLab project or group",
+	Long:  `Retrieve the milestones of a project (-p) or group (-g), active and closed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if (projectID == "") == (groupID == "") {
+			return fmt.Errorf("either --project or --group must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+		if isDestination {
+			baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+		}
+		kind, id := "project", projectID
+		if groupID != "" {
+			kind, id = "group", groupID
+		}
+		_, collectionURL := namespacedEndpoint(baseURL, groupID, projectID, "milestones")
+
+		milestones, err := fetchMilestones(collectionURL, accessToken)
+		if err != nil {
+			return notFoundError(err, kind, id)
+		}
+
+		if outputFormat == outputFormatTable {
+			return printTable(milestones, milestoneColumns, "")
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("milestones", groupID, projectID, isDestination, false)
+		}
+
+		if err := saveOutputToFile(milestones, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// migrateMilestonesCmd copies milestones between projects or groups
+var migrateMilestonesCmd = &cobra.Command{
+	Use:   "milestones",
+	Short: "Migrate milestones between GitLab projects or groups",
+	Long: `Copy the milestones of a source project (-p) or group (-g) to a destination
+project (-P) or group (-G), keeping their title, description, start date and
+due date.
+
+Milestones are matched by title, so re-runs don't create duplicates. Closed
+source milestones are created and then closed on the destination; a matching
+destination milestone that is still active is closed as well. Use --dry-run
+to list the changes without making them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (groupID == "") == (projectID == "") || (destinationGroupID == "") == (destinationProjectID == "") {
+			return fmt.Errorf("provide one source (-g or -p) and one destination (--destination-group or --destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "group", groupID, destinationGroupID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migrateMilestones(config)
+	},
+}
+
+// fetchMilestones lists every milestone of a group or project, active and closed
+func fetchMilestones(collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	milestones, err := newGitLabClient("", accessToken).GetPaginated(collectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching milestones: %w", err)
+	}
+	return milestones, nil
+}
+
+// migrateMilestones creates the source milestones missing on the destination
+// and closes the destination milestones whose source is closed
+func migrateMilestones(config *utils.Config) error {
+	sourceTarget, sourceURL := namespacedEndpoint(config.SourceBaseURL, groupID, projectID, "milestones")
+	destinationTarget, destinationURL := namespacedEndpoint(config.DestinationBaseURL, destinationGroupID, destinationProjectID, "milestones")
+
+	sourceMilestones, err := fetchMilestones(sourceURL, config.SourceAccessToken)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	destinationMilestones, err := fetchMilestones(destinationURL, config.DestinationAccessToken)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]map[string]interface{}, len(destinationMilestones))
+	for _, milestone := range destinationMilestones {
+		title, _ := milestone["title"].(string)
+		existing[title] = milestone
+	}
+
+	log.Printf("Migrating %d milestones from %s to %s", len(sourceMilestones), sourceTarget, destinationTarget)
+	created, closed, skipped, failed := 0, 0, 0, 0
+	for _, milestone := range sourceMilestones {
+		title, _ := milestone["title"].(string)
+		closeIt := milestone["state"] == milestoneClosed
+
+		destination, ok := existing[title]
+		if ok && (!closeIt || destination["state"] == milestoneClosed) {
+			fmt.Printf("skipped: milestone %s (already exists)\n", title)
+			skipped++
+			continue
+		}
+
+		prefix := ""
+		if milestonesDryRun {
+			prefix = "[dry run] "
+		}
+		if !ok {
+			if !milestonesDryRun {
+				payload := make(map[string]interface{})
+				for _, field := range milestoneFields {
+					if value, present := milestone[field]; present && value != nil {
+						payload[field] = value
+					}
+				}
+				destination = make(map[string]interface{})
+				if err := postGitLabJSON(destinationURL, config.DestinationAccessToken, payload, &destination); err != nil {
+					if errors.Is(err, utils.ErrUnauthorized) {
+						return err
+					}
+					fmt.Printf("failed: milestone %s: %v\n", title, err)
+					failed++
+					continue
+				}
+			}
+			fmt.Printf("%screated: milestone %s\n", prefix, title)
+			created++
+		}
+
+		if closeIt {
+			if !milestonesDryRun {
+				if err := closeMilestone(destinationURL, config.DestinationAccessToken, destination["id"]); err != nil {
+					if errors.Is(err, utils.ErrUnauthorized) {
+						return err
+					}
+					fmt.Printf("failed: closing milestone %s: %v\n", title, err)
+					failed++
+					continue
+				}
+			}
+			fmt.Printf("%sclosed: milestone %s\n", prefix, title)
+			closed++
+		}
+	}
+
+	if milestonesDryRun {
+		log.Printf("Dry run: %d milestones would be created and %d closed, %d skipped (already exist)", created, closed, skipped)
+		return nil
+	}
+	log.Printf("Created %d milestones, closed %d, skipped %d (already exist), %d failed", created, closed, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d milestones could not be migrated", failed)
+	}
+	return nil
+}
+
+// closeMilestone closes the milestone with the given ID under collectionURL
+func closeMilestone(collectionURL, accessToken string, id interface{}) error {
+	payload, err := json.Marshal(map[string]string{"state_event": "close"})
+	if err != nil {
+		return fmt.Errorf("error marshaling milestone payload: %v", err)
+	}
+	return makeGitLabAPIRequest("PUT", fmt.Sprintf("%s/%s", collectionURL, diffValue(id)), accessToken, string(payload))
+}
+
+func init() {
+	getMilestonesCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to list the milestones of")
+	getMilestonesCmd.Flags().StringVarP(&groupID, "group", "g", "", "The GitLab group ID to list the milestones of")
+	getCmd.AddCommand(getMilestonesCmd)
+
+	migrateMilestonesCmd.Flags().StringVarP(&groupID, "group", "g", "", "Source group ID")
+	migrateMilestonesCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateMilestonesCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "Destination group ID")
+	migrateMilestonesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migrateMilestonesCmd.Flags().BoolVar(&milestonesDryRun, "dry-run", false, "List the milestones that would be created or closed without changing the destination")
+	migrateCmd.AddCommand(migrateMilestonesCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMigrateMilestonesCreatesThenCloses(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/1/milestones":
+			fmt.Fprint(w, `[
+				{"id":1,"title":"v1","state":"closed","due_date":"2024-01-31"},
+				{"id":2,"title":"v2","state":"active"},
+				{"id":3,"title":"v0","state":"closed"},
+				{"id":4,"title":"v-1","state":"closed"}
+			]`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v4/projects/2/milestones":
+			fmt.Fprint(w, `[{"id":30,"title":"v0","state":"active"},{"id":40,"title":"v-1","state":"closed"}]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects/2/milestones":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			writes = append(writes, "POST "+string(body))
+			mu.Unlock()
+			id := 20
+			if strings.Contains(string(body), `"v2"`) {
+				id = 21
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id":%d}`, id)
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			writes = append(writes, "PUT "+r.URL.Path+" "+string(body))
+			mu.Unlock()
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	if err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "migrate", "milestones", "-p", "1", "-P", "2"); err != nil {
+		t.Fatalf("migrate milestones: %v", err)
+	}
+
+	want := []string{
+		`POST {"due_date":"2024-01-31","title":"v1"}`,
+		`PUT /api/v4/projects/2/milestones/20 {"state_event":"close"}`,
+		`POST {"title":"v2"}`,
+		`PUT /api/v4/projects/2/milestones/30 {"state_event":"close"}`,
+	}
+	if strings.Join(writes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("writes:\n%s\nwant:\n%s", strings.Join(writes, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,6 +19,42 @@ type MirrorCommand struct {
 	targetProjectID string
 	sourceGroupID   string
 	targetGroupID   string
+	destNamespace   string
+	createMissing   bool
+	dryRun          bool
+	matchBy         string
+	direction       string
+}
+
+// Ways to pair source and target projects when mirroring a group
+const (
+	matchByPath = "path_with_namespace"
+	matchByName = "name"
+)
+
+// Directions of a mirror: push mirrors are set up as remote mirrors, pull
+// mirrors by making the target project import from the source
+const (
+	mirrorDirectionPush = "push"
+	mirrorDirectionPull = "pull"
+)
+
+// parseMatchBy checks a --match-by value, accepting "path" for
+// path_with_namespace
+func parseMatchBy(value string) (string, error) {
+	switch value {
+	case matchByPath, "path":
+		return matchByPath, nil
+	case matchByName:
+		return matchByName, nil
+	}
+	return "", fmt.Errorf("unsupported --match-by value %q (use path or %s)", value, matchByName)
+}
+
+// namespaceInfo is the subset of a GitLab namespace used when mirroring
+type namespaceInfo struct {
+	ID       int64  `json:"id"`
+	FullPath string `json:"full_path"`
 }
 
 type MirrorPayload struct {
@@ -22,6 +62,12 @@ type MirrorPayload struct {
 	URL     string `json:"url"`
 }
 
+// PullMirrorPayload makes a project pull mirror the repository at ImportURL
+type PullMirrorPayload struct {
+	ImportURL string `json:"import_url"`
+	Mirror    bool   `json:"mirror"`
+}
+
 func NewMirrorCommand() *cobra.Command {
 	mc := &MirrorCommand{}
 	cmd := &cobra.Command{
@@ -30,15 +76,46 @@ func NewMirrorCommand() *cobra.Command {
 		Long: `Mirror GitLab projects between different instances.
 Examples:
   - Mirror single project: mirror -p sourceProjectID -P targetProjectID
-  - Mirror group projects: mirror -g sourceGroupID -G targetGroupID`,
+    (-P also accepts the target project's full path, e.g. new-org/team/app)
+  - Mirror group projects: mirror -g sourceGroupID -G targetGroupID
+  - Mirror group projects into another namespace: mirror -g sourceGroupID --dest-namespace new-org/team
+
+With --dest-namespace, each source project is matched to the destination
+project at the same path relative to the source group, under the given
+namespace (ID or full path). Missing destination projects are created with
+--create-missing, with the source project's visibility unless
+--target-visibility is given. If the destination rejects the source
+visibility, the next more restrictive level is used and the downgrade is
+reported. Use --dry-run to list the planned mirrors and projects to
+be created, with credentials redacted, and the source projects that have no
+matching destination project, without changing anything.
+
+With -G, source and target projects are paired by their path relative to each
+group. --match-by name pairs them by namespace and project name instead, as
+older versions did; names that match several projects are reported and skipped.
+
+By default push mirrors are created. A push mirror whose URL the target
+project already has is skipped, or enabled again if it was disabled, so
+re-runs don't add duplicates. --direction pull sets up pull mirrors
+instead, for destinations that can reach the source but not the other way
+around: the target project is switched to mirror the source repository,
+authenticated with the configured mirror credentials. Pull mirroring requires
+GitLab Premium on the destination.`,
 		RunE: mc.Run,
 	}
 
 	// Add flags
 	cmd.Flags().StringVarP(&mc.sourceProjectID, "source-project", "p", "", "Source project ID")
-	cmd.Flags().StringVarP(&mc.targetProjectID, "target-project", "P", "", "Target project ID")
+	cmd.Flags().StringVarP(&mc.targetProjectID, "target-project", "P", "", "Target project ID or full path")
 	cmd.Flags().StringVarP(&mc.sourceGroupID, "source-group", "g", "", "Source group ID")
 	cmd.Flags().StringVarP(&mc.targetGroupID, "target-group", "G", "", "Target group ID")
+	cmd.Flags().StringVar(&mc.destNamespace, "dest-namespace", "", "Destination namespace (ID or full path) to mirror group projects into")
+	cmd.Flags().BoolVar(&mc.createMissing, "create-missing", false, "Create destination projects that don't exist yet (with --dest-namespace)")
+	cmd.Flags().StringVar(&targetVisibility, "target-visibility", "", "Visibility of projects created with --create-missing: private, internal or public (default: the source project's)")
+	cmd.Flags().BoolVar(&allowSameInstance, "allow-same-instance", false, "Allow mirroring a project or group onto itself on the same instance")
+	cmd.Flags().BoolVar(&mc.dryRun, "dry-run", false, "Show what would be mirrored or created without making changes")
+	cmd.Flags().StringVar(&mc.direction, "direction", mirrorDirectionPush, "Mirror direction: push (remote mirrors) or pull (the target pulls from the source)")
+	cmd.Flags().StringVar(&mc.matchBy, "match-by", matchByPath, "Pair group projects by path (path_with_namespace relative to the group) or by namespace and project name")
 
 	return cmd
 }
@@ -52,14 +129,59 @@ func (mc *MirrorCommand) Run(cmd *cobra.Command, args []string) error {
 
 	// Validate flags
 	if (mc.sourceProjectID == "" && mc.sourceGroupID == "") ||
-		(mc.targetProjectID == "" && mc.targetGroupID == "") {
-		return fmt.Errorf("must specify either project IDs (-p, -P) or group IDs (-g, -G)")
+		(mc.targetProjectID == "" && mc.targetGroupID == "" && mc.destNamespace == "") {
+		return fmt.Errorf("must specify either project IDs (-p, -P) or group IDs (-g, -G or --dest-namespace)")
+	}
+
+	if mc.matchBy, err = parseMatchBy(mc.matchBy); err != nil {
+		return err
+	}
+
+	if mc.direction != mirrorDirectionPush && mc.direction != mirrorDirectionPull {
+		return fmt.Errorf("unsupported --direction value %q (use %s or %s)", mc.direction, mirrorDirectionPush, mirrorDirectionPull)
+	}
+
+	if mc.createMissing && mc.destNamespace == "" {
+		return fmt.Errorf("--create-missing requires --dest-namespace")
+	}
+
+	if err := validateTargetVisibility(targetVisibility); err != nil {
+		return err
+	}
+
+	if mc.targetProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, mc.targetProjectID); err != nil {
+		return err
+	}
+
+	if err := checkSameInstance(config, "project", mc.sourceProjectID, mc.targetProjectID); err != nil {
+		return err
+	}
+	if err := checkSameInstance(config, "group", mc.sourceGroupID, mc.targetGroupID); err != nil {
+		return err
 	}
 
 	if mc.sourceProjectID != "" && mc.targetProjectID != "" {
+		if mc.dryRun {
+			sourcePath, err := mc.sourceProjectPath(config, mc.sourceProjectID)
+			if err != nil {
+				return err
+			}
+			mirrorURL, err := mc.mirrorURL(config, sourcePath)
+			if err != nil {
+				return err
+			}
+			plan := &mirrorPlan{}
+			plan.addMirror(sourcePath, "project "+mc.targetProjectID, mirrorURL, false)
+			plan.print(config, mc.direction)
+			return nil
+		}
 		return mc.mirrorProject(config, mc.sourceProjectID, mc.targetProjectID)
 	}
 
+	if mc.sourceGroupID != "" && mc.destNamespace != "" {
+		return mc.mirrorGroupToNamespace(config, mc.sourceGroupID, mc.destNamespace)
+	}
+
 	if mc.sourceGroupID != "" && mc.targetGroupID != "" {
 		return mc.mirrorGroup(config, mc.sourceGroupID, mc.targetGroupID)
 	}
@@ -67,31 +189,115 @@ func (mc *MirrorCommand) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (mc *MirrorCommand) mirrorProject(config *utils.Config, sourceID, targetID string) error {
-	// Get source project details
-	sourceURL := fmt.Sprintf("%s/api/v4/projects/%s", config.SourceBaseURL, sourceID)
-	req, err := http.NewRequest("GET", sourceURL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+// mirrorPlan collects the mirrors a dry run would configure and the source
+// projects that have no matching destination project
+type mirrorPlan struct {
+	mirrors   []plannedMirror
+	unmatched []string
+}
+
+// plannedMirror is a single source to destination pairing in a mirrorPlan
+type plannedMirror struct {
+	source string
+	target string
+	url    string
+	create bool
+}
+
+func (p *mirrorPlan) addMirror(source, target, mirrorURL string, create bool) {
+	p.mirrors = append(p.mirrors, plannedMirror{source: source, target: target, url: mirrorURL, create: create})
+}
+
+func (p *mirrorPlan) addUnmatched(source string) {
+	p.unmatched = append(p.unmatched, source)
+}
+
+// print writes the plan; credentials in mirror URLs are redacted
+func (p *mirrorPlan) print(config *utils.Config, direction string) {
+	fmt.Printf("Planned mirrors (%d):\n", len(p.mirrors))
+	for _, m := range p.mirrors {
+		if m.create {
+			fmt.Printf("  %s -> %s (project will be created)\n", m.source, m.target)
+		} else {
+			fmt.Printf("  %s -> %s\n", m.source, m.target)
+		}
+		fmt.Printf("    %s URL: %s\n", direction, utils.RedactURL(m.url))
 	}
 
-	req.Header.Set("PRIVATE-TOKEN", config.SourceAccessToken)
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to get project details: %v", err)
+	if len(p.unmatched) > 0 {
+		fmt.Printf("Unmatched source projects (%d):\n", len(p.unmatched))
+		for _, source := range p.unmatched {
+			fmt.Printf("  %s\n", source)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to get project details, status: %d", resp.StatusCode)
+	if config.AuthUser == "" || config.AuthPassword == "" {
+		fmt.Println("Note: mirror credentials are not configured and will be prompted for on a real run")
 	}
+	fmt.Println("Dry run: no mirrors or projects were created")
+}
 
+// mirrorURL returns the URL, with credentials, used for a mirror of
+// projectPath: the push URL on the destination, or for pull mirrors the
+// source repository the target pulls from
+func (mc *MirrorCommand) mirrorURL(config *utils.Config, projectPath string) (string, error) {
+	baseURL := config.DestinationBaseURL
+	if mc.direction == mirrorDirectionPull {
+		baseURL = config.SourceBaseURL
+	}
+	return authenticatedRepoURL(baseURL, config.AuthUser, config.AuthPassword, projectPath)
+}
+
+// authenticatedRepoURL returns the clone URL of projectPath on the instance at
+// baseURL with the given credentials, percent-encoded so that characters such
+// as @, / or # in a password keep the URL intact
+func authenticatedRepoURL(baseURL, user, password, projectPath string) (string, error) {
+	repoURL, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %s: %v", baseURL, err)
+	}
+	repoURL.User = url.UserPassword(user, password)
+	return repoURL.JoinPath(projectPath + ".git").String(), nil
+}
+
+// sourceProjectPath returns the path_with_namespace of a source project
+func (mc *MirrorCommand) sourceProjectPath(config *utils.Config, sourceID string) (string, error) {
 	var project struct {
 		PathWithNamespace string `json:"path_with_namespace"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return fmt.Errorf("failed to decode project details: %v", err)
+	sourceURL := fmt.Sprintf("%s/api/v4/projects/%s", config.SourceBaseURL, sourceID)
+	if err := getGitLabJSON(sourceURL, config.SourceAccessToken, &project); err != nil {
+		return "", fmt.Errorf("failed to get project details: %v", err)
+	}
+	return project.PathWithNamespace, nil
+}
+
+// saveMirrorCredentials stores the mirror credentials in the config file.
+// The file is read again and only auth_user and auth_password are changed, so
+// its instances and settings are kept rather than replaced by the resolved
+// configuration of this run.
+func saveMirrorCredentials(username, password string) error {
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return err
+		}
+	}
+	config, err := utils.ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	config.AuthUser = username
+	config.AuthPassword = password
+	return writeConfigToFile(config, path)
+}
+
+func (mc *MirrorCommand) mirrorProject(config *utils.Config, sourceID, targetID string) error {
+	// Get source project details
+	sourcePath, err := mc.sourceProjectPath(config, sourceID)
+	if err != nil {
+		return err
 	}
 
 	// Check if credentials are set, if not prompt for them
@@ -103,47 +309,99 @@ func (mc *MirrorCommand) mirrorProject(config *utils.Config, sourceID, targetID
 		fmt.Scan(&password)
 		config.AuthUser = username
 		config.AuthPassword = password
-		// Save updated config
-		if err := writeConfigToFile(config, configPath); err != nil {
+		if err := saveMirrorCredentials(username, password); err != nil {
 			return fmt.Errorf("failed to save config: %v", err)
 		}
 	}
 
-	// Create mirror using the correct repository URL
-	targetURL := fmt.Sprintf("%s/api/v4/projects/%s/remote_mirrors", config.DestinationBaseURL, targetID)
-	payload := MirrorPayload{
-		Enabled: true,
-		URL:     strings.Replace(config.DestinationBaseURL, "https://", fmt.Sprintf("https://%s:%s@", config.AuthUser, config.AuthPassword), 1) + fmt.Sprintf("/%s.git", project.PathWithNamespace),
+	if mc.direction == mirrorDirectionPull {
+		return mc.pullMirrorProject(config, sourceID, targetID, sourcePath)
 	}
 
-	jsonData, err := json.Marshal(payload)
+	// Create mirror using the correct repository URL
+	mirrorURL, err := mc.mirrorURL(config, sourcePath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %v", err)
+		return err
 	}
+	targetURL := fmt.Sprintf("%s/api/v4/projects/%s/remote_mirrors", config.DestinationBaseURL, targetID)
 
-	req, err = http.NewRequest("POST", targetURL, strings.NewReader(string(jsonData)))
+	// GitLab masks the credentials of existing mirrors, so they are matched by
+	// the rest of the URL. A re-run skips or re-enables them instead of adding
+	// a duplicate.
+	existing, err := newGitLabClient("", config.DestinationAccessToken).GetPaginated(targetURL)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return fmt.Errorf("failed to list the mirrors of project %s: %v", targetID, err)
+	}
+	for _, mirror := range existing {
+		existingURL, _ := mirror["url"].(string)
+		if withoutUserInfo(existingURL) != withoutUserInfo(mirrorURL) {
+			continue
+		}
+		if enabled, _ := mirror["enabled"].(bool); enabled {
+			fmt.Printf("Skipped mirror for project %s to %s, it already exists\n", sourceID, targetID)
+			return nil
+		}
+		mirrorID := fmt.Sprintf("%.0f", mirror["id"])
+		if err := makeGitLabAPIRequest("PUT", fmt.Sprintf("%s/%s", targetURL, mirrorID), config.DestinationAccessToken, `{"enabled":true}`); err != nil {
+			return fmt.Errorf("failed to enable mirror %s: %v", mirrorID, err)
+		}
+		fmt.Printf("Updated mirror for project %s to %s, it existed but was disabled\n", sourceID, targetID)
+		return nil
 	}
 
-	req.Header.Set("PRIVATE-TOKEN", config.DestinationAccessToken)
-	req.Header.Set("Content-Type", "application/json")
+	payload := MirrorPayload{
+		Enabled: true,
+		URL:     mirrorURL,
+	}
+	if err := newGitLabClient("", config.DestinationAccessToken).Post(targetURL, payload, nil); err != nil {
+		return fmt.Errorf("failed to create mirror: %w", err)
+	}
+
+	fmt.Printf("Successfully created mirror for project %s to %s\n", sourceID, targetID)
+	return nil
+}
 
-	resp, err = client.Do(req)
+// withoutUserInfo returns rawURL without its credentials
+func withoutUserInfo(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
+		return rawURL
 	}
-	defer resp.Body.Close()
+	parsed.User = nil
+	return parsed.String()
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create mirror, status: %d", resp.StatusCode)
+// pullMirrorProject makes the target project pull mirror the source project
+// at sourcePath
+func (mc *MirrorCommand) pullMirrorProject(config *utils.Config, sourceID, targetID, sourcePath string) error {
+	importURL, err := mc.mirrorURL(config, sourcePath)
+	if err != nil {
+		return err
+	}
+	payload := PullMirrorPayload{
+		ImportURL: importURL,
+		Mirror:    true,
+	}
+	targetURL := fmt.Sprintf("%s/api/v4/projects/%s", config.DestinationBaseURL, targetID)
+	if err := newGitLabClient("", config.DestinationAccessToken).Put(targetURL, payload, nil); err != nil {
+		return fmt.Errorf("failed to configure pull mirror: %w", err)
 	}
 
-	fmt.Printf("Successfully created mirror for project %s to %s\n", sourceID, targetID)
+	fmt.Printf("Successfully configured project %s to pull mirror project %s\n", targetID, sourceID)
 	return nil
 }
 
 func (mc *MirrorCommand) mirrorGroup(config *utils.Config, sourceGroupID, targetGroupID string) error {
+	var sourceGroup, targetGroup namespaceInfo
+	groupURL := fmt.Sprintf("%s/api/v4/groups/%s", config.SourceBaseURL, url.PathEscape(sourceGroupID))
+	if err := getGitLabJSON(groupURL, config.SourceAccessToken, &sourceGroup); err != nil {
+		return fmt.Errorf("failed to fetch source group: %v", err)
+	}
+	groupURL = fmt.Sprintf("%s/api/v4/groups/%s", config.DestinationBaseURL, url.PathEscape(targetGroupID))
+	if err := getGitLabJSON(groupURL, config.DestinationAccessToken, &targetGroup); err != nil {
+		return fmt.Errorf("failed to fetch target group: %v", err)
+	}
+
 	// Fetch all projects from source group
 	sourceProjects, err := mc.fetchGroupProjects(config, sourceGroupID, true)
 	if err != nil {
@@ -156,55 +414,101 @@ func (mc *MirrorCommand) mirrorGroup(config *utils.Config, sourceGroupID, target
 		return fmt.Errorf("failed to fetch target projects: %v", err)
 	}
 
-	// Create a map of target project paths to IDs for quick lookup
-	targetProjectMap := make(map[string]string)
+	// Index both sides by match key; a key shared by several projects can't be
+	// mirrored safely, since the pairing would be a guess
+	targetProjectMap := make(map[string][]string)
 	for _, project := range targetProjects {
-		if namespace, ok := project["namespace"].(map[string]interface{}); ok {
-			if name, ok := project["name"].(string); ok {
-				path := fmt.Sprintf("%s/%s", namespace["name"].(string), name)
-				if id, ok := project["id"].(float64); ok {
-					targetProjectMap[path] = fmt.Sprintf("%.0f", id)
-				}
-			}
+		key, ok := mc.projectMatchKey(project, targetGroup.FullPath)
+		if !ok {
+			continue
+		}
+		if id, ok := project["id"].(float64); ok {
+			targetProjectMap[key] = append(targetProjectMap[key], fmt.Sprintf("%.0f", id))
+		}
+	}
+	sourceKeyCount := make(map[string]int)
+	for _, project := range sourceProjects {
+		if key, ok := mc.projectMatchKey(project, sourceGroup.FullPath); ok {
+			sourceKeyCount[key]++
 		}
 	}
 
 	// Process each source project
+	plan := &mirrorPlan{}
 	for _, sourceProject := range sourceProjects {
-		namespace, ok := sourceProject["namespace"].(map[string]interface{})
+		sourcePath, _ := sourceProject["path_with_namespace"].(string)
+		key, ok := mc.projectMatchKey(sourceProject, sourceGroup.FullPath)
 		if !ok {
-			fmt.Printf("Warning: Could not get namespace for source project\n")
+			log.Printf("Warning: Could not get the %s of source project %s", mc.matchBy, sourcePath)
 			continue
 		}
 
-		name, ok := sourceProject["name"].(string)
-		if !ok {
-			fmt.Printf("Warning: Could not get name for source project\n")
+		// Find corresponding target project
+		targetIDs := targetProjectMap[key]
+		if len(targetIDs) > 1 || sourceKeyCount[key] > 1 {
+			log.Printf("Warning: %s matches %d source and %d target projects by %s, skipping it (use --match-by %s)",
+				key, sourceKeyCount[key], len(targetIDs), mc.matchBy, matchByPath)
+			if mc.dryRun {
+				plan.addUnmatched(sourcePath + " (ambiguous match)")
+			}
 			continue
 		}
-
-		sourcePath := fmt.Sprintf("%s/%s", namespace["name"].(string), name)
-
-		// Find corresponding target project
-		targetID, exists := targetProjectMap[sourcePath]
-		if !exists {
-			fmt.Printf("Warning: Target project %s not found\n", sourcePath)
+		if len(targetIDs) == 0 {
+			if mc.dryRun {
+				plan.addUnmatched(sourcePath)
+				continue
+			}
+			log.Printf("Warning: Target project %s not found", key)
 			continue
 		}
+		targetID := targetIDs[0]
 
 		// Create mirror
+		if mc.dryRun {
+			mirrorURL, err := mc.mirrorURL(config, sourcePath)
+			if err != nil {
+				return err
+			}
+			plan.addMirror(sourcePath, fmt.Sprintf("%s (ID: %s)", key, targetID), mirrorURL, false)
+			continue
+		}
 		err := mc.mirrorProject(config, fmt.Sprintf("%.0f", sourceProject["id"].(float64)), targetID)
 		if err != nil {
-			fmt.Printf("Error mirroring project %s: %v\n", sourcePath, err)
+			log.Printf("Error mirroring project %s: %v", sourcePath, err)
 			continue
 		}
 	}
 
+	if mc.dryRun {
+		plan.print(config, mc.direction)
+	}
 	return nil
 }
 
+// projectMatchKey returns the key a group project is paired on: its path
+// relative to groupPath, or "namespace name/project name" with --match-by name
+func (mc *MirrorCommand) projectMatchKey(project map[string]interface{}, groupPath string) (string, bool) {
+	if mc.matchBy == matchByName {
+		namespace, ok := project["namespace"].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		namespaceName, _ := namespace["name"].(string)
+		name, ok := project["name"].(string)
+		if !ok {
+			return "", false
+		}
+		return namespaceName + "/" + name, true
+	}
+
+	path, ok := project["path_with_namespace"].(string)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(path, groupPath+"/"), true
+}
+
 func (mc *MirrorCommand) fetchGroupProjects(config *utils.Config, groupID string, isSource bool) ([]map[string]interface{}, error) {
-	var allProjects []map[string]interface{}
 	baseURL := config.DestinationBaseURL
 	accessToken := config.DestinationAccessToken
 	if isSource {
@@ -212,38 +516,180 @@ func (mc *MirrorCommand) fetchGroupProjects(config *utils.Config, groupID string
 		accessToken = config.SourceAccessToken
 	}
 
-	page := 1
-	for {
-		url := fmt.Sprintf("%s/api/v4/groups/%s/projects?per_page=100&page=%d&include_subgroups=true", baseURL, groupID, page)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("error creating request: %v", err)
+	url := fmt.Sprintf("%s/api/v4/groups/%s/projects?include_subgroups=true", baseURL, groupID)
+	projects, err := paginateCached(newGitLabClient("", accessToken), url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching projects: %v", err)
+	}
+
+	return projects, nil
+}
+
+// mirrorGroupToNamespace mirrors every project of a source group to the project
+// at the same relative path under destNamespace, optionally creating it first
+func (mc *MirrorCommand) mirrorGroupToNamespace(config *utils.Config, sourceGroupID, destNamespace string) error {
+	var sourceGroup namespaceInfo
+	groupURL := fmt.Sprintf("%s/api/v4/groups/%s", config.SourceBaseURL, url.PathEscape(sourceGroupID))
+	if err := getGitLabJSON(groupURL, config.SourceAccessToken, &sourceGroup); err != nil {
+		return fmt.Errorf("failed to fetch source group: %v", err)
+	}
+
+	targetNamespace, err := mc.lookupNamespace(config, destNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to fetch destination namespace %s: %v", destNamespace, err)
+	}
+
+	sourceProjects, err := mc.fetchGroupProjects(config, sourceGroupID, true)
+	if err != nil {
+		return fmt.Errorf("failed to fetch source projects: %v", err)
+	}
+
+	targetProjects, err := mc.fetchGroupProjects(config, strconv.FormatInt(targetNamespace.ID, 10), false)
+	if err != nil {
+		return fmt.Errorf("failed to fetch target projects: %v", err)
+	}
+
+	targetProjectMap := make(map[string]string)
+	for _, project := range targetProjects {
+		if path, ok := project["path_with_namespace"].(string); ok {
+			if id, ok := project["id"].(float64); ok {
+				targetProjectMap[path] = fmt.Sprintf("%.0f", id)
+			}
 		}
+	}
 
-		req.Header.Set("PRIVATE-TOKEN", accessToken)
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching projects: %v", err)
+	plan := &mirrorPlan{}
+	for _, sourceProject := range sourceProjects {
+		sourcePath, ok := sourceProject["path_with_namespace"].(string)
+		if !ok {
+			log.Printf("Warning: Could not get path for source project")
+			continue
+		}
+		sourceID := fmt.Sprintf("%.0f", sourceProject["id"].(float64))
+
+		relativePath := strings.TrimPrefix(sourcePath, sourceGroup.FullPath+"/")
+		targetPath := targetNamespace.FullPath + "/" + relativePath
+
+		targetID, exists := targetProjectMap[targetPath]
+		if !exists {
+			if !mc.createMissing {
+				if mc.dryRun {
+					plan.addUnmatched(sourcePath)
+					continue
+				}
+				log.Printf("Warning: Target project %s not found", targetPath)
+				continue
+			}
+
+			if mc.dryRun {
+				mirrorURL, err := mc.mirrorURL(config, sourcePath)
+				if err != nil {
+					return err
+				}
+				plan.addMirror(sourcePath, targetPath, mirrorURL, true)
+				continue
+			}
+
+			targetID, err = mc.createProject(config, sourceProject, targetPath)
+			if err != nil {
+				log.Printf("Error creating project %s: %v", targetPath, err)
+				continue
+			}
+			fmt.Printf("Created project %s (ID: %s)\n", targetPath, targetID)
+		}
+
+		if mc.dryRun {
+			mirrorURL, err := mc.mirrorURL(config, sourcePath)
+			if err != nil {
+				return err
+			}
+			plan.addMirror(sourcePath, targetPath, mirrorURL, false)
+			continue
+		}
+
+		if err := mc.mirrorProject(config, sourceID, targetID); err != nil {
+			log.Printf("Error mirroring project %s: %v", sourcePath, err)
 		}
-		defer resp.Body.Close()
+	}
+
+	if mc.dryRun {
+		plan.print(config, mc.direction)
+	}
+	return nil
+}
+
+// lookupNamespace resolves a destination namespace by ID or full path
+func (mc *MirrorCommand) lookupNamespace(config *utils.Config, namespace string) (*namespaceInfo, error) {
+	var info namespaceInfo
+	namespaceURL := fmt.Sprintf("%s/api/v4/namespaces/%s", config.DestinationBaseURL, url.PathEscape(namespace))
+	if err := getGitLabJSON(namespaceURL, config.DestinationAccessToken, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// createProject creates an empty destination project at targetPath, named
+// after the source project. The parent namespace must already exist.
+func (mc *MirrorCommand) createProject(config *utils.Config, sourceProject map[string]interface{}, targetPath string) (string, error) {
+	parentPath := targetPath[:strings.LastIndex(targetPath, "/")]
+	parent, err := mc.lookupNamespace(config, parentPath)
+	if err != nil {
+		return "", fmt.Errorf("destination namespace %s not found: %v", parentPath, err)
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("error fetching projects: %s", resp.Status)
+	// The source visibility is copied unless --target-visibility is set; when
+	// the destination rejects it, the next more restrictive level is tried
+	requested := createVisibility(sourceProject["visibility"])
+	visibility := requested
+	for {
+		payload := map[string]interface{}{
+			"name":         sourceProject["name"],
+			"path":         targetPath[strings.LastIndex(targetPath, "/")+1:],
+			"namespace_id": parent.ID,
+		}
+		if visibility != "" {
+			payload["visibility"] = visibility
 		}
 
-		var projects []map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&projects); err != nil {
-			return nil, fmt.Errorf("error parsing projects: %v", err)
+		status, body, err := mc.postProject(config, payload)
+		if err != nil {
+			return "", err
 		}
 
-		if len(projects) == 0 {
-			break
+		if status == http.StatusBadRequest && targetVisibility == "" && visibility != "" && strings.Contains(string(body), "visibility") {
+			if stricter := stricterVisibility(visibility); stricter != "" {
+				log.Printf("Warning: the destination rejected %s as %s, retrying as %s", targetPath, visibility, stricter)
+				visibility = stricter
+				continue
+			}
+		}
+		if status != http.StatusCreated {
+			return "", fmt.Errorf("failed to create project, status: %d: %s", status, body)
 		}
 
-		allProjects = append(allProjects, projects...)
-		page++
+		var created struct {
+			ID         int64  `json:"id"`
+			Visibility string `json:"visibility"`
+		}
+		if err := json.Unmarshal(body, &created); err != nil {
+			return "", fmt.Errorf("failed to decode created project: %v", err)
+		}
+		if created.Visibility != "" && visibility != "" && created.Visibility != visibility {
+			visibility = created.Visibility
+		}
+		if visibility != requested {
+			log.Printf("Warning: visibility of %s was downgraded from %s to %s by the destination", targetPath, requested, visibility)
+		}
+		return strconv.FormatInt(created.ID, 10), nil
 	}
+}
 
-	return allProjects, nil
+// postProject sends a project creation request to the destination and returns
+// the response status and body
+func (mc *MirrorCommand) postProject(config *utils.Config, payload map[string]interface{}) (int, []byte, error) {
+	resp, body, err := newGitLabClient(config.DestinationBaseURL, config.DestinationAccessToken).Do(context.Background(), http.MethodPost, "projects", payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	return resp.StatusCode, body, nil
 }
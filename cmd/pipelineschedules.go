@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// pipelineScheduleFields are the pipeline schedule attributes copied to the destination
+var pipelineScheduleFields = []string{"description", "ref", "cron", "cron_timezone", "active"}
+
+// pipelineScheduleVariableFields are the schedule variable attributes copied to the destination
+var pipelineScheduleVariableFields = []string{"key", "value", "variable_type"}
+
+// pipelineScheduleColumns are the default table columns for pipeline schedules
+var pipelineScheduleColumns = []string{"id", "description", "ref", "cron", "cron_timezone", "active"}
+
+var pipelineSchedulesDryRun bool
+
+// getPipelineSchedulesCmd lists the pipeline schedules of a project
+var getPipelineSchedulesCmd = &cobra.Command{
+	Use:   "pipeline-schedules",
+	Short: "Retrieve the pipeline schedules of a GitLab project",
+	Long: `Retrieve the pipeline schedules of a project (-p), each with its own
+variables.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if projectID == "" {
+			return fmt.Errorf("--project must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+		if isDestination {
+			baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+		}
+		_, collectionURL := namespacedEndpoint(baseURL, "", projectID, "pipeline_schedules")
+
+		schedules, err := fetchPipelineSchedules(collectionURL, accessToken, outputFormat != outputFormatTable)
+		if err != nil {
+			return notFoundError(err, "project", projectID)
+		}
+
+		if outputFormat == outputFormatTable {
+			return printTable(schedules, pipelineScheduleColumns, "")
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("pipeline-schedules", "", projectID, isDestination, false)
+		}
+
+		if err := saveOutputToFile(schedules, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// migratePipelineSchedulesCmd copies pipeline schedules between projects
+var migratePipelineSchedulesCmd = &cobra.Command{
+	Use:   "pipeline-schedules",
+	Short: "Migrate pipeline schedules between GitLab projects",
+	Long: `Copy the pipeline schedules of a source project (-p) to a destination project
+(-P), keeping their description, ref, cron, timezone and active state, along
+with the variables of each schedule.
+
+A schedule is owned by the user who created it, so the copies are owned by
+the user of the destination access token; the change of owner is logged.
+Schedules whose description and ref already exist on the destination are
+skipped. A schedule whose variables can't all be created is removed again, so
+a re-run creates it in full. Use --dry-run to list what would be created without changing the
+destination.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if projectID == "" || destinationProjectID == "" {
+			return fmt.Errorf("provide a source project (-p) and a destination project (--destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migratePipelineSchedules(config)
+	},
+}
+
+// fetchPipelineSchedules lists the pipeline schedules of a project. The list
+// doesn't include their variables; withVariables fetches every schedule to
+// add them.
+func fetchPipelineSchedules(collectionURL, accessToken string, withVariables bool) ([]map[string]interface{}, error) {
+	schedules, err := newGitLabClient("", accessToken).GetPaginated(collectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pipeline schedules: %w", err)
+	}
+	if !withVariables {
+		return schedules, nil
+	}
+
+	for i, schedule := range schedules {
+		var detailed map[string]interface{}
+		if err := getGitLabJSON(fmt.Sprintf("%s/%s", collectionURL, diffValue(schedule["id"])), accessToken, &detailed); err != nil {
+			return nil, fmt.Errorf("error fetching pipeline schedule %s: %w", diffValue(schedule["id"]), err)
+		}
+		schedules[i] = detailed
+	}
+	return schedules, nil
+}
+
+// pipelineScheduleKey identifies a schedule by its description and ref
+func pipelineScheduleKey(schedule map[string]interface{}) string {
+	return fmt.Sprintf("%s@%s", diffValue(schedule["description"]), diffValue(schedule["ref"]))
+}
+
+// copyFields returns the given fields of item that are set
+func copyFields(item map[string]interface{}, fields []string) map[string]interface{} {
+	payload := make(map[string]interface{})
+	for _, field := range fields {
+		if value, ok := item[field]; ok && value != nil {
+			payload[field] = value
+		}
+	}
+	return payload
+}
+
+// migratePipelineSchedules creates the source schedules, with their variables,
+// that are missing on the destination
+func migratePipelineSchedules(config *utils.Config) error {
+	sourceTarget, sourceURL := namespacedEndpoint(config.SourceBaseURL, "", projectID, "pipeline_schedules")
+	destinationTarget, destinationURL := namespacedEndpoint(config.DestinationBaseURL, "", destinationProjectID, "pipeline_schedules")
+
+	sourceSchedules, err := fetchPipelineSchedules(sourceURL, config.SourceAccessToken, true)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	destinationSchedules, err := fetchPipelineSchedules(destinationURL, config.DestinationAccessToken, false)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]bool, len(destinationSchedules))
+	for _, schedule := range destinationSchedules {
+		existing[pipelineScheduleKey(schedule)] = true
+	}
+
+	var owner struct {
+		Username string `json:"username"`
+	}
+	if err := getGitLabJSON(config.DestinationBaseURL+"/api/v4/user", config.DestinationAccessToken, &owner); err != nil {
+		return fmt.Errorf("failed to look up the user of the destination token: %w", err)
+	}
+
+	prefix := ""
+	if pipelineSchedulesDryRun {
+		prefix = "[dry run] "
+	}
+
+	log.Printf("Migrating %d pipeline schedules from %s to %s", len(sourceSchedules), sourceTarget, destinationTarget)
+	created, skipped, failed := 0, 0, 0
+	for _, schedule := range sourceSchedules {
+		description := diffValue(schedule["description"])
+		if existing[pipelineScheduleKey(schedule)] {
+			fmt.Printf("skipped: pipeline schedule %s (already exists)\n", description)
+			skipped++
+			continue
+		}
+		variables, _ := schedule["variables"].([]interface{})
+
+		if !pipelineSchedulesDryRun {
+			var createdSchedule map[string]interface{}
+			if err := postGitLabJSON(destinationURL, config.DestinationAccessToken, copyFields(schedule, pipelineScheduleFields), &createdSchedule); err != nil {
+				if errors.Is(err, utils.ErrUnauthorized) {
+					return err
+				}
+				fmt.Printf("failed: pipeline schedule %s: %v\n", description, err)
+				failed++
+				continue
+			}
+			existing[pipelineScheduleKey(schedule)] = true
+
+			variablesURL := fmt.Sprintf("%s/%s/variables", destinationURL, diffValue(createdSchedule["id"]))
+			variablesFailed := false
+			for _, entry := range variables {
+				variable, ok := entry.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if err := postGitLabJSON(variablesURL, config.DestinationAccessToken, copyFields(variable, pipelineScheduleVariableFields), nil); err != nil {
+					if errors.Is(err, utils.ErrUnauthorized) {
+						return err
+					}
+					fmt.Printf("failed: variable %s of pipeline schedule %s: %v\n", diffValue(variable["key"]), description, err)
+					variablesFailed = true
+				}
+			}
+			if variablesFailed {
+				// A re-run would skip the schedule as already existing and never
+				// add the missing variables, so it is removed to be created again
+				scheduleURL := fmt.Sprintf("%s/%s", destinationURL, diffValue(createdSchedule["id"]))
+				if err := makeGitLabAPIRequest("DELETE", scheduleURL, config.DestinationAccessToken, ""); err != nil {
+					fmt.Printf("failed: pipeline schedule %s was created without all its variables and could not be removed, delete it before re-running: %v\n", description, err)
+				} else {
+					delete(existing, pipelineScheduleKey(schedule))
+					fmt.Printf("failed: pipeline schedule %s was removed again as not all its variables could be created\n", description)
+				}
+				failed++
+				continue
+			}
+		}
+
+		fmt.Printf("%screated: pipeline schedule %s (%s, %s, %d variables)\n", prefix, description, diffValue(schedule["ref"]), diffValue(schedule["cron"]), len(variables))
+		if sourceOwner, ok := schedule["owner"].(map[string]interface{}); ok && diffValue(sourceOwner["username"]) != owner.Username {
+			log.Printf("Pipeline schedule %s is owned by %s on the source and by %s on the destination", description, diffValue(sourceOwner["username"]), owner.Username)
+		}
+		created++
+	}
+
+	if pipelineSchedulesDryRun {
+		log.Printf("Dry run: %d pipeline schedules would be created, %d skipped (already exist)", created, skipped)
+		return nil
+	}
+	log.Printf("Created %d pipeline schedules, skipped %d (already exist), %d failed", created, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d pipeline schedules could not be migrated", failed)
+	}
+	return nil
+}
+
+func init() {
+	getPipelineSchedulesCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to list the pipeline schedules of")
+	getCmd.AddCommand(getPipelineSchedulesCmd)
+
+	migratePipelineSchedulesCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migratePipelineSchedulesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migratePipelineSchedulesCmd.Flags().BoolVar(&pipelineSchedulesDryRun, "dry-run", false, "List the pipeline schedules that would be created without changing the destination")
+	migrateCmd.AddCommand(migratePipelineSchedulesCmd)
+}
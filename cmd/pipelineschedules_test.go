@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMigratePipelineSchedulesWithVariables(t *testing.T) {
+	tests := []struct {
+		name        string
+		failingKey  string
+		wantErr     bool
+		wantRemoved bool
+	}{
+		{"variables created", "", false, false},
+		{"variable rejected", "B", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var writes []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.Method != http.MethodGet {
+					mu.Lock()
+					writes = append(writes, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(body)))
+					mu.Unlock()
+				}
+				switch {
+				case r.URL.Path == "/api/v4/projects/1/pipeline_schedules":
+					fmt.Fprint(w, `[{"id":5,"description":"nightly","ref":"main"}]`)
+				case r.URL.Path == "/api/v4/projects/1/pipeline_schedules/5":
+					fmt.Fprint(w, `{"id":5,"description":"nightly","ref":"main","cron":"0 1 * * *","cron_timezone":"UTC","active":true,
+						"owner":{"username":"alice"},
+						"variables":[{"key":"A","value":"1","variable_type":"env_var"},{"key":"B","value":"2","variable_type":"file"}]}`)
+				case r.URL.Path == "/api/v4/projects/2/pipeline_schedules" && r.Method == http.MethodGet:
+					fmt.Fprint(w, `[]`)
+				case r.URL.Path == "/api/v4/projects/2/pipeline_schedules":
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprint(w, `{"id":50}`)
+				case r.URL.Path == "/api/v4/projects/2/pipeline_schedules/50/variables":
+					if tt.failingKey != "" && strings.Contains(string(body), `"key":"`+tt.failingKey+`"`) {
+						w.WriteHeader(http.StatusBadRequest)
+						fmt.Fprint(w, `{"message":"invalid"}`)
+						return
+					}
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprint(w, `{}`)
+				case r.URL.Path == "/api/v4/projects/2/pipeline_schedules/50":
+					w.WriteHeader(http.StatusNoContent)
+				case r.URL.Path == "/api/v4/user":
+					fmt.Fprint(w, `{"username":"migration-bot"}`)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "migrate", "pipeline-schedules", "-p", "1", "-P", "2")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrate pipeline-schedules error = %v, want an error: %v", err, tt.wantErr)
+			}
+
+			want := []string{
+				`POST /api/v4/projects/2/pipeline_schedules {"active":true,"cron":"0 1 * * *","cron_timezone":"UTC","description":"nightly","ref":"main"}`,
+				`POST /api/v4/projects/2/pipeline_schedules/50/variables {"key":"A","value":"1","variable_type":"env_var"}`,
+				`POST /api/v4/projects/2/pipeline_schedules/50/variables {"key":"B","value":"2","variable_type":"file"}`,
+			}
+			if tt.wantRemoved {
+				want = append(want, "DELETE /api/v4/projects/2/pipeline_schedules/50")
+			}
+			if strings.Join(writes, "\n") != strings.Join(want, "\n") {
+				t.Errorf("writes:\n%s\nwant:\n%s", strings.Join(writes, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
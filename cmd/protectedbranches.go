@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// accessLevelNoOne is the access level GitLab uses for "No one"
+const accessLevelNoOne = 0
+
+// protectedBranchColumns are the default table columns for protected branches
+var protectedBranchColumns = []string{"id", "name", "push_access_level", "merge_access_level", "allow_force_push"}
+
+var protectedBranchesDryRun bool
+
+// protectedBranchRule is the role-based part of a protected branch, as sent
+// when protecting a branch
+type protectedBranchRule struct {
+	Name                 string `json:"name"`
+	PushAccessLevel      int    `json:"push_access_level"`
+	MergeAccessLevel     int    `json:"merge_access_level"`
+	UnprotectAccessLevel int    `json:"unprotect_access_level,omitempty"`
+	AllowForcePush       bool   `json:"allow_force_push"`
+}
+
+// getProtectedBranchesCmd lists the protected branches of a project
+var getProtectedBranchesCmd = &cobra.Command{
+	Use:   "protected-branches",
+	Short: "Retrieve the protected branches of a GitLab project",
+	Long:  `Retrieve the protected branches of a project (-p) with their access levels.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if projectID == "" {
+			return fmt.Errorf("--project must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+		if isDestination {
+			baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+		}
+		_, collectionURL := namespacedEndpoint(baseURL, "", projectID, "protected_branches")
+
+		branches, err := fetchProtectedBranches(collectionURL, accessToken)
+		if err != nil {
+			return notFoundError(err, "project", projectID)
+		}
+
+		if outputFormat == outputFormatTable {
+			// The table shows the lowest role of each access level list
+			for _, branch := range branches {
+				rule, _ := protectedBranchRuleOf(branch)
+				branch["push_access_level"] = rule.PushAccessLevel
+				branch["merge_access_level"] = rule.MergeAccessLevel
+			}
+			return printTable(branches, protectedBranchColumns, "")
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("protected-branches", "", projectID, isDestination, false)
+		}
+
+		if err := saveOutputToFile(branches, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// migrateProtectedBranchesCmd copies protected branch rules between projects
+var migrateProtectedBranchesCmd = &cobra.Command{
+	Use:   "protected-branches",
+	Short: "Migrate protected branches between GitLab projects",
+	Long: `Protect the branches of a destination project (-P) the way they are protected
+on the source project (-p): the roles allowed to push, merge and unprotect,
+and whether force pushes are allowed. Name patterns such as release/* are
+copied as they are.
+
+Access granted to specific users, groups or deploy keys is not copied, since
+their IDs differ between instances; a warning lists the branches that had any.
+
+A branch that is already protected on the destination with other settings is
+unprotected and protected again with the source settings. Branches whose
+settings already match are skipped. Use --dry-run to list the changes without
+making them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if projectID == "" || destinationProjectID == "" {
+			return fmt.Errorf("provide a source project (-p) and a destination project (--destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migrateProtectedBranches(config)
+	},
+}
+
+// fetchProtectedBranches lists the protected branches of a project
+func fetchProtectedBranches(collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	branches, err := newGitLabClient("", accessToken).GetPaginated(collectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching protected branches: %w", err)
+	}
+	return branches, nil
+}
+
+// protectedBranchRuleOf maps a protected branch as returned by the API to the
+// rule that protects it. Each of its access level lists is reduced to the
+// lowest role allowed, or "No one" when only users, groups or deploy keys
+// are. individual is true when any of them grant access to those.
+func protectedBranchRuleOf(branch map[string]interface{}) (protectedBranchRule, bool) {
+	name, _ := branch["name"].(string)
+	forcePush, _ := branch["allow_force_push"].(bool)
+	rule := protectedBranchRule{Name: name, AllowForcePush: forcePush}
+
+	individual := false
+	roleLevel := func(field string) int {
+		levels, _ := branch[field].([]interface{})
+		lowest := -1
+		for _, entry := range levels {
+			level, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if level["user_id"] != nil || level["group_id"] != nil || level["deploy_key_id"] != nil {
+				individual = true
+				continue
+			}
+			value, ok := level["access_level"].(float64)
+			if ok && (lowest < 0 || int(value) < lowest) {
+				lowest = int(value)
+			}
+		}
+		if lowest < 0 {
+			return accessLevelNoOne
+		}
+		return lowest
+	}
+	rule.PushAccessLevel = roleLevel("push_access_levels")
+	rule.MergeAccessLevel = roleLevel("merge_access_levels")
+	if _, ok := branch["unprotect_access_levels"]; ok {
+		rule.UnprotectAccessLevel = roleLevel("unprotect_access_levels")
+	}
+	return rule, individual
+}
+
+// migrateProtectedBranches protects the source's protected branches on the
+// destination, re-protecting those whose destination settings differ
+func migrateProtectedBranches(config *utils.Config) error {
+	sourceTarget, sourceURL := namespacedEndpoint(config.SourceBaseURL, "", projectID, "protected_branches")
+	destinationTarget, destinationURL := namespacedEndpoint(config.DestinationBaseURL, "", destinationProjectID, "protected_branches")
+
+	sourceBranches, err := fetchProtectedBranches(sourceURL, config.SourceAccessToken)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	destinationBranches, err := fetchProtectedBranches(destinationURL, config.DestinationAccessToken)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]protectedBranchRule, len(destinationBranches))
+	for _, branch := range destinationBranches {
+		rule, _ := protectedBranchRuleOf(branch)
+		existing[rule.Name] = rule
+	}
+
+	prefix := ""
+	if protectedBranchesDryRun {
+		prefix = "[dry run] "
+	}
+
+	log.Printf("Migrating %d protected branches from %s to %s", len(sourceBranches), sourceTarget, destinationTarget)
+	var individual []string
+	created, updated, skipped, failed := 0, 0, 0, 0
+	for _, branch := range sourceBranches {
+		rule, hasIndividual := protectedBranchRuleOf(branch)
+		if hasIndividual {
+			individual = append(individual, rule.Name)
+		}
+
+		current, protected := existing[rule.Name]
+		if protected && current.PushAccessLevel == rule.PushAccessLevel && current.MergeAccessLevel == rule.MergeAccessLevel &&
+			current.AllowForcePush == rule.AllowForcePush && (rule.UnprotectAccessLevel == 0 || current.UnprotectAccessLevel == rule.UnprotectAccessLevel) {
+			fmt.Printf("skipped: protected branch %s (already protected the same way)\n", rule.Name)
+			skipped++
+			continue
+		}
+
+		if !protectedBranchesDryRun {
+			if protected {
+				branchURL := fmt.Sprintf("%s/%s", destinationURL, url.PathEscape(rule.Name))
+				if err := makeGitLabAPIRequest("DELETE", branchURL, config.DestinationAccessToken, ""); err != nil {
+					if errors.Is(err, utils.ErrUnauthorized) {
+						return err
+					}
+					fmt.Printf("failed: unprotecting branch %s: %v\n", rule.Name, err)
+					failed++
+					continue
+				}
+			}
+			if err := postGitLabJSON(destinationURL, config.DestinationAccessToken, rule, nil); err != nil {
+				if errors.Is(err, utils.ErrUnauthorized) {
+					return err
+				}
+				// A re-protect that fails leaves the branch unprotected, say so plainly
+				if protected {
+					fmt.Printf("failed: protected branch %s was unprotected but could not be protected again: %v\n", rule.Name, err)
+				} else {
+					fmt.Printf("failed: protected branch %s: %v\n", rule.Name, err)
+				}
+				failed++
+				continue
+			}
+		}
+
+		if protected {
+			fmt.Printf("%supdated: protected branch %s (push %d, merge %d, force push %t)\n", prefix, rule.Name, rule.PushAccessLevel, rule.MergeAccessLevel, rule.AllowForcePush)
+			updated++
+		} else {
+			fmt.Printf("%screated: protected branch %s (push %d, merge %d, force push %t)\n", prefix, rule.Name, rule.PushAccessLevel, rule.MergeAccessLevel, rule.AllowForcePush)
+			created++
+		}
+	}
+
+	if len(individual) > 0 {
+		log.Printf("Warning: access granted to specific users, groups or deploy keys was not copied for: %v", individual)
+	}
+	if protectedBranchesDryRun {
+		log.Printf("Dry run: %d branches would be protected and %d re-protected, %d skipped (already match)", created, updated, skipped)
+		return nil
+	}
+	log.Printf("Protected %d branches, re-protected %d, skipped %d (already match), %d failed", created, updated, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d protected branches could not be migrated", failed)
+	}
+	return nil
+}
+
+func init() {
+	getProtectedBranchesCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to list the protected branches of")
+	getCmd.AddCommand(getProtectedBranchesCmd)
+
+	migrateProtectedBranchesCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateProtectedBranchesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migrateProtectedBranchesCmd.Flags().BoolVar(&protectedBranchesDryRun, "dry-run", false, "List the branches that would be protected without changing the destination")
+	migrateCmd.AddCommand(migrateProtectedBranchesCmd)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+func TestProtectedBranchRuleOf(t *testing.T) {
+	var branch map[string]interface{}
+	err := json.Unmarshal([]byte(`{
+		"name": "main",
+		"allow_force_push": true,
+		"push_access_levels": [
+			{"access_level": 40, "access_level_description": "Maintainers"},
+			{"access_level": 30, "access_level_description": "Developers + Maintainers"}
+		],
+		"merge_access_levels": [
+			{"access_level": 40, "user_id": 7, "access_level_description": "Jane"},
+			{"access_level": 40, "group_id": 9, "access_level_description": "Release team"}
+		],
+		"unprotect_access_levels": [
+			{"access_level": 60, "access_level_description": "Admins"}
+		]
+	}`), &branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule, individual := protectedBranchRuleOf(branch)
+	want := protectedBranchRule{
+		Name:                 "main",
+		PushAccessLevel:      30,
+		MergeAccessLevel:     accessLevelNoOne,
+		UnprotectAccessLevel: 60,
+		AllowForcePush:       true,
+	}
+	if rule != want {
+		t.Errorf("rule = %+v, want %+v", rule, want)
+	}
+	if !individual {
+		t.Error("individual = false, want true for the user and group merge access")
+	}
+
+	// Older GitLab versions don't return unprotect_access_levels, which must
+	// leave the unprotect level unset rather than "No one"
+	rule, individual = protectedBranchRuleOf(map[string]interface{}{
+		"name":                "develop",
+		"push_access_levels":  []interface{}{map[string]interface{}{"access_level": float64(40)}},
+		"merge_access_levels": []interface{}{map[string]interface{}{"access_level": float64(30)}},
+	})
+	want = protectedBranchRule{Name: "develop", PushAccessLevel: 40, MergeAccessLevel: 30}
+	if rule != want || individual {
+		t.Errorf("rule = %+v, %v, want %+v, false", rule, individual, want)
+	}
+}
+
+// protectedBranchJSON renders a role-only protected branch as the API lists it
+func protectedBranchJSON(name string, push, merge int) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                name,
+		"allow_force_push":    false,
+		"push_access_levels":  []interface{}{map[string]interface{}{"access_level": push}},
+		"merge_access_levels": []interface{}{map[string]interface{}{"access_level": merge}},
+	}
+}
+
+func TestMigrateProtectedBranchesReprotects(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var posted []protectedBranchRule
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+
+		var branches []map[string]interface{}
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v4/projects/1/protected_branches":
+			branches = []map[string]interface{}{
+				protectedBranchJSON("main", 40, 40),
+				protectedBranchJSON("release/1.0", 40, 30),
+				protectedBranchJSON("develop", 30, 30),
+			}
+		case "GET /api/v4/projects/2/protected_branches":
+			branches = []map[string]interface{}{
+				protectedBranchJSON("main", 40, 40),
+				protectedBranchJSON("release/1.0", 30, 30),
+			}
+		case "DELETE /api/v4/projects/2/protected_branches/release%2F1.0":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case "POST /api/v4/projects/2/protected_branches":
+			body, _ := io.ReadAll(r.Body)
+			var rule protectedBranchRule
+			if err := json.Unmarshal(body, &rule); err != nil {
+				t.Errorf("invalid protect payload %s: %v", body, err)
+			}
+			posted = append(posted, rule)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+			return
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(branches)
+	}))
+	t.Cleanup(server.Close)
+
+	projectID, destinationProjectID = "1", "2"
+	t.Cleanup(func() { projectID, destinationProjectID = "", "" })
+	config := &utils.Config{
+		SourceBaseURL:          server.URL,
+		SourceAccessToken:      "srctoken",
+		DestinationBaseURL:     server.URL,
+		DestinationAccessToken: "dsttoken",
+	}
+
+	if err := migrateProtectedBranches(config); err != nil {
+		t.Fatalf("migrateProtectedBranches: %v", err)
+	}
+
+	wantRequests := []string{
+		"GET /api/v4/projects/1/protected_branches",
+		"GET /api/v4/projects/2/protected_branches",
+		"DELETE /api/v4/projects/2/protected_branches/release%2F1.0",
+		"POST /api/v4/projects/2/protected_branches",
+		"POST /api/v4/projects/2/protected_branches",
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+	wantPosted := []protectedBranchRule{
+		{Name: "release/1.0", PushAccessLevel: 40, MergeAccessLevel: 30},
+		{Name: "develop", PushAccessLevel: 30, MergeAccessLevel: 30},
+	}
+	if !reflect.DeepEqual(posted, wantPosted) {
+		t.Errorf("protected %+v, want %+v", posted, wantPosted)
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// resolvedProjectIDs caches the project IDs looked up by path during a run,
+// keyed by base URL and path
+var resolvedProjectIDs = struct {
+	sync.Mutex
+	ids map[string]string
+}{ids: make(map[string]string)}
+
+// resolveProjectID returns the numeric ID of ref, which is either a project ID
+// or a full project path such as "group/subgroup/project" on the instance at
+// baseURL. Paths are looked up with GET /projects/:path, which also checks the
+// project exists and is accessible with accessToken.
+func resolveProjectID(baseURL, accessToken, ref string) (string, error) {
+	if _, err := strconv.ParseInt(ref, 10, 64); err == nil || ref == "" {
+		return ref, nil
+	}
+
+	path := strings.Trim(ref, "/")
+	cacheKey := baseURL + " " + path
+	resolvedProjectIDs.Lock()
+	id, ok := resolvedProjectIDs.ids[cacheKey]
+	resolvedProjectIDs.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var project struct {
+		ID int64 `json:"id"`
+	}
+	projectURL := fmt.Sprintf("%s/api/v4/projects/%s", baseURL, url.PathEscape(path))
+	if err := getGitLabJSON(projectURL, accessToken, &project); err != nil {
+		if errors.Is(err, utils.ErrNotFound) {
+			return "", fmt.Errorf("project %s not found on %s, or not accessible with the access token", path, baseURL)
+		}
+		return "", fmt.Errorf("failed to look up project %s: %w", path, err)
+	}
+
+	id = strconv.FormatInt(project.ID, 10)
+	log.Printf("Resolved project %s to ID %s", path, id)
+	resolvedProjectIDs.Lock()
+	resolvedProjectIDs.ids[cacheKey] = id
+	resolvedProjectIDs.Unlock()
+	return id, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 	// "github.com/spf13/cobra/doc"
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
 )
 
 // Version is the current version of gitlab-migrate
@@ -16,8 +17,16 @@ var configPath string
 var isDestination bool
 var groupID string
 var projectID string
+var projectIDList string
+var groupIDList string
 var recursive bool
 var outputFile string
+var quiet bool
+var verbose bool
+var logFormat string
+var dataDir string
+var sourceInstance string
+var destinationInstance string
 
 // rootCmd represents the base command
 // rootCmd represents the base command
@@ -27,6 +36,20 @@ var rootCmd = &cobra.Command{
 	Long: `gitlab-migrate is a command-line tool designed to migrate GitLab projects 
 using the GitLab API and a configuration file written in YAML. It streamlines the 
 process of transferring projects between GitLab instances or groups.`,
+	// Errors are printed once by Execute, through the logger
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyLogSettings(); err != nil {
+			return err
+		}
+		if err := validateHTTPFlags(); err != nil {
+			return err
+		}
+		// The flags are valid, so an error returned from here on is a failed
+		// run rather than a usage mistake
+		cmd.SilenceUsage = true
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("==========================================")
 		fmt.Println("🚀 Welcome to gitlab-migrate! 🚀")
@@ -42,7 +65,8 @@ process of transferring projects between GitLab instances or groups.`,
 		fmt.Println("  3. Sit back and let the magic happen!")
 		fmt.Println()
 		fmt.Println("📂 Default Configuration:")
-		fmt.Println("  - If no '--config' flag is provided, it will look for 'config.yaml' in your home directory.")
+		fmt.Println("  - If no '--config' flag is provided, it will look for '~/.config/gitlab-migrate/config.yaml'")
+		fmt.Println("    (under $XDG_CONFIG_HOME when set), then for 'config.yaml' in your home directory.")
 		fmt.Println()
 		fmt.Println("💡 Need help?")
 		fmt.Println("  - Use '--help' for detailed usage and options.")
@@ -53,18 +77,62 @@ process of transferring projects between GitLab instances or groups.`,
 	},
 }
 
+// applyLogSettings sets the log level from --verbose and --quiet and the log
+// format from --log-format
+func applyLogSettings() error {
+	if err := utils.SetLogFormat(logFormat); err != nil {
+		return err
+	}
+	switch {
+	case verbose && quiet:
+		return fmt.Errorf("--verbose and --quiet can't be used together")
+	case verbose:
+		utils.SetLogLevel(utils.LevelDebug)
+	case quiet:
+		utils.SetLogLevel(utils.LevelWarn)
+	default:
+		utils.SetLogLevel(utils.LevelInfo)
+	}
+	return nil
+}
+
 func Execute() {
+	// Every log line goes to stderr through the level filter and the log format
+	utils.InstallLogger(os.Stderr)
+
 	rootCmd.Version = Version
 	rootCmd.SetVersionTemplate("gitlab-migrate {{.Version}}")
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to the config.yaml file (default: $HOME/config.yaml)")
+
+	if err := rootCmd.Execute(); err != nil {
+		utils.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// init registers the global flags, so the command tree is complete without
+// going through Execute, e.g. in tests
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "Path to the config.yaml file (default: $XDG_CONFIG_HOME/gitlab-migrate/config.yaml or ~/.config/gitlab-migrate/config.yaml, then $HOME/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&sourceInstance, "source-instance", "", "Name of the config instances: entry to use as source, overriding source_instance")
+	rootCmd.PersistentFlags().StringVar(&destinationInstance, "dest-instance", "", "Name of the config instances: entry to use as destination, overriding destination_instance")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", utils.DefaultTimeout, "Maximum time a GitLab API request may take, including reading the response, e.g. 2m for slow instances (0 means no limit)")
+	rootCmd.PersistentFlags().IntVar(&maxRetries, "max-retries", defaultMaxRetries, "Number of times a request that hit a network error, a 5xx or a rate limit is retried before giving up")
+	rootCmd.PersistentFlags().IntVar(&maxIdleConns, "max-idle-conns", utils.DefaultMaxIdleConns, "Maximum number of idle HTTP connections kept for reuse")
+	rootCmd.PersistentFlags().IntVar(&maxConnsPerHost, "max-conns-per-host", 0, "Maximum number of HTTP connections per host (0 means no limit)")
+	rootCmd.PersistentFlags().DurationVar(&dialTimeout, "dial-timeout", 0, "Maximum time to establish a connection, e.g. 10s (0 means only the request timeout applies)")
+	rootCmd.PersistentFlags().DurationVar(&responseHeaderTimeout, "response-header-timeout", 0, "Maximum time to wait for response headers, not counting the body transfer (0 means only the request timeout applies)")
+	rootCmd.PersistentFlags().DurationVar(&retryBaseDelay, "retry-base-delay", retryDelay, "Longest wait before the first retry of a failed request; it doubles per retry and the actual wait is randomized below it")
+	rootCmd.PersistentFlags().DurationVar(&retryMaxDelay, "retry-max-delay", maxRetryDelay, "Cap on the wait between retries of a failed request")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only show warnings and errors, without progress output")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Also show debug output, such as every API request")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", utils.LogFormatText, "Log format: text, or json for one JSON object per line with time, level, message and context fields such as project_id")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", utils.DefaultDataDir, "Directory output files are written to, overriding data_dir in the config")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Refetch project lists on every lookup instead of reusing them within the run")
+	rootCmd.PersistentFlags().BoolVar(&insecureFlag, "insecure", false, "Skip TLS certificate verification, overriding insecure_skip_tls_verify in the config")
+	rootCmd.PersistentFlags().Var(&customHeaders, "header", "Extra \"Name: Value\" header sent with every request, e.g. for an auth gateway (repeatable)")
 	// err := doc.GenMarkdownTree(rootCmd, "./docs")
 	// if err != nil {
 	// 	log.Fatal(err)
 	// }
 	rootCmd.AddCommand(NewMirrorCommand())
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
 }
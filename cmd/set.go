@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -14,7 +19,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var inputFilePath string
+// stdinInput is the --input value that reads the payload from standard input
+const stdinInput = "-"
+
+var inputFilePaths []string
 var isSource bool
 var destinationGroupID string
 var destinationProjectID string
@@ -38,88 +46,323 @@ This command supports:
 - Updating variables in a specific group
 - Recursive updates to all projects within a group
 
-The input file should contain the variables in JSON format.
-Use --source flag for source GitLab instance or --destination for target instance.`,
-	Run: func(cmd *cobra.Command, args []string) {
+The input file should contain the variables in JSON format. --input can be
+repeated or given a glob pattern (e.g. "secrets/*.json") to merge several files.
+Variables with the same key and environment scope are taken from the last file
+listed. All files are read and validated before any variable is applied.
+Use "--input -" to read the JSON from standard input, e.g. when piping from
+another command.
+Use --source flag for source GitLab instance or --destination for target instance.
+
+--format reads other kinds of input instead of JSON:
+- dotenv:    KEY=VALUE lines, e.g. a .env file or the output of
+             "get variables --output-format env-export"
+- gitlab-ci: the top-level variables: block of a .gitlab-ci.yml, with
+             KEY: value entries or the expanded form with value, description
+             and expand (expand: false creates a raw variable). Job-level
+             variables are ignored.
+
+Variables whose key and environment scope already exist on the target are
+handled according to --on-conflict:
+- skip:   leave the existing variable untouched
+- update: overwrite the existing variable
+- fail:   report the variable as failed (default)
+- rename: create it under a new key with --rename-suffix appended
+The same applies when GitLab rejects a new variable because its key and scope
+are already taken, e.g. when the existing variables could not be listed: with
+update the variable is then written via PUT for its environment scope.
+
+--project-ids or --group-ids apply the same input to an explicit list of
+projects or groups, given as "12,34,56" or "@file" with one ID per line.
+
+--destination-project also accepts the full path of the project, e.g.
+group/subgroup/project, which is looked up once per run.
+
+--recursive pairs the projects of the input with those of the destination
+group and its subgroups by their path relative to the group: the group the
+input was exported from is the namespace its projects have in common, so
+old-org/backend/api is set on new-org/backend/api with -G new-org. Projects
+without a match at the same relative path fall back to the same full path,
+then to the same name.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		config, err := loadConfig() // Pass the config file path here
 		if err != nil {
-			fmt.Println("Error:", err)
-			return
+			return err
+		}
+
+		if len(inputFilePaths) == 0 {
+			return fmt.Errorf("input file path is required")
+		}
+
+		inputFiles, err := resolveInputFiles(inputFilePaths)
+		if err != nil {
+			return err
+		}
+
+		if err := validateConflictStrategy(onConflict); err != nil {
+			return err
+		}
+
+		if err := validateUnmaskableStrategy(unmaskable); err != nil {
+			return err
 		}
 
-		if inputFilePath == "" {
-			fmt.Println("Error: Input file path is required.")
-			return
+		if err := setupKeyFilter(); err != nil {
+			return err
 		}
 
-		if (destinationProjectID != "" && destinationGroupID != "") || (destinationProjectID == "" && destinationGroupID == "") {
-			fmt.Println("Error: Either --destination-project or --destination-group must be provided.")
-			return
+		if err := validateInputFormat(inputFormat); err != nil {
+			return err
+		}
+		if recursive && inputFormat != inputFormatJSON {
+			return fmt.Errorf("--recursive reads the JSON written by \"get variables -r\", --format %s is not supported with it", inputFormat)
+		}
+
+		projectIDs, groupIDs, err := parseIDListFlags()
+		if err != nil {
+			return err
+		}
+		if len(projectIDs) > 0 || len(groupIDs) > 0 {
+			if destinationProjectID != "" || destinationGroupID != "" {
+				return fmt.Errorf("--project-ids and --group-ids replace --destination-project and --destination-group")
+			}
+		} else if (destinationProjectID != "" && destinationGroupID != "") || (destinationProjectID == "" && destinationGroupID == "") {
+			return fmt.Errorf("either --destination-project or --destination-group must be provided")
+		}
+
+		if destinationProjectID != "" {
+			baseURL, accessToken := config.DestinationBaseURL, config.DestinationAccessToken
+			if isSource {
+				baseURL, accessToken = config.SourceBaseURL, config.SourceAccessToken
+			}
+			if destinationProjectID, err = resolveProjectID(baseURL, accessToken, destinationProjectID); err != nil {
+				return err
+			}
 		}
 
-		if destinationGroupID != "" {
+		ctx, stop := interruptContext()
+		defer stop()
+
+		// Input projects of a recursive run that couldn't be paired with a destination project
+		unmatched := 0
+		if len(projectIDs) > 0 || len(groupIDs) > 0 {
+			variables, err := readInputFiles(inputFiles)
+			if err != nil {
+				return fmt.Errorf("failed to read input file: %v", err)
+			}
+			for _, id := range projectIDs {
+				if ctx.Err() != nil {
+					break
+				}
+				withProjectTimeout(ctx, "project "+id, func(ctx context.Context) {
+					createVariablesForProject(ctx, config, id, variables)
+				})
+			}
+			for _, id := range groupIDs {
+				if ctx.Err() != nil {
+					break
+				}
+				withProjectTimeout(ctx, "group "+id, func(ctx context.Context) {
+					createVariablesForGroup(ctx, config, id, variables)
+				})
+			}
+		} else if destinationGroupID != "" {
 			if recursive {
-				inputData, err := readRecursiveIputFile(inputFilePath)
+				inputData, err := readRecursiveInputFiles(inputFiles)
 				if err != nil {
-					fmt.Printf("Error reading input file: %v\n", err)
-					return
+					return fmt.Errorf("failed to read input file: %v", err)
 				}
-				projects, err := fetchAllProjects(config)
+				byRelativePath, index, err := fetchDestinationProjects(config)
 				if err != nil {
-					fmt.Printf("Error fetching projects: %v\n", err)
-					return
+					return fmt.Errorf("failed to fetch projects: %v", err)
 				}
+				inputRoot := inputGroupPath(inputData)
 
 				for _, projectData := range inputData {
+					if ctx.Err() != nil {
+						break
+					}
 					projectName, ok := projectData["project_name"].(string)
 					if !ok {
-						fmt.Printf("Error: Project name is not in the correct format.\n")
+						log.Printf("Error: Project name is not in the correct format.")
+						unmatched++
 						continue
 					}
-					projectID := findProjectIDByExactName(projects, projectName)
+					// Prefer the path recorded by newer exports, names can be ambiguous
+					var projectID int64
+					if projectPath, ok := projectData["path_with_namespace"].(string); ok && projectPath != "" {
+						if relativePath, under := relativeProjectPath(projectPath, inputRoot); under {
+							projectID = byRelativePath[relativePath]
+						}
+						if projectID == 0 {
+							projectID = index.byPath(projectPath)
+						}
+					}
+					if projectID == 0 {
+						projectID = index.byName(projectName)
+					}
 					if projectID == 0 {
-						fmt.Printf("Error: Project %s not found in the destination.\n", projectName)
+						log.Printf("Error: Project %s not found in the destination.", projectName)
+						unmatched++
 						continue
 					}
 					variables, ok := projectData["variables"].([]interface{})
 
 					if !ok {
-						fmt.Printf("Error: Variables for project %s are not in the correct format.\n", projectName)
+						log.Printf("Error: Variables for project %s are not in the correct format.", projectName)
+						unmatched++
 						continue
 					}
 
-					createVariablesForProject(config, strconv.FormatInt(projectID, 10), variables)
+					target := strconv.FormatInt(projectID, 10)
+					withProjectTimeout(ctx, "project "+target, func(ctx context.Context) {
+						createVariablesForProject(ctx, config, target, variables)
+					})
 				}
 			} else {
-				variables, err := readInputFile(inputFilePath)
+				variables, err := readInputFiles(inputFiles)
 				if err != nil {
-					fmt.Printf("Error reading input file: %v\n", err)
-					return
+					return fmt.Errorf("failed to read input file: %v", err)
 				}
-				createVariablesForGroup(config, destinationGroupID, variables)
+				createVariablesForGroup(ctx, config, destinationGroupID, variables)
 			}
 
 		} else {
-			variables, err := readInputFile(inputFilePath)
+			variables, err := readInputFiles(inputFiles)
 			if err != nil {
-				fmt.Printf("Error reading input file: %v\n", err)
-				return
+				return fmt.Errorf("failed to read input file: %v", err)
 			}
-			createVariablesForProject(config, destinationProjectID, variables)
+			createVariablesForProject(ctx, config, destinationProjectID, variables)
+		}
+
+		variablesSummary.print()
+		exitIfInterrupted(ctx)
+		failed := variablesSummary.failed()
+		if unmatched > 0 {
+			return fmt.Errorf("%d variables failed and %d input projects were not set", failed, unmatched)
 		}
+		if failed > 0 {
+			return fmt.Errorf("%d variables failed", failed)
+		}
+		return nil
 	},
 }
 
-func readRecursiveIputFile(filePath string) (map[string]map[string]interface{}, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("could not open file: %v", err)
+// resolveInputFiles expands glob patterns in the --input values, keeping their order
+func resolveInputFiles(patterns []string) ([]string, error) {
+	var files []string
+	readsStdin := false
+	for _, pattern := range patterns {
+		if pattern == stdinInput {
+			// Standard input can only be consumed once
+			if readsStdin {
+				return nil, fmt.Errorf("standard input (--input %s) can only be given once", stdinInput)
+			}
+			readsStdin = true
+			files = append(files, pattern)
+			continue
+		}
+
+		if !strings.ContainsAny(pattern, "*?[") {
+			files = append(files, pattern)
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("input pattern %s matched no files", pattern)
+		}
+		files = append(files, matches...)
 	}
-	defer file.Close()
+	return files, nil
+}
+
+// readInputFiles reads and merges the variable lists of several input files
+func readInputFiles(filePaths []string) ([]interface{}, error) {
+	var merged []interface{}
+	index := make(map[string]int)
+	for _, filePath := range filePaths {
+		variables, err := readInputFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", inputName(filePath), err)
+		}
+		if merged, err = mergeVariables(merged, index, variables, inputName(filePath)); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// readRecursiveInputFiles reads and merges several recursive input files.
+// Projects are matched by their key in the file; their variable lists are merged.
+func readRecursiveInputFiles(filePaths []string) (map[string]map[string]interface{}, error) {
+	merged := make(map[string]map[string]interface{})
+	indexes := make(map[string]map[string]int)
+	for _, filePath := range filePaths {
+		inputData, err := readRecursiveIputFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", inputName(filePath), err)
+		}
+
+		for projectKey, projectData := range inputData {
+			variables, ok := projectData["variables"].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: variables for project %s are not in the correct format", inputName(filePath), projectKey)
+			}
+
+			existing, ok := merged[projectKey]
+			if !ok {
+				existing = make(map[string]interface{})
+				merged[projectKey] = existing
+				indexes[projectKey] = make(map[string]int)
+			}
+			mergedVariables, _ := existing["variables"].([]interface{})
+			for k, v := range projectData {
+				existing[k] = v
+			}
+
+			mergedVariables, err = mergeVariables(mergedVariables, indexes[projectKey], variables, inputName(filePath))
+			if err != nil {
+				return nil, err
+			}
+			existing["variables"] = mergedVariables
+		}
+	}
+	return merged, nil
+}
 
-	data, err := io.ReadAll(file)
+// mergeVariables adds variables to merged, replacing entries with the same key
+// and environment scope. index maps variableID to the position in merged.
+func mergeVariables(merged []interface{}, index map[string]int, variables []interface{}, source string) ([]interface{}, error) {
+	for _, v := range variables {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: variable is not in the correct format", source)
+		}
+		key, ok := variable["key"].(string)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s: variable without a key", source)
+		}
+
+		id := variableID(key, variableScope(variable))
+		if i, exists := index[id]; exists {
+			utils.Infof("Merging inputs: %s (scope %s) from %s replaces an earlier definition", key, variableScope(variable), source)
+			merged[i] = variable
+			continue
+		}
+		index[id] = len(merged)
+		merged = append(merged, variable)
+	}
+	return merged, nil
+}
+
+func readRecursiveIputFile(filePath string) (map[string]map[string]interface{}, error) {
+	data, err := readInputData(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("could not read file: %v", err)
+		return nil, err
 	}
 
 	var parsedData map[string]map[string]interface{}
@@ -132,94 +375,162 @@ func readRecursiveIputFile(filePath string) (map[string]map[string]interface{},
 
 // readInputFile reads the input file for project variables
 func readInputFile(filePath string) ([]interface{}, error) {
+	data, err := readInputData(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVariablesInput(data, inputFormat)
+}
+
+// inputName describes an input file in messages
+func inputName(filePath string) string {
+	if filePath == stdinInput {
+		return "standard input"
+	}
+	return filePath
+}
+
+// readInputData reads an input file, or standard input when filePath is
+// stdinInput. Files ending in .gz are decompressed.
+func readInputData(filePath string) ([]byte, error) {
+	if filePath == stdinInput {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not read standard input: %v", err)
+		}
+		return data, nil
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open file: %v", err)
 	}
 	defer file.Close()
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("could not read file: %v", err)
+	var r io.Reader = file
+	if strings.HasSuffix(filePath, gzipExtension) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress file: %v", err)
+		}
+		defer gz.Close()
+		r = gz
 	}
 
-	var parsedData []interface{}
-	if err := json.Unmarshal(data, &parsedData); err != nil {
-		return nil, fmt.Errorf("could not parse JSON: %v", err)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %v", err)
 	}
-
-	return parsedData, nil
+	return data, nil
 }
 
 // fetchAllProjects retrieves all projects
 func fetchAllProjects(config *utils.Config) ([]map[string]interface{}, error) {
-	var allProjects []map[string]interface{}
 	baseUrl := config.DestinationBaseURL
 	accessToken := config.DestinationAccessToken
-	page := 1
 
 	if isSource {
 		baseUrl = config.SourceBaseURL
 		accessToken = config.SourceAccessToken
 	}
 
-	for {
-		url := fmt.Sprintf("%s/api/v4/groups/%s/projects?per_page=100&page=%d", baseUrl, destinationGroupID, page)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("error creating request: %v", err)
-		}
-
-		req.Header.Set("PRIVATE-TOKEN", accessToken)
+	projects, err := paginateCached(newGitLabClient(baseUrl, accessToken), "groups/"+destinationGroupID+"/projects")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching projects: %v", err)
+	}
 
-		httpConfig := utils.NewDefaultConfig()
-		httpConfig.SkipTLSVerification = true
-		client := utils.CreateHTTPClient(httpConfig)
+	return projects, nil
+}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching projects: %v", err)
-		}
-		defer resp.Body.Close()
+// fetchDestinationProjects lists the projects of the destination group (-G)
+// and its subgroups, on the source instance with --source. They are mapped by
+// their path relative to the group and indexed by full path and name.
+func fetchDestinationProjects(config *utils.Config) (map[string]int64, *projectIndex, error) {
+	baseURL, accessToken := config.DestinationBaseURL, config.DestinationAccessToken
+	if isSource {
+		baseURL, accessToken = config.SourceBaseURL, config.SourceAccessToken
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("error fetching projects: %s", resp.Status)
-		}
+	byRelativePath, err := projectsByRelativePath(baseURL, accessToken, destinationGroupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The list was just fetched, so unless --no-cache is set it comes from the
+	// list cache
+	projects, err := fetchProjectsWithSubgroups(baseURL, accessToken, destinationGroupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return byRelativePath, newProjectIndex(projects), nil
+}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("error reading response: %v", err)
+// inputGroupPath returns the namespace every project of a recursive input
+// has in common, the group it was exported from. It is empty when the input
+// records no paths or its projects share no namespace.
+func inputGroupPath(inputData map[string]map[string]interface{}) string {
+	var common []string
+	found := false
+	for _, projectData := range inputData {
+		projectPath, _ := projectData["path_with_namespace"].(string)
+		if projectPath == "" {
+			continue
 		}
-
-		var projects []map[string]interface{}
-		if err := json.Unmarshal(body, &projects); err != nil {
-			return nil, fmt.Errorf("error parsing projects: %v", err)
+		parts := strings.Split(projectPath, "/")
+		namespace := parts[:len(parts)-1]
+		if !found {
+			common, found = namespace, true
+			continue
 		}
-
-		if len(projects) == 0 {
-			break
+		n := 0
+		for n < len(common) && n < len(namespace) && common[n] == namespace[n] {
+			n++
 		}
-
-		allProjects = append(allProjects, projects...)
-		page++
+		common = common[:n]
 	}
+	return strings.Join(common, "/")
+}
 
-	return allProjects, nil
+// projectIndex maps the names and paths of a project list to their IDs, so
+// pairing thousands of projects doesn't scan the whole list for each one
+type projectIndex struct {
+	names map[string]int64
+	paths map[string]int64
 }
 
-// findProjectIDByExactName searches for a project by exact name in the list of projects
-func findProjectIDByExactName(projects []map[string]interface{}, projectName string) int64 {
+// newProjectIndex indexes projects. Of several projects with the same name,
+// the first one listed is kept.
+func newProjectIndex(projects []map[string]interface{}) *projectIndex {
+	index := &projectIndex{
+		names: make(map[string]int64, len(projects)),
+		paths: make(map[string]int64, len(projects)),
+	}
 	for _, project := range projects {
-		if projectName == project["name"].(string) {
-			return int64(project["id"].(float64))
+		id, _ := project["id"].(float64)
+		if name, ok := project["name"].(string); ok {
+			if _, seen := index.names[name]; !seen {
+				index.names[name] = int64(id)
+			}
+		}
+		if path, ok := project["path_with_namespace"].(string); ok {
+			index.paths[path] = int64(id)
 		}
 	}
-	return 0
+	return index
+}
+
+// byName returns the ID of the project with the exact name, or 0
+func (i *projectIndex) byName(projectName string) int64 {
+	return i.names[projectName]
 }
 
-// createVariablesForProject updates variables for a specific project
-func createVariablesForProject(config *utils.Config, projectID string, variables []interface{}) {
-	var url string
+// byPath returns the ID of the project at path_with_namespace, or 0
+func (i *projectIndex) byPath(projectPath string) int64 {
+	return i.paths[projectPath]
+}
+
+// createVariablesForProject creates variables for a specific project
+func createVariablesForProject(ctx context.Context, config *utils.Config, projectID string, variables []interface{}) {
 	baseUrl := config.DestinationBaseURL
 	accessToken := config.DestinationAccessToken
 
@@ -228,28 +539,21 @@ func createVariablesForProject(config *utils.Config, projectID string, variables
 		accessToken = config.SourceAccessToken
 	}
 
-	url = fmt.Sprintf("%s/api/v4/projects/%s/variables", baseUrl, projectID)
-
-	for _, variable := range variables {
-		payload, err := json.Marshal(variable)
-		if err != nil {
-			fmt.Printf("Error marshaling variable payload for project %s: %v\n", projectID, err)
-			continue
-		}
-
-		// Use POST method to create the variable
-		err = makeGitLabAPIRequest("POST", url, accessToken, string(payload))
-		if err != nil {
-			fmt.Printf("Error creating variable for project %s: %v\n", projectID, err)
-		} else {
-			fmt.Printf("Successfully created variable for project %s\n", projectID)
+	if ensureEnvironments && ctx.Err() == nil {
+		if err := ensureProjectEnvironments(ctx, baseUrl, accessToken, projectID, variables); err != nil {
+			utils.WithFields(utils.Fields{"project_id": projectID}).Warnf("Could not ensure environments for project %s: %v", projectID, err)
+			if errors.Is(err, utils.ErrUnauthorized) {
+				abortRun(err)
+			}
 		}
 	}
+
+	url := fmt.Sprintf("%s/api/v4/projects/%s/variables", baseUrl, projectID)
+	applyVariables(ctx, "project "+projectID, baseUrl, url, accessToken, variables)
 }
 
-// createVariablesForGroup updates variables for a specific group
-func createVariablesForGroup(config *utils.Config, groupID string, variables []interface{}) {
-	var url string
+// createVariablesForGroup creates variables for a specific group
+func createVariablesForGroup(ctx context.Context, config *utils.Config, groupID string, variables []interface{}) {
 	baseUrl := config.DestinationBaseURL
 	accessToken := config.DestinationAccessToken
 
@@ -258,60 +562,60 @@ func createVariablesForGroup(config *utils.Config, groupID string, variables []i
 		accessToken = config.SourceAccessToken
 	}
 
-	url = fmt.Sprintf("%s/api/v4/groups/%s/variables", baseUrl, groupID)
-
-	for _, variable := range variables {
-		payload, err := json.Marshal(variable)
-		if err != nil {
-			fmt.Printf("Error marshaling variable payload for group %s: %v\n", groupID, err)
-			continue
-		}
-
-		// Use POST method to create the variable
-		err = makeGitLabAPIRequest("POST", url, accessToken, string(payload))
-		if err != nil {
-			fmt.Printf("Error creating variable for group %s: %v\n", groupID, err)
-		} else {
-			fmt.Printf("Successfully created variable for group %s\n", groupID)
-		}
+	if ensureEnvironments {
+		warnOnce("--ensure-environments only applies to project variables, groups have no environments")
 	}
+
+	url := fmt.Sprintf("%s/api/v4/groups/%s/variables", baseUrl, groupID)
+	applyVariables(ctx, "group "+groupID, baseUrl, url, accessToken, variables)
 }
 
 // makeGitLabAPIRequest makes an HTTP request to the GitLab API
 func makeGitLabAPIRequest(method, url, token string, payload string) error {
-	req, err := http.NewRequest(method, url, strings.NewReader(payload))
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
-	}
-
-	req.Header.Set("PRIVATE-TOKEN", token)
-	req.Header.Set("Content-Type", "application/json")
+	return makeGitLabAPIRequestContext(context.Background(), method, url, token, payload)
+}
 
-	httpConfig := utils.NewDefaultConfig()
-	httpConfig.SkipTLSVerification = true
-	client := utils.CreateHTTPClient(httpConfig)
+// makeGitLabAPIRequestContext is makeGitLabAPIRequest with the request bound to ctx
+func makeGitLabAPIRequestContext(ctx context.Context, method, url, token string, payload string) error {
+	return newGitLabClient("", token).Request(ctx, method, url, jsonPayload(payload), nil)
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
+// sendGitLabAPIRequest sends a request to the GitLab API and returns the
+// response with its body already read, whatever the status
+func sendGitLabAPIRequest(ctx context.Context, method, url, token string, payload string) (*http.Response, []byte, error) {
+	return newGitLabClient("", token).Do(ctx, method, url, jsonPayload(payload))
+}
 
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API returned error status: %s", resp.Status)
+// jsonPayload returns an encoded JSON request body as the body of a
+// utils.GitLabClient request, nil when there is none
+func jsonPayload(payload string) interface{} {
+	if payload == "" {
+		return nil
 	}
-
-	return nil
+	return json.RawMessage(payload)
 }
 
 func init() {
 	// input file for setting variables
-	setVariablesCmd.Flags().StringVarP(&inputFilePath, "input", "i", "", "Path to the input JSON file")
+	setVariablesCmd.Flags().StringArrayVarP(&inputFilePaths, "input", "i", nil, "Path or glob of the input JSON file, or - for standard input (repeatable, later files win)")
 	setVariablesCmd.MarkFlagRequired("input")
-	setVariablesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "The destination project ID to set variables for")
+	setVariablesCmd.Flags().StringVar(&inputFormat, "format", inputFormatJSON, "Format of the input files: json, dotenv or gitlab-ci (.gitlab-ci.yml variables: block)")
+	setVariablesCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "The destination project ID or full path to set variables for")
 	setVariablesCmd.Flags().StringVarP(&destinationGroupID, "destination-group", "G", "", "The destination group ID to set variables for")
+	setVariablesCmd.Flags().StringVar(&projectIDList, "project-ids", "", "Comma-separated destination project IDs, or @file with one ID per line, to set the variables for")
+	setVariablesCmd.Flags().StringVar(&groupIDList, "group-ids", "", "Comma-separated destination group IDs, or @file with one ID per line, to set the variables for")
 	setVariablesCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Recursively set variables from all projects in a group")
 	setVariablesCmd.Flags().BoolVarP(&isSource, "source", "s", false, "Set variables to the source instance instead of the destination instance")
+	setVariablesCmd.Flags().StringVar(&onConflict, "on-conflict", conflictFail, "What to do when a variable key and scope already exist: skip, update, fail or rename")
+	setVariablesCmd.Flags().StringVar(&renameSuffix, "rename-suffix", "_MIGRATED", "Suffix appended to the key of conflicting variables with --on-conflict=rename")
+	setVariablesCmd.Flags().StringVar(&unmaskable, "unmaskable", unmaskableKeep, "Masked variables GitLab can't mask: keep (send as-is), unmask (create unmasked with a warning) or skip")
+	setVariablesCmd.Flags().BoolVar(&ensureEnvironments, "ensure-environments", false, "Create the environments named by variable scopes on destination projects that lack them")
+	setVariablesCmd.Flags().BoolVar(&unprotect, "unprotect", false, "Create protected variables as unprotected, e.g. when the destination has no protected branches yet")
+	setVariablesCmd.Flags().DurationVar(&timeoutPerProject, "timeout-per-project", 0, "Give up on a project after this long when setting several, recording its variables as timed out (0 means no limit)")
+	setVariablesCmd.Flags().BoolVar(&sortKeys, "sort-keys", true, "Create variables sorted by key and environment scope for stable, comparable runs")
+	setVariablesCmd.Flags().StringArrayVar(&filterKeys, "filter-key", nil, "Only set variables whose key matches this pattern: a glob like PROD_*, or a regular expression between slashes like /^PROD_/ (repeatable)")
+	setVariablesCmd.Flags().StringArrayVar(&excludeKeys, "exclude-key", nil, "Leave out variables whose key matches this glob or /regular expression/ (repeatable, wins over --filter-key)")
+	setVariablesCmd.Flags().StringVar(&filterEnvironmentScope, "environment-scope", "", "Only set variables of this environment scope, e.g. production (* or empty for every scope); scopes are kept as they are")
 
 	setCmd.AddCommand(setVariablesCmd)
 	rootCmd.AddCommand(setCmd)
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInputGroupPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"direct projects", []string{"old-org/web", "old-org/api"}, "old-org"},
+		{"subgroups", []string{"old-org/backend/api", "old-org/frontend/api"}, "old-org"},
+		{"nested group", []string{"old-org/team/api", "old-org/team/web"}, "old-org/team"},
+		{"no common namespace", []string{"old-org/api", "other-org/api"}, ""},
+		{"no paths", []string{""}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputData := make(map[string]map[string]interface{})
+			for i, path := range tt.paths {
+				inputData[fmt.Sprint(i)] = map[string]interface{}{"path_with_namespace": path}
+			}
+			if got := inputGroupPath(inputData); got != tt.want {
+				t.Errorf("inputGroupPath(%v) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRecursivePairsByPathRelativeToGroup(t *testing.T) {
+	listing := groupListingHandler(t)
+	var mu sync.Mutex
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/variables") {
+			mu.Lock()
+			created = append(created, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/variables") {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		listing(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	input := filepath.Join(t.TempDir(), "input.json")
+	data := `{
+		"1": {"project_name": "api", "path_with_namespace": "old-org/backend/api", "variables": [{"key": "BACKEND", "value": "1"}]},
+		"2": {"project_name": "api", "path_with_namespace": "old-org/frontend/api", "variables": [{"key": "FRONTEND", "value": "2"}]}
+	}`
+	if err := os.WriteFile(input, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "--no-cache", "set", "variables", "-G", "new-org", "-r", "-i", input)
+	if err != nil {
+		t.Fatalf("set variables: %v", err)
+	}
+	sort.Strings(created)
+	want := []string{"/api/v4/projects/11/variables", "/api/v4/projects/12/variables"}
+	if strings.Join(created, " ") != strings.Join(want, " ") {
+		t.Errorf("variables created at %v, want %v", created, want)
+	}
+}
+
+func TestNewProjectIndex(t *testing.T) {
+	index := newProjectIndex([]map[string]interface{}{
+		{"id": float64(10), "name": "web", "path_with_namespace": "new-org/web"},
+		{"id": float64(11), "name": "api", "path_with_namespace": "new-org/backend/api"},
+		{"id": float64(12), "name": "api", "path_with_namespace": "new-org/frontend/api"},
+		{"id": float64(13)},
+	})
+
+	tests := []struct {
+		lookup string
+		key    string
+		want   int64
+	}{
+		{"name", "web", 10},
+		{"name", "api", 11},
+		{"name", "Web", 0},
+		{"name", "missing", 0},
+		{"path", "new-org/frontend/api", 12},
+		{"path", "new-org/backend/api", 11},
+		{"path", "new-org/api", 0},
+	}
+	for _, tt := range tests {
+		got := index.byName(tt.key)
+		if tt.lookup == "path" {
+			got = index.byPath(tt.key)
+		}
+		if got != tt.want {
+			t.Errorf("by%s(%q) = %d, want %d", tt.lookup, tt.key, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkProjectIndex pairs every project of a large group by name, as set
+// -r does for each input project
+func BenchmarkProjectIndex(b *testing.B) {
+	projects := make([]map[string]interface{}, 5000)
+	for i := range projects {
+		name := fmt.Sprintf("project-%d", i)
+		projects[i] = map[string]interface{}{"id": float64(i + 1), "name": name, "path_with_namespace": "new-org/" + name}
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		index := newProjectIndex(projects)
+		for _, project := range projects {
+			if index.byName(project["name"].(string)) == 0 {
+				b.Fatalf("project %s not found", project["name"])
+			}
+		}
+	}
+}
+
+func TestSetVariablesSendsDescription(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{"17.0.0", []string{`A:"Deploy token"`, "B:", "C:"}},
+		// Descriptions were added in GitLab 16.2
+		{"16.1.0", []string{"A:", "B:", "C:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var mu sync.Mutex
+			var sent []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/api/v4/version":
+					fmt.Fprintf(w, `{"version":"%s"}`, tt.version)
+				case r.Method == http.MethodGet:
+					fmt.Fprint(w, `[]`)
+				default:
+					var variable map[string]interface{}
+					json.NewDecoder(r.Body).Decode(&variable)
+					description := ""
+					if value, ok := variable["description"]; ok {
+						encoded, _ := json.Marshal(value)
+						description = string(encoded)
+					}
+					mu.Lock()
+					sent = append(sent, fmt.Sprintf("%s:%s", variable["key"], description))
+					mu.Unlock()
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprint(w, `{}`)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			input := filepath.Join(t.TempDir(), "input.json")
+			data := `[
+				{"key": "A", "value": "1", "description": "Deploy token"},
+				{"key": "B", "value": "2", "description": ""},
+				{"key": "C", "value": "3", "description": null}
+			]`
+			if err := os.WriteFile(input, []byte(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "set", "variables", "-P", "2", "-i", input); err != nil {
+				t.Fatalf("set variables: %v", err)
+			}
+			sort.Strings(sent)
+			if strings.Join(sent, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("sent %v, want %v", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetVariablesReturnsErrorOnFailure(t *testing.T) {
+	_, server := newVariablesServer(t, false)
+	setVariableFlags(t, conflictFail, false)
+
+	input := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(input, []byte(`[{"key": "A", "value": "new"}, {"key": "B", "value": "2"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A already exists and --on-conflict is fail
+	err := executeCommand(t, "-c", writeTestConfig(t, server.URL), "-q", "set", "variables", "-P", "1", "-i", input)
+	if want := "1 variables failed"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
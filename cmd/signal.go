@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// exitCodeInterrupted is the exit status of a run stopped by SIGINT/SIGTERM (128 + SIGINT)
+const exitCodeInterrupted = 130
+
+// errInterrupted is the cancellation cause of a context stopped by a signal
+var errInterrupted = errors.New("interrupted")
+
+// abortRun cancels the context of the current run with the given cause, e.g.
+// when the access token stops working. It is set by interruptContext.
+var abortRun context.CancelCauseFunc = func(error) {}
+
+// interruptContext returns a context that is cancelled on the first SIGINT or
+// SIGTERM, or by abortRun. Work already in flight is left to finish; callers
+// check the context before starting the next request. A second signal
+// terminates immediately.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	abortRun = cancel
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			// Restore default signal handling so a second Ctrl-C exits right away
+			signal.Stop(signals)
+			fmt.Println("Interrupt received, finishing the current request and stopping...")
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(nil)
+	}
+}
+
+// wasInterrupted reports whether ctx was cancelled by a signal
+func wasInterrupted(ctx context.Context) bool {
+	return context.Cause(ctx) == errInterrupted
+}
+
+// exitIfInterrupted exits with exitCodeInterrupted when ctx was cancelled by a
+// signal, or with status 1 when the run was aborted because the access token
+// was rejected. Call it after the summary has been printed.
+func exitIfInterrupted(ctx context.Context) {
+	if errors.Is(context.Cause(ctx), utils.ErrUnauthorized) {
+		fmt.Println("Run aborted: the access token expired or was revoked. Variables marked \"not applied\" were not created, renew the token and re-run to continue")
+		os.Exit(1)
+	}
+	if !wasInterrupted(ctx) {
+		return
+	}
+	fmt.Println("Run interrupted: variables marked \"not applied\" were not created, re-run to continue")
+	os.Exit(exitCodeInterrupted)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+var sinceExport string
+
+// readPreviousExport reads a variables export written by "get variables" or
+// the source backup of "migrate variables": a list of variables, or a
+// recursive export of entries that each carry a "variables" list. Exports
+// ending in .gz are decompressed.
+func readPreviousExport(path string) (interface{}, error) {
+	data, err := readInputData(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous export %s: %w", path, err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var variables []map[string]interface{}
+		if err := json.Unmarshal(data, &variables); err != nil {
+			return nil, fmt.Errorf("failed to parse previous export %s: %w", path, err)
+		}
+		return variables, nil
+	}
+
+	var entries map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse previous export %s: %w", path, err)
+	}
+	for key, entry := range entries {
+		variables, err := utils.ToRows(entry["variables"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid variables for entry %s of previous export %s: %w", key, path, err)
+		}
+		entry["variables"] = variables
+	}
+	return entries, nil
+}
+
+// filterSinceExport keeps only the source variables that are new or differ
+// from the previous export, compared like "diff variables", and reports the
+// added, changed and unchanged keys of every group or project
+func filterSinceExport(current, previous interface{}) (interface{}, error) {
+	switch cur := current.(type) {
+	case []map[string]interface{}:
+		prev, ok := previous.([]map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the previous export is a recursive export, but the source is a single group or project")
+		}
+		return changedVariables("", cur, prev), nil
+
+	case map[string]map[string]interface{}:
+		prev, ok := previous.(map[string]map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the previous export holds the variables of a single group or project, but the source is recursive")
+		}
+
+		// Entries are matched by project ID, or by path for exports keyed by path
+		prevByProject := make(map[string]map[string]interface{}, len(prev))
+		for _, entry := range prev {
+			prevByProject[diffValue(entry["project_id"])] = entry
+			if path, ok := entry["path_with_namespace"].(string); ok && path != "" {
+				prevByProject[path] = entry
+			}
+		}
+
+		for _, key := range sortedProjectKeys(cur, sortProjectsBy) {
+			entry := cur[key]
+			prevEntry, ok := prevByProject[diffValue(entry["project_id"])]
+			if !ok {
+				path, _ := entry["path_with_namespace"].(string)
+				prevEntry = prevByProject[path]
+			}
+			variables, _ := entry["variables"].([]map[string]interface{})
+			prevVariables, _ := prevEntry["variables"].([]map[string]interface{})
+			label, _ := entry["path_with_namespace"].(string)
+			entry["variables"] = changedVariables(label, variables, prevVariables)
+		}
+		return cur, nil
+	}
+	return current, nil
+}
+
+// changedVariables returns the variables of current that are new or differ
+// from previous, printing which keys were added, changed or unchanged
+func changedVariables(label string, current, previous []map[string]interface{}) []map[string]interface{} {
+	diff := diffVariables(current, previous)
+	changed := make(map[string]bool, len(diff.Changed))
+	for _, c := range diff.Changed {
+		changed[variableID(c.Key, c.Scope)] = true
+	}
+	inPrevious := make(map[string]bool, len(previous))
+	for _, variable := range previous {
+		key, _ := variable["key"].(string)
+		inPrevious[variableID(key, variableScope(variable))] = true
+	}
+
+	var selected []map[string]interface{}
+	var added, updated []string
+	for _, variable := range current {
+		key, _ := variable["key"].(string)
+		id := variableID(key, variableScope(variable))
+		switch {
+		case !inPrevious[id]:
+			added = append(added, describeVariable(variable))
+		case changed[id]:
+			updated = append(updated, describeVariable(variable))
+		default:
+			continue
+		}
+		selected = append(selected, variable)
+	}
+
+	heading := "Since the previous export"
+	if label != "" {
+		heading += " (" + label + ")"
+	}
+	fmt.Printf("%s: %d added, %d changed, %d unchanged\n", heading, len(added), len(updated), diff.Identical)
+	for _, v := range added {
+		fmt.Printf("  added    %s\n", v)
+	}
+	for _, v := range updated {
+		fmt.Printf("  changed  %s\n", v)
+	}
+	if len(diff.OnlyInDestination) > 0 {
+		fmt.Printf("  %d removed from the source since the export, not deleted on the destination\n", len(diff.OnlyInDestination))
+	}
+	return selected
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+var timeoutPerProject time.Duration
+
+// projectContext returns the context to process one of several projects or
+// groups with: ctx, limited to --timeout-per-project when it is set
+func projectContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeoutPerProject > 0 {
+		return context.WithTimeout(ctx, timeoutPerProject)
+	}
+	return context.WithCancel(ctx)
+}
+
+// requestContext returns the context to send a single write request with: ctx
+// without its cancellation, so a request already sent when the run is
+// interrupted is left to finish, but still bound to its --timeout-per-project
+// deadline
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return context.WithCancel(detached)
+}
+
+// projectTimedOut reports whether ctx passed its --timeout-per-project deadline
+func projectTimedOut(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// withProjectTimeout runs fn for target with projectContext, so a target whose
+// requests hang is given up on and the run moves on to the next one
+func withProjectTimeout(ctx context.Context, target string, fn func(ctx context.Context)) {
+	projectCtx, cancel := projectContext(ctx)
+	defer cancel()
+	fn(projectCtx)
+	if projectTimedOut(projectCtx) {
+		utils.WithFields(targetFields(target)).Warnf("timed out after %s processing %s, continuing with the next one", timeoutPerProject, target)
+	}
+}
@@ -2,12 +2,17 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"os"
 	"os/exec"
 	"runtime"
 
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
 	"github.com/spf13/cobra"
 )
 
@@ -15,41 +20,79 @@ type Release struct {
 	TagName string `json:"tag_name"`
 }
 
+// releasesURL lists the published releases of gitlab-migrate, newest first
+const releasesURL = "https://gitlab.com/api/v4/projects/65329846/releases"
+
+// errNoReleases is returned when no release has been published yet
+var errNoReleases = errors.New("no releases found")
+
+// exitUpgradeAvailable is the exit status of upgrade --check-only when a newer
+// release exists; failing to check exits with 1
+const exitUpgradeAvailable = 2
+
+var upgradeForce bool
+var upgradeCheckOnly bool
+
 var upgradeCmd = &cobra.Command{
 	Use:   "upgrade",
 	Short: "Upgrade gitlab-migrate to the latest version",
 	Long: `Upgrade gitlab-migrate to the latest version from the official repository.
-This command will check for the latest version and upgrade if necessary.`,
+This command will check for the latest version and upgrade if it is newer than
+the current one, comparing them as semantic versions. Use --force to reinstall
+or downgrade to the latest release regardless.
+
+With --check-only nothing is installed: the result is printed and the command
+exits with status 2 when a newer release is available, 0 when it isn't and 1
+when the check fails, e.g. for a CI job.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if upgradeCheckOnly && upgradeForce {
+			log.Println("Error: --check-only doesn't install anything, don't combine it with --force")
+			os.Exit(1)
+		}
+
 		currentVersion := Version // Version should be defined in root.go
 		fmt.Printf("Current version: %s\n", currentVersion)
 
-		// Get latest version from GitLab API
-		resp, err := http.Get("https://gitlab.com/api/v4/projects/65329846/releases")
-		if err != nil {
-			fmt.Printf("Error checking for updates: %v\n", err)
+		latestVersion, err := fetchLatestRelease(releasesURL)
+		if errors.Is(err, errNoReleases) {
+			fmt.Println("No releases found, there is nothing to upgrade to yet.")
 			return
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
 		if err != nil {
-			fmt.Printf("Error reading response: %v\n", err)
+			log.Printf("Error checking for updates: %v", err)
+			exitIfCheckOnly()
 			return
 		}
+		fmt.Printf("Latest version: %s\n", latestVersion)
 
-		var releases []Release
-		if err := json.Unmarshal(body, &releases); err != nil {
-			fmt.Printf("Error parsing response: %v\n", err)
-			return
+		comparison, err := compareVersions(currentVersion, latestVersion)
+		if upgradeCheckOnly {
+			switch {
+			case err != nil:
+				log.Printf("Error: %v", err)
+			case comparison < 0:
+				fmt.Printf("Version %s is available, run \"gitlab-migrate upgrade\" to install it.\n", latestVersion)
+			case comparison == 0:
+				fmt.Println("You are already using the latest version!")
+			default:
+				fmt.Println("Your version is newer than the latest release.")
+			}
+			os.Exit(checkOnlyExitCode(comparison, err))
 		}
 
-		latestVersion := releases[0].TagName
-		fmt.Printf("Latest version: %s\n", latestVersion)
-
-		if currentVersion == latestVersion {
-			fmt.Println("You are already using the latest version!")
-			return
+		if !upgradeForce {
+			if err != nil {
+				log.Printf("Error: %v; use --force to install %s anyway", err, latestVersion)
+				return
+			}
+			switch comparison {
+			case 0:
+				fmt.Println("You are already using the latest version!")
+				return
+			case 1:
+				fmt.Printf("Your version is newer than the latest release, not downgrading (use --force to install %s).\n", latestVersion)
+				return
+			}
 		}
 
 		fmt.Printf("Upgrading to version %s...\n", latestVersion)
@@ -68,7 +111,7 @@ This command will check for the latest version and upgrade if necessary.`,
 
 		output, err := updCmd.CombinedOutput()
 		if err != nil {
-			fmt.Printf("Error upgrading: %v\n%s\n", err, string(output))
+			log.Printf("Error upgrading: %v\n%s", err, string(output))
 			return
 		}
 
@@ -76,6 +119,87 @@ This command will check for the latest version and upgrade if necessary.`,
 	},
 }
 
+// compareVersions compares the current version with the latest release as
+// semantic versions, returning -1, 0 or 1 as for SemanticVersion.Compare
+func compareVersions(currentVersion, latestVersion string) (int, error) {
+	current, err := utils.ParseSemanticVersion(currentVersion)
+	if err != nil {
+		return 0, fmt.Errorf("the current version can't be compared: %v", err)
+	}
+	latest, err := utils.ParseSemanticVersion(latestVersion)
+	if err != nil {
+		return 0, fmt.Errorf("the latest release can't be compared: %v", err)
+	}
+	return current.Compare(latest), nil
+}
+
+// checkOnlyExitCode returns the exit status of upgrade --check-only for the
+// result of compareVersions: exitUpgradeAvailable when the latest release is
+// newer, 1 when the versions can't be compared and 0 otherwise
+func checkOnlyExitCode(comparison int, err error) int {
+	switch {
+	case err != nil:
+		return 1
+	case comparison < 0:
+		return exitUpgradeAvailable
+	default:
+		return 0
+	}
+}
+
+// exitIfCheckOnly exits with status 1 under --check-only, so a failed check
+// isn't mistaken for being up to date
+func exitIfCheckOnly() {
+	if upgradeCheckOnly {
+		os.Exit(1)
+	}
+}
+
+// fetchLatestRelease returns the highest release version listed at url. Tags
+// that aren't semantic versions are left out, unless no tag is one, in which
+// case the first listed is returned.
+func fetchLatestRelease(url string) (string, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("release list returned %s", resp.Status)
+	}
+
+	var releases []Release
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return "", fmt.Errorf("failed to parse response: %v", err)
+	}
+	if len(releases) == 0 || releases[0].TagName == "" {
+		return "", errNoReleases
+	}
+
+	var latest *utils.SemanticVersion
+	for _, release := range releases {
+		version, err := utils.ParseSemanticVersion(release.TagName)
+		if err != nil {
+			continue
+		}
+		if latest == nil || version.Compare(latest) > 0 {
+			latest = version
+		}
+	}
+	if latest == nil {
+		return releases[0].TagName, nil
+	}
+	return latest.Raw, nil
+}
+
 func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCheckOnly, "check-only", false, "Only report whether a newer release is available, exiting with status 2 if so, without installing it")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Install the latest release even when it isn't newer than the current version")
 	rootCmd.AddCommand(upgradeCmd)
 }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            int
+	}{
+		{"v1.0.3", "v1.0.3", 0},
+		{"1.0.3", "v1.0.3", 0},
+		{"v1.0.3", "v1.1.0", -1},
+		{"v1.1.0-rc.1", "v1.1.0", -1},
+		{"v1.1.0", "v1.1.0-rc.1", 1},
+		{"v2.0.0", "v1.9.9", 1},
+	}
+	for _, tt := range tests {
+		got, err := compareVersions(tt.current, tt.latest)
+		if err != nil {
+			t.Errorf("compareVersions(%q, %q): %v", tt.current, tt.latest, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsUnparseable(t *testing.T) {
+	tests := []struct {
+		current, latest string
+	}{
+		{"dev", "v1.0.3"},
+		{"v1.0.3", "latest"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if _, err := compareVersions(tt.current, tt.latest); err == nil {
+			t.Errorf("compareVersions(%q, %q) succeeded, want an error", tt.current, tt.latest)
+		}
+	}
+}
+
+func TestFetchLatestRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr string
+	}{
+		{"highest version", http.StatusOK, `[{"tag_name":"v1.2.0"},{"tag_name":"v1.10.0"},{"tag_name":"nightly"}]`, "v1.10.0", ""},
+		{"no semantic versions", http.StatusOK, `[{"tag_name":"nightly"},{"tag_name":"latest"}]`, "nightly", ""},
+		{"no releases", http.StatusOK, `[]`, "", errNoReleases.Error()},
+		{"error status", http.StatusServiceUnavailable, `{"message":"503 Service Unavailable"}`, "", "release list returned 503 Service Unavailable"},
+		{"not found", http.StatusNotFound, `{"message":"404 Project Not Found"}`, "", "release list returned 404 Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			got, err := fetchLatestRelease(server.URL)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				if tt.wantErr == errNoReleases.Error() && !errors.Is(err, errNoReleases) {
+					t.Errorf("error %v doesn't match errNoReleases", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchLatestRelease: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("fetchLatestRelease() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOnlyExitCode(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            int
+	}{
+		{"v1.0.0", "v1.1.0", exitUpgradeAvailable},
+		{"v1.1.0", "v1.1.0", 0},
+		{"v1.2.0", "v1.1.0", 0},
+		{"dev", "v1.1.0", 1},
+	}
+	for _, tt := range tests {
+		if got := checkOnlyExitCode(compareVersions(tt.current, tt.latest)); got != tt.want {
+			t.Errorf("checkOnlyExitCode for %s -> %s = %d, want %d", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
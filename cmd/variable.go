@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Defaults GitLab applies to variable attributes that are not sent
+const (
+	defaultVariableType  = "env_var"
+	defaultVariableScope = "*"
+)
+
+// Variable holds the attributes that decide how a CI/CD variable behaves.
+// They are always sent explicitly when a variable is written, since any that
+// are left out revert to the destination's defaults.
+type Variable struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	VariableType     string `json:"variable_type"`
+	Protected        bool   `json:"protected"`
+	Masked           bool   `json:"masked"`
+	Raw              bool   `json:"raw"`
+	EnvironmentScope string `json:"environment_scope"`
+	Description      string `json:"description,omitempty"`
+}
+
+// decodeVariable reads the typed attributes of a variable payload, filling in
+// GitLab's defaults for those that are missing
+func decodeVariable(variable map[string]interface{}) (Variable, error) {
+	data, err := json.Marshal(variable)
+	if err != nil {
+		return Variable{}, err
+	}
+	var decoded Variable
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return Variable{}, err
+	}
+	if decoded.Key == "" {
+		return Variable{}, fmt.Errorf("the variable has no key")
+	}
+	if decoded.VariableType == "" {
+		decoded.VariableType = defaultVariableType
+	}
+	if decoded.EnvironmentScope == "" {
+		decoded.EnvironmentScope = defaultVariableScope
+	}
+	return decoded, nil
+}
+
+// withVariableAttributes returns a copy of variable with every typed attribute
+// set explicitly, a missing description as an empty one. Other attributes are
+// kept as they are.
+func withVariableAttributes(variable map[string]interface{}) (map[string]interface{}, error) {
+	decoded, err := decodeVariable(variable)
+	if err != nil {
+		return nil, err
+	}
+	explicit := copyVariable(variable)
+	explicit["key"] = decoded.Key
+	explicit["variable_type"] = decoded.VariableType
+	explicit["protected"] = decoded.Protected
+	explicit["masked"] = decoded.Masked
+	explicit["raw"] = decoded.Raw
+	explicit["environment_scope"] = decoded.EnvironmentScope
+	explicit["description"] = decoded.Description
+	return explicit, nil
+}
+
+// decodeVariables applies withVariableAttributes to variables listed by the API
+func decodeVariables(variables []map[string]interface{}) ([]map[string]interface{}, error) {
+	decoded := make([]map[string]interface{}, 0, len(variables))
+	for _, variable := range variables {
+		explicit, err := withVariableAttributes(variable)
+		if err != nil {
+			key, _ := variable["key"].(string)
+			return nil, fmt.Errorf("variable %q: %v", key, err)
+		}
+		decoded = append(decoded, explicit)
+	}
+	return decoded, nil
+}
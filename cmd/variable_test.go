@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeVariable(t *testing.T) {
+	decoded, err := decodeVariable(map[string]interface{}{
+		"key":         "DB_URL",
+		"value":       "postgres://db",
+		"protected":   true,
+		"description": "Primary database",
+	})
+	if err != nil {
+		t.Fatalf("decodeVariable: %v", err)
+	}
+	want := Variable{
+		Key:              "DB_URL",
+		Value:            "postgres://db",
+		VariableType:     defaultVariableType,
+		Protected:        true,
+		EnvironmentScope: defaultVariableScope,
+		Description:      "Primary database",
+	}
+	if decoded != want {
+		t.Errorf("decodeVariable = %+v, want %+v", decoded, want)
+	}
+
+	if _, err := decodeVariable(map[string]interface{}{"value": "x"}); err == nil {
+		t.Error("decodeVariable accepted a variable without a key")
+	}
+}
+
+func TestWithVariableAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable map[string]interface{}
+		want     interface{}
+	}{
+		{"description", map[string]interface{}{"key": "A", "description": "kept"}, "kept"},
+		{"null description", map[string]interface{}{"key": "A", "description": nil}, ""},
+		{"no description", map[string]interface{}{"key": "A"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explicit, err := withVariableAttributes(tt.variable)
+			if err != nil {
+				t.Fatalf("withVariableAttributes: %v", err)
+			}
+			if got := explicit["description"]; got != tt.want {
+				t.Errorf("description = %#v, want %#v", got, tt.want)
+			}
+			want := map[string]interface{}{
+				"key":               "A",
+				"variable_type":     defaultVariableType,
+				"protected":         false,
+				"masked":            false,
+				"raw":               false,
+				"environment_scope": defaultVariableScope,
+				"description":       tt.want,
+			}
+			if !reflect.DeepEqual(explicit, want) {
+				t.Errorf("withVariableAttributes = %v, want %v", explicit, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,527 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+)
+
+// Strategies for variables whose key and scope already exist on the destination
+const (
+	conflictSkip   = "skip"
+	conflictUpdate = "update"
+	conflictFail   = "fail"
+	conflictRename = "rename"
+)
+
+// actionCreate is used when a variable does not exist on the destination yet
+const actionCreate = "create"
+
+// errVariableExists is returned when creating a variable whose key and
+// environment scope are already taken on the destination
+var errVariableExists = errors.New("already exists")
+
+// Outcomes recorded in the variable summary
+const (
+	outcomeCreated = "created"
+	outcomeUpdated = "updated"
+	outcomeSkipped = "skipped"
+	outcomeRenamed = "renamed"
+	outcomeFailed  = "failed"
+	// outcomeNotApplied marks variables left untouched because the run was interrupted or aborted
+	outcomeNotApplied = "not applied"
+	// outcomeTimedOut marks variables not written because their project passed --timeout-per-project
+	outcomeTimedOut = "timed out"
+)
+
+// unprotectWarning is recorded for variables --unprotect turned protected off on
+const unprotectWarning = "protected was turned off (--unprotect)"
+
+// Ways to handle masked variables whose values GitLab can't mask
+const (
+	unmaskableKeep   = "keep"
+	unmaskableUnmask = "unmask"
+	unmaskableSkip   = "skip"
+)
+
+var onConflict string
+var renameSuffix string
+var unmaskable string
+var sortKeys bool
+var unprotect bool
+var variableKey string
+var variablesDryRun bool
+var variableEnvScope string
+
+// variableResult records what happened to a single variable
+type variableResult struct {
+	Target  string
+	Key     string
+	Scope   string
+	Outcome string
+	Detail  string
+	// Warnings lists changes made to the variable before it was written
+	Warnings []string
+	// Unprotected is set when --unprotect turned protected off
+	Unprotected bool
+}
+
+// variableSummary collects per-variable outcomes for the end-of-run report
+type variableSummary struct {
+	mu      sync.Mutex
+	results []variableResult
+}
+
+var variablesSummary variableSummary
+
+func (s *variableSummary) add(result variableResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, result)
+}
+
+// unfinished returns the results of variables that failed or were not applied,
+// leaving out entries without a key since they can't be retried
+func (s *variableSummary) unfinished() []variableResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []variableResult
+	for _, r := range s.results {
+		if (r.Outcome == outcomeFailed || r.Outcome == outcomeNotApplied || r.Outcome == outcomeTimedOut) && r.Key != "" {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// failed returns the number of variables that failed or timed out
+func (s *variableSummary) failed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := 0
+	for _, r := range s.results {
+		if r.Outcome == outcomeFailed || r.Outcome == outcomeTimedOut {
+			failed++
+		}
+	}
+	return failed
+}
+
+// print logs every recorded outcome followed by per-outcome totals, as one
+// block so it isn't interleaved with other output
+func (s *variableSummary) print() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.results) == 0 {
+		return
+	}
+
+	logger := utils.NewLogger(nil, true)
+	defer logger.Flush()
+	counts := make(map[string]int)
+	unprotected := 0
+	var timedOut []string
+	if variablesDryRun {
+		logger.Infof("Variable summary (dry run, nothing was changed):")
+	} else {
+		logger.Infof("Variable summary:")
+	}
+	for _, r := range s.results {
+		counts[r.Outcome]++
+		if r.Outcome == outcomeTimedOut && !slices.Contains(timedOut, r.Target) {
+			timedOut = append(timedOut, r.Target)
+		}
+		if r.Unprotected && r.Outcome != outcomeFailed {
+			unprotected++
+		}
+		line := fmt.Sprintf("  %-11s %s %s (scope %s)", r.Outcome, r.Target, r.Key, r.Scope)
+		if r.Detail != "" {
+			line += ": " + r.Detail
+		}
+		logger.Infof("%s", line)
+		for _, warning := range r.Warnings {
+			logger.Infof("              warning: %s", warning)
+		}
+	}
+	logger.Infof("Created: %d, Updated: %d, Renamed: %d, Skipped: %d, Failed: %d",
+		counts[outcomeCreated], counts[outcomeUpdated], counts[outcomeRenamed], counts[outcomeSkipped], counts[outcomeFailed])
+	if unprotected > 0 {
+		logger.Infof("Unprotected (--unprotect): %d", unprotected)
+	}
+	if counts[outcomeNotApplied] > 0 {
+		logger.Infof("Not applied (run stopped early): %d", counts[outcomeNotApplied])
+	}
+	if len(timedOut) > 0 {
+		logger.Infof("Timed out (--timeout-per-project): %d variables of %s", counts[outcomeTimedOut], strings.Join(timedOut, ", "))
+	}
+}
+
+// validateConflictStrategy checks the --on-conflict flag value
+func validateConflictStrategy(strategy string) error {
+	switch strategy {
+	case conflictSkip, conflictUpdate, conflictFail, conflictRename:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --on-conflict value %q (use skip, update, fail or rename)", strategy)
+	}
+}
+
+// validateUnmaskableStrategy checks the --unmaskable flag value
+func validateUnmaskableStrategy(strategy string) error {
+	switch strategy {
+	case unmaskableKeep, unmaskableUnmask, unmaskableSkip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --unmaskable value %q (use keep, unmask or skip)", strategy)
+	}
+}
+
+// variableID identifies a variable on a project or group by key and environment scope
+func variableID(key, scope string) string {
+	return key + "@" + scope
+}
+
+// variableScope returns the environment scope of a variable payload, defaulting to "*"
+func variableScope(variable map[string]interface{}) string {
+	if scope, ok := variable["environment_scope"].(string); ok && scope != "" {
+		return scope
+	}
+	return "*"
+}
+
+// resolveConflict decides what to do with a variable given the variables already
+// present on the destination. It returns the action and the key to write under,
+// which only differs from key for the rename strategy.
+func resolveConflict(strategy, key, scope string, existing map[string]bool) (string, string) {
+	if !existing[variableID(key, scope)] {
+		return actionCreate, key
+	}
+
+	if strategy != conflictRename {
+		return strategy, key
+	}
+
+	newKey := key + renameSuffix
+	for i := 2; existing[variableID(newKey, scope)]; i++ {
+		newKey = fmt.Sprintf("%s%s_%d", key, renameSuffix, i)
+	}
+	return conflictRename, newKey
+}
+
+// applyVariables creates variables under collectionURL (a project or group
+// variables endpoint on the instance at baseURL), resolving conflicts with
+// existing variables according to the --on-conflict strategy and recording
+// each outcome in the summary
+func applyVariables(ctx context.Context, target, baseURL, collectionURL, accessToken string, variables []interface{}) {
+	version, err := getInstanceVersion(baseURL, accessToken)
+	if err != nil {
+		warnOnce(fmt.Sprintf("Could not detect the GitLab version of %s, variable attributes will be sent as-is: %v", baseURL, err))
+	}
+
+	existing, err := fetchExistingVariableIDs(ctx, collectionURL, accessToken)
+	if err != nil {
+		utils.WithFields(targetFields(target)).Warnf("Could not list existing variables for %s, conflicts will not be detected: %v", target, err)
+		existing = make(map[string]bool)
+	}
+
+	if keyFilter != nil {
+		var selected []interface{}
+		for _, v := range variables {
+			if variable, ok := v.(map[string]interface{}); ok && !keyFilter.selects(variable) {
+				continue
+			}
+			selected = append(selected, v)
+		}
+		if skipped := len(variables) - len(selected); skipped > 0 {
+			utils.WithFields(targetFields(target)).Infof("Leaving out %d variables for %s not selected by --filter-key, --exclude-key or --environment-scope", skipped, target)
+		}
+		variables = selected
+	}
+
+	if sortKeys {
+		variables = sortVariables(variables)
+	}
+
+	for i, v := range variables {
+		// Stop launching new requests once interrupted or timed out, recording what is left
+		if ctx.Err() != nil {
+			outcome := outcomeNotApplied
+			if projectTimedOut(ctx) {
+				outcome = outcomeTimedOut
+			}
+			for _, remaining := range variables[i:] {
+				if variable, ok := remaining.(map[string]interface{}); ok {
+					key, _ := variable["key"].(string)
+					variablesSummary.add(variableResult{Target: target, Key: key, Scope: variableScope(variable), Outcome: outcome})
+				}
+			}
+			return
+		}
+
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			variablesSummary.add(variableResult{Target: target, Outcome: outcomeFailed, Detail: "variable is not in the correct format"})
+			continue
+		}
+
+		explicit, err := withVariableAttributes(variable)
+		if err != nil {
+			key, _ := variable["key"].(string)
+			variablesSummary.add(variableResult{Target: target, Key: key, Scope: variableScope(variable), Outcome: outcomeFailed, Detail: "variable is not in the correct format: " + err.Error()})
+			continue
+		}
+		variable = gateVariableFeatures(explicit, version)
+		key, _ := variable["key"].(string)
+		scope := variableScope(variable)
+		result := variableResult{Target: target, Key: key, Scope: scope}
+		logger := utils.WithFields(targetFields(target)).With(utils.Fields{"variable_key": key})
+
+		if variable["masked"] == true {
+			value, _ := variable["value"].(string)
+			if problem := utils.MaskProblem(value); problem != "" {
+				switch unmaskable {
+				case unmaskableSkip:
+					result.Outcome = outcomeSkipped
+					result.Detail = "masked but GitLab can't mask it: " + problem
+					logger.Infof("%s: variable %s (scope %s) for %s", result.Outcome, key, scope, target)
+					variablesSummary.add(result)
+					continue
+				case unmaskableKeep:
+					warning := "masked but GitLab can't mask it, the destination will likely reject it (see --unmaskable): " + problem
+					result.Warnings = append(result.Warnings, warning)
+					logger.Warnf("variable %s (scope %s) for %s: %s", key, scope, target, warning)
+				case unmaskableUnmask:
+					variable = copyVariable(variable)
+					variable["masked"] = false
+					warning := "masked was turned off because GitLab can't mask it: " + problem
+					result.Warnings = append(result.Warnings, warning)
+					logger.Warnf("variable %s (scope %s) for %s: %s", key, scope, target, warning)
+				}
+			}
+		}
+
+		action, writeKey := resolveConflict(onConflict, key, scope, existing)
+		// Protected variables are only exposed on protected branches and tags,
+		// which may not be configured on the destination yet
+		if unprotect && variable["protected"] == true && action != conflictSkip && action != conflictFail {
+			variable = copyVariable(variable)
+			variable["protected"] = false
+			result.Unprotected = true
+			result.Warnings = append(result.Warnings, unprotectWarning)
+			logger.Warnf("variable %s (scope %s) for %s: %s", key, scope, target, unprotectWarning)
+		}
+
+		// The interrupt is only checked between variables, a write that has
+		// started is left to finish so its outcome is known
+		requestCtx, cancelRequest := requestContext(ctx)
+		result.Outcome, result.Detail, err = writeVariable(requestCtx, collectionURL, accessToken, action, key, writeKey, scope, variable)
+		if errors.Is(err, errVariableExists) {
+			// The variable was created after the destination was listed, or the
+			// listing failed: resolve the conflict now, e.g. by updating it via PUT
+			existing[variableID(key, scope)] = true
+			action, writeKey = resolveConflict(onConflict, key, scope, existing)
+			// Nothing is written now, so protected wasn't turned off after all
+			if action == conflictSkip || action == conflictFail {
+				result.Unprotected = false
+				result.Warnings = slices.DeleteFunc(result.Warnings, func(warning string) bool { return warning == unprotectWarning })
+			}
+			result.Outcome, result.Detail, err = writeVariable(requestCtx, collectionURL, accessToken, action, key, writeKey, scope, variable)
+		}
+		cancelRequest()
+
+		if err != nil {
+			result.Outcome = outcomeFailed
+			if projectTimedOut(ctx) {
+				result.Outcome = outcomeTimedOut
+			}
+			result.Detail = err.Error()
+			// Every remaining request would fail the same way, stop the whole run
+			if errors.Is(err, utils.ErrUnauthorized) {
+				logger.Errorf("token expired or revoked while writing variable %s for %s — aborting", key, target)
+				abortRun(err)
+			}
+		} else if result.Outcome == outcomeCreated || result.Outcome == outcomeRenamed {
+			existing[variableID(writeKey, scope)] = true
+		}
+
+		switch {
+		case result.Outcome == outcomeFailed || result.Outcome == outcomeTimedOut:
+			logger.Errorf("%s: variable %s (scope %s) for %s: %s", result.Outcome, key, scope, target, result.Detail)
+		case variablesDryRun:
+			logger.Infof("[dry run] %s: variable %s (scope %s) for %s", result.Outcome, key, scope, target)
+		default:
+			logger.Infof("%s: variable %s (scope %s) for %s", result.Outcome, key, scope, target)
+		}
+		variablesSummary.add(result)
+	}
+}
+
+// writeVariable carries out the action chosen by resolveConflict for one
+// variable and returns its outcome and detail. Nothing is written with --dry-run.
+func writeVariable(ctx context.Context, collectionURL, accessToken, action, key, writeKey, scope string, variable map[string]interface{}) (string, string, error) {
+	switch action {
+	case conflictSkip:
+		return outcomeSkipped, "already exists", nil
+	case conflictFail:
+		return outcomeFailed, "already exists", nil
+	case conflictUpdate:
+		if variablesDryRun {
+			return outcomeUpdated, "", nil
+		}
+		return outcomeUpdated, "", putVariable(ctx, collectionURL, accessToken, key, scope, variable)
+	case conflictRename:
+		detail := "created as " + writeKey
+		if variablesDryRun {
+			return outcomeRenamed, detail, nil
+		}
+		renamed := copyVariable(variable)
+		renamed["key"] = writeKey
+		return outcomeRenamed, detail, postVariable(ctx, collectionURL, accessToken, renamed)
+	default:
+		if variablesDryRun {
+			return outcomeCreated, "", nil
+		}
+		return outcomeCreated, "", postVariable(ctx, collectionURL, accessToken, variable)
+	}
+}
+
+// selectVariable returns the variable with the given key from variables. scope
+// disambiguates a key defined for several environment scopes; when it is empty
+// the key must be unique.
+func selectVariable(variables []map[string]interface{}, key, scope string) ([]map[string]interface{}, error) {
+	var matches []map[string]interface{}
+	var scopes []string
+	for _, variable := range variables {
+		if variableKey, _ := variable["key"].(string); variableKey != key {
+			continue
+		}
+		if scope != "" && variableScope(variable) != scope {
+			scopes = append(scopes, variableScope(variable))
+			continue
+		}
+		matches = append(matches, variable)
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches, nil
+	case len(matches) > 1:
+		for _, match := range matches {
+			scopes = append(scopes, variableScope(match))
+		}
+		return nil, fmt.Errorf("variable %s exists for several environment scopes (%s), select one with --env-scope", key, strings.Join(scopes, ", "))
+	case len(scopes) > 0:
+		return nil, fmt.Errorf("variable %s does not exist for environment scope %s on the source (found: %s)", key, scope, strings.Join(scopes, ", "))
+	default:
+		return nil, fmt.Errorf("variable %s does not exist on the source", key)
+	}
+}
+
+// sortVariables returns a copy of variables ordered by key, then environment
+// scope, so runs create variables in a stable, reviewable order. Entries that
+// aren't variables keep their place at the end.
+func sortVariables(variables []interface{}) []interface{} {
+	sorted := make([]interface{}, len(variables))
+	copy(sorted, variables)
+	sortKey := func(v interface{}) (string, string, bool) {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			return "", "", false
+		}
+		key, _ := variable["key"].(string)
+		return key, variableScope(variable), true
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		keyI, scopeI, okI := sortKey(sorted[i])
+		keyJ, scopeJ, okJ := sortKey(sorted[j])
+		if okI != okJ {
+			return okI
+		}
+		if keyI != keyJ {
+			return keyI < keyJ
+		}
+		return scopeI < scopeJ
+	})
+	return sorted
+}
+
+// copyVariable returns a shallow copy of a variable payload so it can be
+// adjusted without changing the caller's data
+func copyVariable(variable map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(variable))
+	for k, v := range variable {
+		copied[k] = v
+	}
+	return copied
+}
+
+// postVariable creates a variable via POST. It returns errVariableExists when
+// GitLab rejects the variable because its key and scope are already taken.
+func postVariable(ctx context.Context, collectionURL, accessToken string, variable map[string]interface{}) error {
+	payload, err := json.Marshal(variable)
+	if err != nil {
+		return fmt.Errorf("error marshaling variable payload: %v", err)
+	}
+	resp, body, err := sendGitLabAPIRequest(ctx, "POST", collectionURL, accessToken, string(payload))
+	if err != nil {
+		return err
+	}
+	if (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusConflict) && strings.Contains(string(body), "has already been taken") {
+		return errVariableExists
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("API returned error status: %s", resp.Status)
+	}
+	return nil
+}
+
+// putVariable updates the variable with the given key and environment scope via PUT
+func putVariable(ctx context.Context, collectionURL, accessToken, key, scope string, variable map[string]interface{}) error {
+	payload, err := json.Marshal(variable)
+	if err != nil {
+		return fmt.Errorf("error marshaling variable payload: %v", err)
+	}
+	putURL := fmt.Sprintf("%s/%s?filter[environment_scope]=%s", collectionURL, url.PathEscape(key), url.QueryEscape(scope))
+	return makeGitLabAPIRequestContext(ctx, "PUT", putURL, accessToken, string(payload))
+}
+
+// fetchExistingVariableIDs lists the variables at collectionURL, keyed by variableID
+func fetchExistingVariableIDs(ctx context.Context, collectionURL, accessToken string) (map[string]bool, error) {
+	variables, err := fetchVariablesContext(ctx, collectionURL, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(variables))
+	for _, variable := range variables {
+		key, _ := variable["key"].(string)
+		existing[variableID(key, variableScope(variable))] = true
+	}
+	return existing, nil
+}
+
+// fetchVariables retrieves every page of variables at collectionURL
+func fetchVariables(collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	return fetchVariablesContext(context.Background(), collectionURL, accessToken)
+}
+
+// fetchVariablesContext is fetchVariables with the requests bound to ctx
+func fetchVariablesContext(ctx context.Context, collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	variables, err := newGitLabClient("", accessToken).GetPaginatedContext(ctx, collectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching variables: %v", err)
+	}
+	return variables, nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPostVariable(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    bool
+		wantExists bool
+	}{
+		{"created", http.StatusCreated, `{"key":"A"}`, false, false},
+		{"taken as 400", http.StatusBadRequest, `{"message":{"key":["(A) has already been taken"]}}`, true, true},
+		{"taken as 409", http.StatusConflict, `{"message":{"key":["(A) has already been taken"]}}`, true, true},
+		{"other 400", http.StatusBadRequest, `{"message":{"value":["is invalid"]}}`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/api/v4/projects/1/variables" {
+					t.Errorf("request %s %s, want POST /api/v4/projects/1/variables", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			err := postVariable(context.Background(), server.URL+"/api/v4/projects/1/variables", "dsttoken", map[string]interface{}{"key": "A", "value": "1"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("postVariable error = %v, want an error: %v", err, tt.wantErr)
+			}
+			if errors.Is(err, errVariableExists) != tt.wantExists {
+				t.Errorf("postVariable error = %v, want errVariableExists: %v", err, tt.wantExists)
+			}
+		})
+	}
+}
+
+func TestPutVariableFiltersByEnvironmentScope(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+		fmt.Fprint(w, `{}`)
+	}))
+	t.Cleanup(server.Close)
+
+	variable := map[string]interface{}{"key": "API KEY", "value": "2", "environment_scope": "review/*"}
+	if err := putVariable(context.Background(), server.URL+"/api/v4/projects/1/variables", "dsttoken", "API KEY", "review/*", variable); err != nil {
+		t.Fatalf("putVariable: %v", err)
+	}
+	if got.Method != http.MethodPut || got.URL.Path != "/api/v4/projects/1/variables/API KEY" {
+		t.Errorf("request %s %s, want PUT /api/v4/projects/1/variables/API KEY", got.Method, got.URL.Path)
+	}
+	if scope := got.URL.Query().Get("filter[environment_scope]"); scope != "review/*" {
+		t.Errorf("filter[environment_scope] = %q, want review/*", scope)
+	}
+	if !strings.Contains(body, `"value":"2"`) {
+		t.Errorf("body %s lacks the new value", body)
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	renameSuffix = "_MIGRATED"
+	existing := map[string]bool{
+		variableID("A", "*"):            true,
+		variableID("B", "*"):            true,
+		variableID("B_MIGRATED", "*"):   true,
+		variableID("A", "production"):   false,
+		variableID("C", "production"):   true,
+		variableID("C_MIGRATED", "*"):   true,
+		variableID("D_MIGRATED", "*"):   true,
+		variableID("D", "*"):            true,
+		variableID("D_MIGRATED_2", "*"): true,
+	}
+	tests := []struct {
+		strategy, key, scope string
+		wantAction, wantKey  string
+	}{
+		{conflictFail, "NEW", "*", actionCreate, "NEW"},
+		{conflictFail, "A", "production", actionCreate, "A"},
+		{conflictSkip, "A", "*", conflictSkip, "A"},
+		{conflictUpdate, "A", "*", conflictUpdate, "A"},
+		{conflictFail, "A", "*", conflictFail, "A"},
+		{conflictRename, "A", "*", conflictRename, "A_MIGRATED"},
+		{conflictRename, "B", "*", conflictRename, "B_MIGRATED_2"},
+		{conflictRename, "C", "production", conflictRename, "C_MIGRATED"},
+		{conflictRename, "D", "*", conflictRename, "D_MIGRATED_3"},
+	}
+	for _, tt := range tests {
+		action, key := resolveConflict(tt.strategy, tt.key, tt.scope, existing)
+		if action != tt.wantAction || key != tt.wantKey {
+			t.Errorf("resolveConflict(%s, %s@%s) = %s, %s, want %s, %s", tt.strategy, tt.key, tt.scope, action, key, tt.wantAction, tt.wantKey)
+		}
+	}
+}
+
+// variablesServer serves a project whose only variable is A (scope *),
+// recording every write. When listFails is set the variables can't be listed,
+// so taken keys are only found out when creating them.
+type variablesServer struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func newVariablesServer(t *testing.T, listFails bool) (*variablesServer, *httptest.Server) {
+	t.Helper()
+	vs := &variablesServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/version":
+			fmt.Fprint(w, `{"version":"17.0.0"}`)
+		case r.Method == http.MethodGet && listFails:
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `[{"key":"A","value":"old","environment_scope":"*"}]`)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			vs.mu.Lock()
+			vs.writes = append(vs.writes, r.Method+" "+r.URL.Path)
+			vs.mu.Unlock()
+			if r.Method == http.MethodPost && strings.Contains(string(body), `"key":"A"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"message":{"key":["(A) has already been taken"]}}`)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return vs, server
+}
+
+// setVariableFlags sets the flags applyVariables reads for one test
+func setVariableFlags(t *testing.T, strategy string, unprotectVariables bool) {
+	onConflict, renameSuffix, unprotect, sortKeys = strategy, "_MIGRATED", unprotectVariables, true
+	variablesSummary.results = nil
+	t.Cleanup(func() {
+		onConflict, renameSuffix, unprotect = conflictFail, "_MIGRATED", false
+		variablesSummary.results = nil
+	})
+}
+
+func TestApplyVariablesConflictStrategies(t *testing.T) {
+	tests := []struct {
+		strategy    string
+		wantOutcome string
+		wantWrites  []string
+	}{
+		{conflictSkip, outcomeSkipped, nil},
+		{conflictUpdate, outcomeUpdated, []string{"PUT /api/v4/projects/1/variables/A"}},
+		{conflictFail, outcomeFailed, nil},
+		{conflictRename, outcomeRenamed, []string{"POST /api/v4/projects/1/variables"}},
+	}
+	for _, tt := range tests {
+		for _, listFails := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/list fails %v", tt.strategy, listFails), func(t *testing.T) {
+				vs, server := newVariablesServer(t, listFails)
+				setVariableFlags(t, tt.strategy, false)
+
+				variables := []interface{}{map[string]interface{}{"key": "A", "value": "new"}}
+				applyVariables(context.Background(), "project 1", server.URL, server.URL+"/api/v4/projects/1/variables", "dsttoken", variables)
+
+				results := variablesSummary.results
+				if len(results) != 1 || results[0].Outcome != tt.wantOutcome {
+					t.Fatalf("results = %+v, want one %s", results, tt.wantOutcome)
+				}
+				writes := vs.writes
+				// The listing failed, so the taken key is found out by a rejected POST first
+				if listFails {
+					if len(writes) == 0 || writes[0] != "POST /api/v4/projects/1/variables" {
+						t.Fatalf("writes = %v, want a POST first", writes)
+					}
+					writes = writes[1:]
+				}
+				if strings.Join(writes, ", ") != strings.Join(tt.wantWrites, ", ") {
+					t.Errorf("writes = %v, want %v", writes, tt.wantWrites)
+				}
+				if tt.strategy == conflictRename && results[0].Detail != "created as A_MIGRATED" {
+					t.Errorf("detail = %q, want created as A_MIGRATED", results[0].Detail)
+				}
+			})
+		}
+	}
+}
+
+func TestApplyVariablesDropsUnprotectWarningWhenSkipped(t *testing.T) {
+	_, server := newVariablesServer(t, true)
+	setVariableFlags(t, conflictSkip, true)
+
+	variables := []interface{}{map[string]interface{}{"key": "A", "value": "new", "protected": true}}
+	applyVariables(context.Background(), "project 1", server.URL, server.URL+"/api/v4/projects/1/variables", "dsttoken", variables)
+
+	results := variablesSummary.results
+	if len(results) != 1 || results[0].Outcome != outcomeSkipped {
+		t.Fatalf("results = %+v, want one skipped", results)
+	}
+	if results[0].Unprotected || len(results[0].Warnings) != 0 {
+		t.Errorf("skipped variable is still reported as unprotected: %+v", results[0])
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+)
+
+// visibilityLevels are GitLab's visibility levels from most to least restrictive
+var visibilityLevels = []string{"private", "internal", "public"}
+
+var targetVisibility string
+
+// validateTargetVisibility checks the --target-visibility flag value
+func validateTargetVisibility(visibility string) error {
+	if visibility == "" || slices.Contains(visibilityLevels, visibility) {
+		return nil
+	}
+	return fmt.Errorf("unsupported --target-visibility value %q (use private, internal or public)", visibility)
+}
+
+// createVisibility returns the visibility to create a copy of a resource with:
+// --target-visibility when set, otherwise the source's visibility ("" lets
+// the destination apply its default)
+func createVisibility(sourceVisibility interface{}) string {
+	if targetVisibility != "" {
+		return targetVisibility
+	}
+	visibility, _ := sourceVisibility.(string)
+	if !slices.Contains(visibilityLevels, visibility) {
+		return ""
+	}
+	return visibility
+}
+
+// stricterVisibility returns the next more restrictive level than visibility,
+// or "" when it is already private
+func stricterVisibility(visibility string) string {
+	if i := slices.Index(visibilityLevels, visibility); i > 0 {
+		return visibilityLevels[i-1]
+	}
+	return ""
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"gitlab.com/linhtutkyawdev/gitlab-migrate/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// webhookFields are the project webhook attributes copied to the destination.
+// GitLab never returns the secret token, so it can't be among them.
+var webhookFields = []string{
+	"url",
+	"name",
+	"description",
+	"push_events",
+	"push_events_branch_filter",
+	"branch_filter_strategy",
+	"issues_events",
+	"confidential_issues_events",
+	"merge_requests_events",
+	"tag_push_events",
+	"note_events",
+	"confidential_note_events",
+	"job_events",
+	"pipeline_events",
+	"wiki_page_events",
+	"deployment_events",
+	"releases_events",
+	"feature_flag_events",
+	"enable_ssl_verification",
+}
+
+// webhookColumns are the default table columns for webhooks
+var webhookColumns = []string{"id", "url", "push_events", "merge_requests_events", "tag_push_events", "enable_ssl_verification"}
+
+var webhooksDryRun bool
+var webhookToken string
+
+// getWebhooksCmd lists the webhooks of a project
+var getWebhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Retrieve the webhooks of a GitLab project",
+	Long: `Retrieve the webhooks of a project (-p). GitLab doesn't return their secret
+tokens.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		if projectID == "" {
+			return fmt.Errorf("--project must be provided")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		baseURL, accessToken := config.SourceBaseURL, config.SourceAccessToken
+		if isDestination {
+			baseURL, accessToken = config.DestinationBaseURL, config.DestinationAccessToken
+		}
+		_, collectionURL := namespacedEndpoint(baseURL, "", projectID, "hooks")
+
+		webhooks, err := fetchWebhooks(collectionURL, accessToken)
+		if err != nil {
+			return notFoundError(err, "project", projectID)
+		}
+
+		if outputFormat == outputFormatTable {
+			return printTable(webhooks, webhookColumns, "")
+		}
+
+		if outputFile == "" {
+			if err := utils.EnsureDataDir(); err != nil {
+				return err
+			}
+			outputFile = utils.GenerateOutputFileName("webhooks", "", projectID, isDestination, false)
+		}
+
+		if err := saveOutputToFile(webhooks, outputFile); err != nil {
+			return fmt.Errorf("failed to save output: %v", err)
+		}
+		return nil
+	},
+}
+
+// migrateWebhooksCmd copies webhooks between projects
+var migrateWebhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Migrate webhooks between GitLab projects",
+	Long: `Copy the webhooks of a source project (-p) to a destination project (-P),
+keeping their URL, branch filter, SSL verification and every event toggle.
+
+GitLab never returns the secret token of a webhook, so it can't be copied.
+--token sets the given token on every created webhook; without it they are
+created without a token and their secrets must be set again on the
+destination by hand. The same goes for the values of URL variables, a
+warning names the webhooks that use them. Webhooks are listed by ID and host,
+not by URL, as their URLs may carry credentials.
+
+Webhooks whose URL already exists on the destination are skipped, so the
+command can be re-run safely. Use --dry-run to list what would be created
+without changing the destination.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if projectID == "" || destinationProjectID == "" {
+			return fmt.Errorf("provide a source project (-p) and a destination project (--destination-project)")
+		}
+
+		config, err := loadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %v", err)
+		}
+
+		if destinationProjectID, err = resolveProjectID(config.DestinationBaseURL, config.DestinationAccessToken, destinationProjectID); err != nil {
+			return err
+		}
+		if err := checkSameInstance(config, "project", projectID, destinationProjectID); err != nil {
+			return err
+		}
+
+		return migrateWebhooks(config)
+	},
+}
+
+// fetchWebhooks lists the webhooks of a project
+func fetchWebhooks(collectionURL, accessToken string) ([]map[string]interface{}, error) {
+	webhooks, err := newGitLabClient("", accessToken).GetPaginated(collectionURL)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// webhookPayload returns the attributes to create a copy of webhook with,
+// setting token as its secret when it isn't empty
+func webhookPayload(webhook map[string]interface{}, token string) map[string]interface{} {
+	payload := make(map[string]interface{})
+	for _, field := range webhookFields {
+		if value, ok := webhook[field]; ok && value != nil {
+			payload[field] = value
+		}
+	}
+	if token != "" {
+		payload["token"] = token
+	}
+	return payload
+}
+
+// webhookLabel names webhook in progress output by its source ID and host.
+// The full URL isn't printed, as it may carry credentials in its path or query.
+func webhookLabel(webhook map[string]interface{}) string {
+	label := fmt.Sprint(webhook["id"])
+	hookURL, _ := webhook["url"].(string)
+	if parsed, err := url.Parse(hookURL); err == nil && parsed.Host != "" {
+		label += " (" + parsed.Host + ")"
+	}
+	return label
+}
+
+// hasURLVariables reports whether webhook masks parts of its URL with URL
+// variables. GitLab only returns their keys, so the values can't be copied.
+func hasURLVariables(webhook map[string]interface{}) bool {
+	variables, _ := webhook["url_variables"].([]interface{})
+	return len(variables) > 0
+}
+
+// migrateWebhooks creates the source webhooks whose URL is missing on the destination
+func migrateWebhooks(config *utils.Config) error {
+	sourceTarget, sourceURL := namespacedEndpoint(config.SourceBaseURL, "", projectID, "hooks")
+	destinationTarget, destinationURL := namespacedEndpoint(config.DestinationBaseURL, "", destinationProjectID, "hooks")
+
+	sourceWebhooks, err := fetchWebhooks(sourceURL, config.SourceAccessToken)
+	if err != nil {
+		return fmt.Errorf("source %s: %w", sourceTarget, err)
+	}
+	destinationWebhooks, err := fetchWebhooks(destinationURL, config.DestinationAccessToken)
+	if err != nil {
+		return fmt.Errorf("destination %s: %w", destinationTarget, err)
+	}
+
+	existing := make(map[string]bool, len(destinationWebhooks))
+	for _, webhook := range destinationWebhooks {
+		hookURL, _ := webhook["url"].(string)
+		existing[hookURL] = true
+	}
+
+	log.Printf("Migrating %d webhooks from %s to %s", len(sourceWebhooks), sourceTarget, destinationTarget)
+	created, skipped, failed := 0, 0, 0
+	for _, webhook := range sourceWebhooks {
+		hookURL, _ := webhook["url"].(string)
+		label := webhookLabel(webhook)
+		if existing[hookURL] {
+			fmt.Printf("skipped: webhook %s (already exists)\n", label)
+			skipped++
+			continue
+		}
+		if hasURLVariables(webhook) {
+			log.Printf("Warning: webhook %s uses URL variables, whose values can't be read from the source. Set them on %s by hand", label, destinationTarget)
+		}
+
+		if webhooksDryRun {
+			fmt.Printf("[dry run] created: webhook %s\n", label)
+			created++
+			continue
+		}
+		if err := postGitLabJSON(destinationURL, config.DestinationAccessToken, webhookPayload(webhook, webhookToken), nil); err != nil {
+			if errors.Is(err, utils.ErrUnauthorized) {
+				return err
+			}
+			fmt.Printf("failed: webhook %s: %v\n", label, err)
+			failed++
+			continue
+		}
+		fmt.Printf("created: webhook %s\n", label)
+		existing[hookURL] = true
+		created++
+	}
+
+	if created > 0 && webhookToken == "" {
+		log.Printf("Warning: webhook secret tokens can't be read from the source, the created webhooks have none. Set them on %s by hand or re-create the webhooks with --token", destinationTarget)
+	}
+	if webhooksDryRun {
+		log.Printf("Dry run: %d webhooks would be created, %d skipped (already exist)", created, skipped)
+		return nil
+	}
+	log.Printf("Created %d webhooks, skipped %d (already exist), %d failed", created, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d webhooks could not be created", failed)
+	}
+	return nil
+}
+
+func init() {
+	getWebhooksCmd.Flags().StringVarP(&projectID, "project", "p", "", "The GitLab project ID to list the webhooks of")
+	getCmd.AddCommand(getWebhooksCmd)
+
+	migrateWebhooksCmd.Flags().StringVarP(&projectID, "project", "p", "", "Source project ID")
+	migrateWebhooksCmd.Flags().StringVarP(&destinationProjectID, "destination-project", "P", "", "Destination project ID or full path")
+	migrateWebhooksCmd.Flags().StringVar(&webhookToken, "token", "", "Secret token to set on every created webhook (the source tokens can't be read)")
+	migrateWebhooksCmd.Flags().BoolVar(&webhooksDryRun, "dry-run", false, "List the webhooks that would be created without changing the destination")
+	migrateCmd.AddCommand(migrateWebhooksCmd)
+}
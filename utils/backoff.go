@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes retry delays that grow exponentially with full jitter: each
+// delay is a random duration between zero and a ceiling that doubles on every
+// attempt, so clients that failed at the same time don't retry in lockstep.
+type Backoff struct {
+	// Base is the ceiling of the first retry
+	Base time.Duration
+	// Max caps the ceiling of later retries, 0 means no cap
+	Max time.Duration
+}
+
+// Ceiling returns the longest delay before retry attempt, counting from 1
+func (b Backoff) Ceiling(attempt int) time.Duration {
+	if b.Base <= 0 {
+		return 0
+	}
+	ceiling := b.Base
+	for i := 1; i < attempt && ceiling < math.MaxInt64/2 && (b.Max <= 0 || ceiling < b.Max); i++ {
+		ceiling *= 2
+	}
+	if b.Max > 0 && ceiling > b.Max {
+		ceiling = b.Max
+	}
+	return ceiling
+}
+
+// Delay returns a random delay in [0, Ceiling(attempt)] before retry attempt
+func (b Backoff) Delay(attempt int) time.Duration {
+	ceiling := b.Ceiling(attempt)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffCeiling(t *testing.T) {
+	backoff := Backoff{Base: time.Second, Max: 10 * time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoff.Ceiling(tt.attempt); got != tt.want {
+			t.Errorf("Ceiling(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+
+	if got := (Backoff{Base: time.Second}).Ceiling(1000); got <= 0 {
+		t.Errorf("uncapped Ceiling(1000) = %s, want it not to overflow", got)
+	}
+	if got := (Backoff{}).Delay(3); got != 0 {
+		t.Errorf("zero Backoff Delay = %s, want 0", got)
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	backoff := Backoff{Base: 100 * time.Millisecond, Max: 300 * time.Millisecond}
+	for attempt := 1; attempt <= 5; attempt++ {
+		ceiling := backoff.Ceiling(attempt)
+		seen := map[time.Duration]bool{}
+		for i := 0; i < 200; i++ {
+			delay := backoff.Delay(attempt)
+			if delay < 0 || delay > ceiling {
+				t.Fatalf("Delay(%d) = %s, want it within [0, %s]", attempt, delay, ceiling)
+			}
+			seen[delay] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("Delay(%d) returned the same value 200 times, want it jittered", attempt)
+		}
+	}
+}
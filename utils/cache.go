@@ -0,0 +1,51 @@
+package utils
+
+import "sync"
+
+// ListCache is an in-memory cache of GET list responses for the lifetime of a
+// single run. Entries are never invalidated, so only use it for collections
+// the run itself doesn't change, such as project lists.
+type ListCache struct {
+	mu      sync.Mutex
+	entries map[string][]map[string]interface{}
+}
+
+// NewListCache returns an empty ListCache
+func NewListCache() *ListCache {
+	return &ListCache{entries: make(map[string][]map[string]interface{})}
+}
+
+// Fetch returns the items cached for url and accessToken, calling fetch on a
+// miss. Failed fetches are not cached. The items are copied, so callers may add
+// fields to them without affecting later lookups.
+func (c *ListCache) Fetch(url, accessToken string, fetch func() ([]map[string]interface{}, error)) ([]map[string]interface{}, error) {
+	key := accessToken + " " + url
+
+	c.mu.Lock()
+	items, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		var err error
+		if items, err = fetch(); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.entries[key] = items
+		c.mu.Unlock()
+	}
+
+	return copyItems(items), nil
+}
+
+// copyItems returns a copy of items with each item's top-level fields copied
+func copyItems(items []map[string]interface{}) []map[string]interface{} {
+	copied := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		copied[i] = make(map[string]interface{}, len(item))
+		for k, v := range item {
+			copied[i][k] = v
+		}
+	}
+	return copied
+}
@@ -1,38 +1,163 @@
 package utils
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultDataDir is where output files are written unless data_dir or
+// --data-dir names another directory
+const DefaultDataDir = "data"
+
+// DataDir is the directory EnsureDataDir creates and GenerateOutputFileName
+// places files in
+var DataDir = DefaultDataDir
+
+// CurrentConfigVersion is the newest config format this build understands.
+// Files without a version field are version 0, the original flat format.
+const CurrentConfigVersion = 1
+
 // Config represents the application configuration loaded from YAML
 type Config struct {
+	Version                int    `yaml:"version,omitempty"`
 	SourceBaseURL          string `yaml:"source_base_url"`
 	SourceAccessToken      string `yaml:"source_access_token"`
 	DestinationBaseURL     string `yaml:"destination_base_url"`
 	DestinationAccessToken string `yaml:"destination_access_token"`
 	AuthUser               string `yaml:"auth_user"`
 	AuthPassword           string `yaml:"auth_password"`
+	// InsecureSkipTLSVerify turns off TLS certificate verification, e.g. for
+	// instances with self-signed certificates
+	InsecureSkipTLSVerify bool `yaml:"insecure_skip_tls_verify,omitempty"`
+	// DataDir is where output files are written, "data" when empty
+	DataDir string `yaml:"data_dir,omitempty"`
+	// Instances are named GitLab instances that can act as source or
+	// destination instead of the flat fields
+	Instances map[string]Instance `yaml:"instances,omitempty"`
+	// SourceInstance and DestinationInstance name the instances used by
+	// default, overridden by --source-instance and --dest-instance
+	SourceInstance      string `yaml:"source_instance,omitempty"`
+	DestinationInstance string `yaml:"destination_instance,omitempty"`
+}
+
+// Instance is a named GitLab instance of the instances: map
+type Instance struct {
+	BaseURL     string `yaml:"base_url"`
+	AccessToken string `yaml:"access_token"`
+}
+
+// Redacted returns a copy of the configuration with the access tokens and
+// password masked. Use it whenever the configuration is printed.
+func (c Config) Redacted() Config {
+	c.SourceAccessToken = RedactSecret(c.SourceAccessToken)
+	c.DestinationAccessToken = RedactSecret(c.DestinationAccessToken)
+	c.AuthPassword = RedactSecret(c.AuthPassword)
+	if c.Instances != nil {
+		instances := make(map[string]Instance, len(c.Instances))
+		for name, instance := range c.Instances {
+			instance.AccessToken = RedactSecret(instance.AccessToken)
+			instances[name] = instance
+		}
+		c.Instances = instances
+	}
+	return c
+}
+
+// String renders the configuration as YAML with its secrets masked, so
+// printing a Config with fmt or log never reveals them
+func (c Config) String() string {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<invalid config: %v>", err)
+	}
+	return string(data)
+}
+
+// SelectInstances makes the named instances the source and destination,
+// replacing the flat fields. An empty name falls back to source_instance or
+// destination_instance, and when that is empty too the flat fields are kept.
+func (c *Config) SelectInstances(source, destination string) error {
+	if source == "" {
+		source = c.SourceInstance
+	}
+	if destination == "" {
+		destination = c.DestinationInstance
+	}
+
+	if source != "" {
+		instance, err := c.instance(source)
+		if err != nil {
+			return fmt.Errorf("source instance: %w", err)
+		}
+		c.SourceBaseURL, c.SourceAccessToken = instance.BaseURL, instance.AccessToken
+		c.SourceInstance = source
+	}
+	if destination != "" {
+		instance, err := c.instance(destination)
+		if err != nil {
+			return fmt.Errorf("destination instance: %w", err)
+		}
+		c.DestinationBaseURL, c.DestinationAccessToken = instance.BaseURL, instance.AccessToken
+		c.DestinationInstance = destination
+	}
+	return nil
+}
+
+// instance looks up a named instance
+func (c *Config) instance(name string) (Instance, error) {
+	instance, ok := c.Instances[name]
+	if !ok {
+		names := make([]string, 0, len(c.Instances))
+		for known := range c.Instances {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return Instance{}, fmt.Errorf("%q is not defined, the config has no instances: map", name)
+		}
+		return Instance{}, fmt.Errorf("%q is not defined (known instances: %s)", name, strings.Join(names, ", "))
+	}
+	if strings.TrimSpace(instance.BaseURL) == "" || strings.TrimSpace(instance.AccessToken) == "" {
+		return Instance{}, fmt.Errorf("%q needs both base_url and access_token", name)
+	}
+	return instance, nil
+}
+
+// Reversed returns a copy of the configuration with the source and destination
+// instances swapped
+func (c Config) Reversed() Config {
+	c.SourceBaseURL, c.DestinationBaseURL = c.DestinationBaseURL, c.SourceBaseURL
+	c.SourceAccessToken, c.DestinationAccessToken = c.DestinationAccessToken, c.SourceAccessToken
+	return c
 }
 
 // Validate checks if all required fields are properly set and formatted
 func (c *Config) Validate() error {
+	hint := ""
+	if len(c.Instances) > 0 {
+		hint = " (or select one of the instances with source_instance/destination_instance or --source-instance/--dest-instance)"
+	}
 	if strings.TrimSpace(c.SourceBaseURL) == "" {
-		return fmt.Errorf("source_base_url is required")
+		return fmt.Errorf("source_base_url is required%s", hint)
 	}
 	if strings.TrimSpace(c.SourceAccessToken) == "" {
-		return fmt.Errorf("source_access_token is required")
+		return fmt.Errorf("source_access_token is required%s", hint)
 	}
 	if strings.TrimSpace(c.DestinationBaseURL) == "" {
-		return fmt.Errorf("destination_base_url is required")
+		return fmt.Errorf("destination_base_url is required%s", hint)
 	}
 	if strings.TrimSpace(c.DestinationAccessToken) == "" {
-		return fmt.Errorf("destination_access_token is required")
+		return fmt.Errorf("destination_access_token is required%s", hint)
 	}
 
 	// Validate URLs
@@ -61,8 +186,62 @@ func validateURL(urlStr string) error {
 	return nil
 }
 
+// SameBaseURL reports whether two base URLs point at the same GitLab instance,
+// ignoring letter case in the scheme and host, default ports and trailing slashes
+func SameBaseURL(a, b string) bool {
+	return normalizeBaseURL(a) == normalizeBaseURL(b)
+}
+
+func normalizeBaseURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return strings.TrimRight(rawURL, "/")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return scheme + "://" + host + strings.TrimRight(u.Path, "/")
+}
+
+// DefaultConfigPath returns where the config is kept unless --config names
+// another file: gitlab-migrate/config.yaml under $XDG_CONFIG_HOME, or under
+// ~/.config when that isn't set
+func DefaultConfigPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to find home directory: %v", err)
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "gitlab-migrate", "config.yaml"), nil
+}
+
+// LegacyConfigPath returns $HOME/config.yaml, the default config path of
+// earlier versions, which is still read when DefaultConfigPath doesn't exist
+func LegacyConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find home directory: %v", err)
+	}
+	return filepath.Join(homeDir, "config.yaml"), nil
+}
+
 // LoadConfig loads and validates configuration from the specified YAML file
 func LoadConfig(filePath string) (*Config, error) {
+	return LoadConfigWithInstances(filePath, "", "")
+}
+
+// LoadConfigWithInstances is LoadConfig with the named instances selected as
+// source and destination, see Config.SelectInstances
+func LoadConfigWithInstances(filePath, sourceInstance, destinationInstance string) (*Config, error) {
 	if strings.TrimSpace(filePath) == "" {
 		return nil, fmt.Errorf("config file path cannot be empty")
 	}
@@ -72,9 +251,17 @@ func LoadConfig(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal yaml: %w", err)
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.upgrade(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := config.SelectInstances(sourceInstance, destinationInstance); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	if err := config.Validate(); err != nil {
@@ -84,6 +271,86 @@ func LoadConfig(filePath string) (*Config, error) {
 	return &config, nil
 }
 
+// ReadConfigFile decodes a config file as it is written, without upgrading
+// it, selecting instances or validating it, so that it can be changed and
+// written back without flattening it into the resolved configuration
+func ReadConfigFile(filePath string) (*Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// unknownFieldError matches the errors a strict yaml decoder reports for keys
+// without a matching struct field
+var unknownFieldError = regexp.MustCompile(`^(line \d+): field (\S+) not found in type \S+$`)
+
+// decodeConfig parses a config file, rejecting keys Config doesn't have so a
+// misspelled one isn't silently ignored. A config of a newer version than this
+// build may have settings it doesn't know, so it is decoded leniently instead.
+func decodeConfig(data []byte) (Config, error) {
+	var config Config
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err == nil && header.Version > CurrentConfigVersion {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return config, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+		return config, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&config)
+	if err == nil || errors.Is(err, io.EOF) {
+		return config, nil
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		var unknown, other []string
+		for _, message := range typeErr.Errors {
+			if match := unknownFieldError.FindStringSubmatch(message); match != nil {
+				unknown = append(unknown, fmt.Sprintf("%s: unknown field %s in config", match[1], match[2]))
+			} else {
+				other = append(other, message)
+			}
+		}
+		if len(unknown) > 0 && len(other) == 0 {
+			return config, fmt.Errorf("invalid configuration: %s", strings.Join(unknown, "; "))
+		}
+	}
+	return config, fmt.Errorf("failed to unmarshal yaml: %w", err)
+}
+
+// upgrade brings a config loaded from an older format up to
+// CurrentConfigVersion, one version at a time. A newer version is loaded as
+// far as this build understands it, with a warning.
+func (c *Config) upgrade() error {
+	if c.Version < 0 {
+		return fmt.Errorf("version must not be negative, got %d", c.Version)
+	}
+	if c.Version > CurrentConfigVersion {
+		Warnf("config version %d is newer than this gitlab-migrate supports (%d), settings it doesn't know are ignored; consider upgrading", c.Version, CurrentConfigVersion)
+		return nil
+	}
+
+	for c.Version < CurrentConfigVersion {
+		switch c.Version {
+		case 0:
+			// Version 1 only introduces the version field, the flat fields are unchanged
+		}
+		c.Version++
+	}
+	return nil
+}
+
 // GenerateOutputFileName generates a consistent file name based on command parameters
 func GenerateOutputFileName(command string, groupID, projectID string, isDestination bool, isRecursive bool) string {
 	prefix := "s"
@@ -95,12 +362,41 @@ func GenerateOutputFileName(command string, groupID, projectID string, isDestina
 	switch command {
 	case "groups":
 		identifier = "groups"
+	case "all":
+		if groupID != "" {
+			identifier = fmt.Sprintf("all_g-%s", groupID)
+		} else {
+			identifier = "all"
+		}
 	case "projects":
 		if groupID != "" {
 			identifier = fmt.Sprintf("projects_g-%s", groupID)
 		} else {
 			identifier = "projects"
 		}
+	case "members":
+		if groupID != "" {
+			identifier = fmt.Sprintf("members_g-%s", groupID)
+		} else {
+			identifier = fmt.Sprintf("members_p-%s", projectID)
+		}
+		if isRecursive {
+			identifier += "_all"
+		}
+	case "approval-rules":
+		identifier = fmt.Sprintf("approval-rules_p-%s", projectID)
+	case "pipeline-schedules":
+		identifier = fmt.Sprintf("pipeline-schedules_p-%s", projectID)
+	case "protected-branches":
+		identifier = fmt.Sprintf("protected-branches_p-%s", projectID)
+	case "webhooks":
+		identifier = fmt.Sprintf("webhooks_p-%s", projectID)
+	case "milestones":
+		if groupID != "" {
+			identifier = fmt.Sprintf("milestones_g-%s", groupID)
+		} else {
+			identifier = fmt.Sprintf("milestones_p-%s", projectID)
+		}
 	case "variables":
 		if groupID != "" {
 			if isRecursive {
@@ -116,13 +412,12 @@ func GenerateOutputFileName(command string, groupID, projectID string, isDestina
 	}
 
 	fileName := fmt.Sprintf("%s-gitlab_get_%s.json", prefix, identifier)
-	return filepath.Join("data", fileName)
+	return filepath.Join(DataDir, fileName)
 }
 
-// EnsureDataDir ensures that the data directory exists
+// EnsureDataDir ensures that the data directory, DataDir, exists
 func EnsureDataDir() error {
-	dataDir := "data"
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := os.MkdirAll(DataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 	return nil
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes content to a config file in a temporary directory
+// and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const validConfig = `version: 1
+source_base_url: https://source.example.com
+source_access_token: srctoken
+destination_base_url: https://destination.example.com
+destination_access_token: dsttoken
+`
+
+func TestLoadConfigRejectsUnknownField(t *testing.T) {
+	path := writeConfigFile(t, strings.Replace(validConfig, "source_access_token", "source_acess_token", 1))
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("LoadConfig accepted a misspelled key")
+	}
+	if want := "line 3: unknown field source_acess_token in config"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestLoadConfigOfNewerVersionIgnoresUnknownFields(t *testing.T) {
+	path := writeConfigFile(t, strings.Replace(validConfig, "version: 1", "version: 99", 1)+"future_setting: true\n")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.SourceAccessToken != "srctoken" {
+		t.Errorf("source token = %q, want srctoken", config.SourceAccessToken)
+	}
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Run("XDG_CONFIG_HOME set", func(t *testing.T) {
+		configHome := filepath.Join(home, "xdg")
+		t.Setenv("XDG_CONFIG_HOME", configHome)
+		path, err := DefaultConfigPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(configHome, "gitlab-migrate", "config.yaml"); path != want {
+			t.Errorf("DefaultConfigPath() = %s, want %s", path, want)
+		}
+	})
+
+	t.Run("XDG_CONFIG_HOME unset", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		path, err := DefaultConfigPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(home, ".config", "gitlab-migrate", "config.yaml"); path != want {
+			t.Errorf("DefaultConfigPath() = %s, want %s", path, want)
+		}
+	})
+
+	legacy, err := LegacyConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "config.yaml"); legacy != want {
+		t.Errorf("LegacyConfigPath() = %s, want %s", legacy, want)
+	}
+}
+
+const instancesConfig = `version: 1
+instances:
+  old:
+    base_url: https://old.example.com
+    access_token: oldtoken
+  new:
+    base_url: https://new.example.com
+    access_token: newtoken
+  staging:
+    base_url: https://staging.example.com
+    access_token: stagingtoken
+source_instance: old
+destination_instance: new
+`
+
+func TestLoadConfigWithInstances(t *testing.T) {
+	path := writeConfigFile(t, instancesConfig)
+
+	tests := []struct {
+		name                 string
+		sourceFlag, destFlag string
+		wantSource, wantDest string
+		wantSourceToken      string
+		wantErr              string
+	}{
+		{"config defaults", "", "", "https://old.example.com", "https://new.example.com", "oldtoken", ""},
+		{"source flag", "staging", "", "https://staging.example.com", "https://new.example.com", "stagingtoken", ""},
+		{"both flags", "new", "old", "https://new.example.com", "https://old.example.com", "newtoken", ""},
+		{"unknown instance", "prod", "", "", "", "", `source instance: "prod" is not defined (known instances: new, old, staging)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadConfigWithInstances(path, tt.sourceFlag, tt.destFlag)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfigWithInstances: %v", err)
+			}
+			if config.SourceBaseURL != tt.wantSource || config.DestinationBaseURL != tt.wantDest {
+				t.Errorf("source, destination = %s, %s, want %s, %s", config.SourceBaseURL, config.DestinationBaseURL, tt.wantSource, tt.wantDest)
+			}
+			if config.SourceAccessToken != tt.wantSourceToken {
+				t.Errorf("source token = %s, want %s", config.SourceAccessToken, tt.wantSourceToken)
+			}
+		})
+	}
+}
+
+func TestSelectInstancesNeedsBaseURLAndToken(t *testing.T) {
+	config := Config{Instances: map[string]Instance{"old": {BaseURL: "https://old.example.com"}}}
+	err := config.SelectInstances("old", "")
+	if err == nil || !strings.Contains(err.Error(), `"old" needs both base_url and access_token`) {
+		t.Errorf("error = %v, want an error about the missing access_token", err)
+	}
+}
+
+func TestLoadConfigUpgradesToCurrentVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unversioned", strings.Replace(validConfig, "version: 1\n", "", 1)},
+		{"versioned", validConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadConfig(writeConfigFile(t, tt.content))
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if config.Version != CurrentConfigVersion {
+				t.Errorf("version = %d, want %d", config.Version, CurrentConfigVersion)
+			}
+			if config.DestinationAccessToken != "dsttoken" {
+				t.Errorf("destination token = %q, want dsttoken", config.DestinationAccessToken)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(writeConfigFile(t, strings.Replace(validConfig, "version: 1", "version: -1", 1))); err == nil {
+		t.Error("LoadConfig accepted a negative version")
+	}
+}
+
+func TestConfigStringMasksSecrets(t *testing.T) {
+	config := Config{
+		Version:                CurrentConfigVersion,
+		SourceBaseURL:          "https://source.example.com",
+		SourceAccessToken:      "glpat-source0000x7Qz",
+		DestinationBaseURL:     "https://destination.example.com",
+		DestinationAccessToken: "short",
+		AuthUser:               "mirror-bot",
+		AuthPassword:           "hunter2-password",
+		Instances:              map[string]Instance{"old": {BaseURL: "https://old.example.com", AccessToken: "glpat-instance00Ab9K"}},
+	}
+
+	// config show prints the configuration with fmt.Print
+	shown := config.String()
+	for _, secret := range []string{"glpat-source0000x7Qz", "short", "hunter2-password", "glpat-instance00Ab9K"} {
+		if strings.Contains(shown, secret) {
+			t.Errorf("output reveals %q:\n%s", secret, shown)
+		}
+	}
+	for _, want := range []string{"source_access_token: '****x7Qz'", "destination_access_token: '****'", "access_token: '****Ab9K'", "auth_user: mirror-bot"} {
+		if !strings.Contains(shown, want) {
+			t.Errorf("output lacks %q:\n%s", want, shown)
+		}
+	}
+	if config.SourceAccessToken != "glpat-source0000x7Qz" || config.Instances["old"].AccessToken != "glpat-instance00Ab9K" {
+		t.Error("String changed the secrets of the config itself")
+	}
+}
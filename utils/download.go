@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// progressInterval is how often download progress is redrawn
+const progressInterval = 500 * time.Millisecond
+
+// ProgressFunc is called while a download advances with the bytes written so far
+// and the expected total, which is -1 when the size is unknown. done is true on
+// the final call.
+type ProgressFunc func(written, total int64, done bool)
+
+// DownloadToFile streams body to filePath without buffering it in memory and
+// returns the number of bytes written. The data is written to a ".part" file
+// that is renamed once complete, so an interrupted download never looks finished.
+// progress may be nil.
+func DownloadToFile(body io.Reader, total int64, filePath string, progress ProgressFunc) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	partPath := filePath + ".part"
+	f, err := os.Create(partPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	var w io.Writer = f
+	var counter *progressWriter
+	if progress != nil {
+		counter = &progressWriter{total: total, progress: progress}
+		w = io.MultiWriter(f, counter)
+	}
+
+	written, err := io.Copy(w, body)
+	if counter != nil {
+		progress(written, total, true)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return written, fmt.Errorf("error writing %s: %w", filePath, err)
+	}
+
+	if err := os.Rename(partPath, filePath); err != nil {
+		return written, fmt.Errorf("failed to move download into place: %w", err)
+	}
+	return written, nil
+}
+
+// progressWriter counts the bytes written through it and reports them at most
+// once per progressInterval
+type progressWriter struct {
+	written    int64
+	total      int64
+	lastReport time.Time
+	progress   ProgressFunc
+}
+
+func (p *progressWriter) Write(data []byte) (int, error) {
+	p.written += int64(len(data))
+	if time.Since(p.lastReport) >= progressInterval {
+		p.lastReport = time.Now()
+		p.progress(p.written, p.total, false)
+	}
+	return len(data), nil
+}
+
+// NewProgressPrinter returns a ProgressFunc that redraws a single
+// "label: transferred / total (percent)" line on w
+func NewProgressPrinter(w io.Writer, label string) ProgressFunc {
+	return func(written, total int64, done bool) {
+		if total > 0 {
+			fmt.Fprintf(w, "\r%s: %s / %s (%d%%)", label, FormatBytes(written), FormatBytes(total), written*100/total)
+		} else {
+			fmt.Fprintf(w, "\r%s: %s", label, FormatBytes(written))
+		}
+		if done {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// FormatBytes formats a byte count using binary units, e.g. "1.5 GiB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
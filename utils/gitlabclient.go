@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GitLabClient sends authenticated requests to the API of one GitLab
+// instance. Requests go through DoWithRetry, so maintenance pages and rate
+// limits are retried and a rejected token is reported as ErrUnauthorized.
+type GitLabClient struct {
+	BaseURL     string
+	AccessToken string
+	HTTPClient  *http.Client
+	// PerPage is the page size of GetPaginated, DefaultPerPage when 0
+	PerPage int
+}
+
+// NewGitLabClient returns a client for the instance at baseURL
+func NewGitLabClient(baseURL, accessToken string, httpClient *http.Client) *GitLabClient {
+	return &GitLabClient{BaseURL: strings.TrimRight(baseURL, "/"), AccessToken: accessToken, HTTPClient: httpClient}
+}
+
+// APIError is returned for a response with an error status. A 404 matches
+// ErrNotFound with errors.Is.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("API returned error status: %s", e.Status)
+	}
+	return fmt.Sprintf("API returned error status: %s: %s", e.Status, e.Body)
+}
+
+// Unwrap lets errors.Is(err, ErrNotFound) match a 404
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// URL returns the URL of an API path such as "projects/42/variables". Full
+// URLs, e.g. built by the caller with query parameters, are returned as-is.
+func (c *GitLabClient) URL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return c.BaseURL + "/api/v4/" + strings.TrimPrefix(path, "/")
+}
+
+// Do sends a request and returns the response with its body already read,
+// whatever its status. body is sent as JSON unless it is nil.
+func (c *GitLabClient) Do(ctx context.Context, method, path string, body interface{}) (*http.Response, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error marshaling payload: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.URL(path), reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.AccessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := DoWithRetry(c.HTTPClient, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response: %v", err)
+	}
+	return resp, data, nil
+}
+
+// Request sends a request and decodes the JSON response into out, unless out
+// is nil. A status outside 2xx is returned as an *APIError.
+func (c *GitLabClient) Request(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, data, err := c.Do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{Method: method, URL: resp.Request.URL.Redacted(), StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(data))}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error parsing response: %v", err)
+	}
+	return nil
+}
+
+// Get fetches path and decodes the JSON response into out
+func (c *GitLabClient) Get(path string, out interface{}) error {
+	return c.Request(context.Background(), http.MethodGet, path, nil, out)
+}
+
+// GetPaginated fetches every page of the list at path, see Paginate
+func (c *GitLabClient) GetPaginated(path string) ([]map[string]interface{}, error) {
+	return c.GetPaginatedContext(context.Background(), path)
+}
+
+// GetPaginatedContext is GetPaginated with the requests bound to ctx
+func (c *GitLabClient) GetPaginatedContext(ctx context.Context, path string) ([]map[string]interface{}, error) {
+	perPage := c.PerPage
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+	return PaginateContext(ctx, c.HTTPClient, c.URL(path), c.AccessToken, perPage)
+}
+
+// Post sends body as JSON to path and decodes the response, usually the
+// created resource, into out unless it is nil
+func (c *GitLabClient) Post(path string, body, out interface{}) error {
+	return c.Request(context.Background(), http.MethodPost, path, body, out)
+}
+
+// Put sends body as JSON to path and decodes the response into out unless it
+// is nil
+func (c *GitLabClient) Put(path string, body, out interface{}) error {
+	return c.Request(context.Background(), http.MethodPut, path, body, out)
+}
+
+// Delete deletes the resource at path
+func (c *GitLabClient) Delete(path string) error {
+	return c.Request(context.Background(), http.MethodDelete, path, nil, nil)
+}
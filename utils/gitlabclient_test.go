@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestGitLabClient(t *testing.T, handler http.HandlerFunc) *GitLabClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewGitLabClient(server.URL+"/", "secret", server.Client())
+}
+
+func TestGitLabClientURL(t *testing.T) {
+	client := NewGitLabClient("https://gitlab.example.com/", "", nil)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"projects/42/variables", "https://gitlab.example.com/api/v4/projects/42/variables"},
+		{"/groups", "https://gitlab.example.com/api/v4/groups"},
+		{"https://other.example.com/api/v4/user", "https://other.example.com/api/v4/user"},
+	}
+	for _, tt := range tests {
+		if got := client.URL(tt.path); got != tt.want {
+			t.Errorf("URL(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGitLabClientGetPaginated(t *testing.T) {
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/projects/1/variables" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "secret" {
+			t.Errorf("PRIVATE-TOKEN = %q, want secret", got)
+		}
+		if got := r.URL.Query().Get("per_page"); got != "2" {
+			t.Errorf("per_page = %q, want 2", got)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		switch page {
+		case 1:
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"key":"A"},{"key":"B"}]`)
+		case 2:
+			w.Header().Set("X-Next-Page", "")
+			fmt.Fprint(w, `[{"key":"C"}]`)
+		default:
+			t.Errorf("unexpected page %d", page)
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	client.PerPage = 2
+
+	items, err := client.GetPaginated("projects/1/variables")
+	if err != nil {
+		t.Fatalf("GetPaginated: %v", err)
+	}
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item["key"].(string))
+	}
+	if fmt.Sprint(keys) != "[A B C]" {
+		t.Errorf("keys = %v, want [A B C]", keys)
+	}
+}
+
+func TestGitLabClientUnauthorized(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"401 Unauthorized"}`)
+	})
+
+	var out map[string]interface{}
+	if err := client.Get("user", &out); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Get error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := client.GetPaginated("projects"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("GetPaginated error = %v, want ErrUnauthorized", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("sent %d requests, want 2: a 401 must not be retried", got)
+	}
+}
+
+func TestGitLabClientNotFound(t *testing.T) {
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+	})
+
+	err := client.Get("projects/404", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("error = %v, want an *APIError with status 404", err)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want it to match ErrNotFound", err)
+	}
+}
+
+func TestGitLabClientRetriesRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", r.Header.Get("Content-Type"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":7}`)
+	})
+
+	var created struct {
+		ID int `json:"id"`
+	}
+	if err := client.Post("projects/1/hooks", map[string]string{"url": "https://example.com"}, &created); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	if created.ID != 7 {
+		t.Errorf("id = %d, want 7", created.ID)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("sent %d requests, want 3", got)
+	}
+}
+
+func TestGitLabClientRateLimitGivesUp(t *testing.T) {
+	previous := RateLimitRetries
+	RateLimitRetries = 1
+	t.Cleanup(func() { RateLimitRetries = previous })
+
+	var requests atomic.Int32
+	client := newTestGitLabClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	err := client.Delete("projects/1/hooks/2")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("error = %v, want an *APIError with status 429", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("sent %d requests, want 2 (one retry)", got)
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// secretHeaderHints are substrings of header names whose values should never be printed
+var secretHeaderHints = []string{"auth", "token", "key", "secret", "password", "cookie", "session", "signature"}
+
+// ParseHeader parses a "Name: Value" header argument
+func ParseHeader(header string) (string, string, error) {
+	name, value, found := strings.Cut(header, ":")
+	if !found {
+		return "", "", errors.New("invalid header, expected \"Name: Value\"")
+	}
+
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if name == "" {
+		return "", "", errors.New("invalid header, the name is empty")
+	}
+	for _, r := range name {
+		// Header names are RFC 7230 tokens
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", r) {
+			return "", "", fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return "", "", fmt.Errorf("invalid value for header %s, it must be a single line", name)
+	}
+	return http.CanonicalHeaderKey(name), value, nil
+}
+
+// IsSecretHeader reports whether values of the named header look like credentials
+func IsSecretHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, hint := range secretHeaderHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeader formats a header for display, hiding the value if it looks like a secret
+func RedactHeader(name, value string) string {
+	if IsSecretHeader(name) {
+		value = redactedPassword
+	}
+	return name + ": " + value
+}
+
+// headerTransport adds fixed headers to every request that doesn't already set them
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		if req.Header.Get(name) != "" {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
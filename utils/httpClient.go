@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/tls"
+	"net"
 	"net/http"
 	"time"
 )
@@ -25,6 +26,17 @@ type HTTPClientConfig struct {
 	MaxIdleConns int
 	// IdleConnTimeout is the maximum amount of time an idle connection will be kept in the pool
 	IdleConnTimeout time.Duration
+	// MaxConnsPerHost limits the total connections per host, 0 means no limit
+	MaxConnsPerHost int
+	// DialTimeout limits how long establishing a connection may take, 0 means
+	// only Timeout applies
+	DialTimeout time.Duration
+	// ResponseHeaderTimeout limits the wait for response headers once the request
+	// is sent, without limiting how long the body takes to read. 0 means only
+	// Timeout applies.
+	ResponseHeaderTimeout time.Duration
+	// Headers are added to every request, e.g. for an auth gateway in front of GitLab
+	Headers http.Header
 }
 
 // NewDefaultConfig returns a new HTTPClientConfig with default values
@@ -47,12 +59,22 @@ func CreateHTTPClient(config *HTTPClientConfig) *http.Client {
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: config.SkipTLSVerification,
 		},
-		MaxIdleConns:    config.MaxIdleConns,
-		IdleConnTimeout: config.IdleConnTimeout,
+		MaxIdleConns:          config.MaxIdleConns,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+	}
+	if config.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: config.DialTimeout}).DialContext
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if len(config.Headers) > 0 {
+		roundTripper = &headerTransport{base: transport, headers: config.Headers}
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: roundTripper,
 		Timeout:   config.Timeout,
 	}
 }
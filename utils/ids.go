@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseIDList parses a list of GitLab IDs given as "12,34,56", or as "@file"
+// naming a file with IDs separated by commas or whitespace, where lines
+// starting with # are comments. Every ID must be a positive integer. Duplicates
+// are dropped, keeping the first occurrence.
+func ParseIDList(value string) ([]string, error) {
+	source := "the ID list"
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ID list: %w", err)
+		}
+		var lines []string
+		for _, line := range strings.Split(string(data), "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+				lines = append(lines, line)
+			}
+		}
+		value = strings.Join(lines, "\n")
+		source = path
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, field := range fields {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ID %q in %s, IDs must be positive integers", field, source)
+		}
+		normalized := strconv.FormatInt(id, 10)
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		ids = append(ids, normalized)
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s contains no IDs", source)
+	}
+	return ids, nil
+}
@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log line
+type Level int
+
+// Log levels, from the most to the least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the name of the level as written in JSON logs
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Log formats accepted by SetLogFormat
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logTimeFormat matches the timestamp of the standard log flags
+const logTimeFormat = "2006/01/02 15:04:05 "
+
+// Fields are the structured context of a log line, such as the project it is
+// about. They are only written in JSON logs; text messages name their
+// subject themselves.
+type Fields map[string]interface{}
+
+var (
+	logSettingsMu sync.RWMutex
+	logLevel      = LevelInfo
+	logFormat     = LogFormatText
+)
+
+// SetLogLevel sets the lowest level that is written, e.g. LevelWarn to only
+// show warnings and errors
+func SetLogLevel(level Level) {
+	logSettingsMu.Lock()
+	defer logSettingsMu.Unlock()
+	logLevel = level
+}
+
+// LogLevel returns the lowest level that is written
+func LogLevel() Level {
+	logSettingsMu.RLock()
+	defer logSettingsMu.RUnlock()
+	return logLevel
+}
+
+// SetLogFormat sets how log lines are written, LogFormatText or LogFormatJSON
+func SetLogFormat(format string) error {
+	if format != LogFormatText && format != LogFormatJSON {
+		return fmt.Errorf("invalid log format %q, use %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+	logSettingsMu.Lock()
+	defer logSettingsMu.Unlock()
+	logFormat = format
+	return nil
+}
+
+func currentLogFormat() string {
+	logSettingsMu.RLock()
+	defer logSettingsMu.RUnlock()
+	return logFormat
+}
+
+// LineLevel returns the level of a log line. Messages carry their level as a
+// prefix, "Error"/"Failed", "Warning" or "DEBUG", like the ones written by
+// Errorf, Warnf and Debugf; anything else is informational. A leading
+// "[scope] " is skipped.
+func LineLevel(line string) Level {
+	_, line = splitScope(line)
+	switch {
+	case strings.HasPrefix(line, "Error"), strings.HasPrefix(line, "ERROR"), strings.HasPrefix(line, "Failed"):
+		return LevelError
+	case strings.HasPrefix(line, "Warning"), strings.HasPrefix(line, "WARNING"):
+		return LevelWarn
+	case strings.HasPrefix(line, "DEBUG"):
+		return LevelDebug
+	default:
+		return LevelInfo
+	}
+}
+
+// splitScope splits a leading "[scope] " off line
+func splitScope(line string) (scope, rest string) {
+	if strings.HasPrefix(line, "[") {
+		if end := strings.Index(line, "] "); end >= 0 {
+			return line[1:end], line[end+2:]
+		}
+	}
+	return "", line
+}
+
+// levelPrefixes are the prefixes Debugf, Warnf and Errorf give to messages.
+// JSON logs carry the level in its own key and drop them from the message.
+var levelPrefixes = []string{"DEBUG: ", "Warning: ", "Error: "}
+
+// logEntry is one log message
+type logEntry struct {
+	time    time.Time
+	level   Level
+	message string
+	fields  Fields
+}
+
+// render formats the entry in the log format, ending with a newline
+func (e logEntry) render(format string) []byte {
+	if format != LogFormatJSON {
+		line := e.time.Format(logTimeFormat)
+		if scope, ok := e.fields["scope"]; ok {
+			line += fmt.Sprintf("[%v] ", scope)
+		}
+		return []byte(line + e.message + "\n")
+	}
+
+	message := e.message
+	for _, prefix := range levelPrefixes {
+		message = strings.TrimPrefix(message, prefix)
+	}
+	record := make(map[string]interface{}, len(e.fields)+3)
+	for key, value := range e.fields {
+		record[key] = value
+	}
+	record["time"] = e.time.Format(time.RFC3339)
+	record["level"] = e.level.String()
+	record["message"] = message
+	data, err := json.Marshal(record)
+	if err != nil {
+		data, _ = json.Marshal(map[string]interface{}{"time": record["time"], "level": record["level"], "message": message})
+	}
+	return append(data, '\n')
+}
+
+// logWriter writes entries to stderr, or the writer given to InstallLogger
+type logWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+var stdLog = &logWriter{out: os.Stderr}
+
+// write renders the entries at or above the log level in a single write, so
+// entries written together stay together
+func (w *logWriter) write(entries ...logEntry) error {
+	threshold, format := LogLevel(), currentLogFormat()
+	var kept bytes.Buffer
+	for _, entry := range entries {
+		if entry.level >= threshold {
+			kept.Write(entry.render(format))
+		}
+	}
+	if kept.Len() == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.out.Write(kept.Bytes())
+	return err
+}
+
+// Write takes the output of the standard logger, one message per write. The
+// lines of a multi-line message belong to its first line, except in the
+// block flushed by a buffered logger, where every "[scope] " line starts a
+// message of its own. The level of a message is its LineLevel.
+func (w *logWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	var entries []logEntry
+	for i, line := range strings.SplitAfter(string(p), "\n") {
+		if line == "" {
+			continue
+		}
+		if i > 0 && !strings.HasPrefix(line, "[") {
+			entries[len(entries)-1].message += "\n" + strings.TrimSuffix(line, "\n")
+			continue
+		}
+		line = strings.TrimSuffix(line, "\n")
+		entry := logEntry{time: now, level: LineLevel(line), message: line}
+		if scope, rest := splitScope(line); scope != "" {
+			entry.message = rest
+			entry.fields = Fields{"scope": scope}
+		}
+		entries = append(entries, entry)
+	}
+	if err := w.write(entries...); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// InstallLogger sends every log line, including those of the standard
+// logger, through the level filter and the log format to out
+func InstallLogger(out io.Writer) {
+	stdLog.mu.Lock()
+	stdLog.out = out
+	stdLog.mu.Unlock()
+	// The entries are timestamped when they are rendered
+	log.SetFlags(0)
+	log.SetOutput(stdLog)
+}
+
+// Logger writes messages with a set of fields. A buffered logger keeps its
+// messages until Flush and then writes them as one block, so the output of
+// concurrent workers isn't interleaved.
+type Logger struct {
+	fields Fields
+	buffer *logBuffer
+}
+
+type logBuffer struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+var defaultLogger = &Logger{}
+
+// NewLogger returns a logger that adds fields to every message. Messages are
+// written right away unless buffered is set.
+func NewLogger(fields Fields, buffered bool) *Logger {
+	l := &Logger{fields: fields}
+	if buffered {
+		l.buffer = &logBuffer{}
+	}
+	return l
+}
+
+// WithFields returns an unbuffered logger that adds fields to every message
+func WithFields(fields Fields) *Logger {
+	return NewLogger(fields, false)
+}
+
+// With returns a logger with fields added to those of l, sharing its buffer
+func (l *Logger) With(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Logger{fields: merged, buffer: l.buffer}
+}
+
+func (l *Logger) log(level Level, message string) {
+	entry := logEntry{time: time.Now(), level: level, message: message, fields: l.fields}
+	if l.buffer == nil {
+		stdLog.write(entry)
+		return
+	}
+	l.buffer.mu.Lock()
+	defer l.buffer.mu.Unlock()
+	l.buffer.entries = append(l.buffer.entries, entry)
+}
+
+// Printf logs a message whose level is given by its prefix, see LineLevel
+func (l *Logger) Printf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	l.log(LineLevel(message), message)
+}
+
+// Debugf logs a message only shown with --verbose
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if LogLevel() > LevelDebug {
+		return
+	}
+	l.log(LevelDebug, "DEBUG: "+fmt.Sprintf(format, args...))
+}
+
+// Infof logs a progress message, hidden by --quiet
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.log(LevelInfo, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a message prefixed with "Warning: "
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.log(LevelWarn, "Warning: "+fmt.Sprintf(format, args...))
+}
+
+// Errorf logs a message prefixed with "Error: "
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.log(LevelError, "Error: "+fmt.Sprintf(format, args...))
+}
+
+// Flush writes the buffered messages in a single write. It does nothing for
+// an unbuffered logger.
+func (l *Logger) Flush() {
+	if l.buffer == nil {
+		return
+	}
+	l.buffer.mu.Lock()
+	entries := l.buffer.entries
+	l.buffer.entries = nil
+	l.buffer.mu.Unlock()
+	stdLog.write(entries...)
+}
+
+// Debugf logs a message only shown with --verbose
+func Debugf(format string, args ...interface{}) {
+	defaultLogger.Debugf(format, args...)
+}
+
+// Infof logs a progress message, hidden by --quiet
+func Infof(format string, args ...interface{}) {
+	defaultLogger.Infof(format, args...)
+}
+
+// Warnf logs a message prefixed with "Warning: "
+func Warnf(format string, args ...interface{}) {
+	defaultLogger.Warnf(format, args...)
+}
+
+// Errorf logs a message prefixed with "Error: "
+func Errorf(format string, args ...interface{}) {
+	defaultLogger.Errorf(format, args...)
+}
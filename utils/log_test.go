@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog sends log output to a buffer at level until the test ends
+func captureLog(t *testing.T, level Level) *bytes.Buffer {
+	t.Helper()
+	var out bytes.Buffer
+	InstallLogger(&out)
+	SetLogLevel(level)
+	t.Cleanup(func() {
+		InstallLogger(os.Stderr)
+		SetLogLevel(LevelInfo)
+		SetLogFormat(LogFormatText)
+	})
+	return &out
+}
+
+func TestQuietSuppressesInfoButNotErrors(t *testing.T) {
+	// --quiet sets the level to LevelWarn
+	out := captureLog(t, LevelWarn)
+
+	Infof("created: variable A (scope *) for project 1")
+	log.Printf("Migrating variables from project 1 to project 2")
+	Warnf("variable B (scope *) for project 1: protected was turned off (--unprotect)")
+	Errorf("failed: variable C (scope *) for project 1: 400 Bad Request")
+	log.Printf("Error: Project name not found for project 3")
+
+	got := out.String()
+	for _, hidden := range []string{"created: variable A", "Migrating variables"} {
+		if strings.Contains(got, hidden) {
+			t.Errorf("output contains the info message %q:\n%s", hidden, got)
+		}
+	}
+	for _, shown := range []string{"Warning: variable B", "Error: failed: variable C", "Error: Project name not found"} {
+		if !strings.Contains(got, shown) {
+			t.Errorf("output lacks %q:\n%s", shown, got)
+		}
+	}
+}
+
+func TestJSONLogKeys(t *testing.T) {
+	out := captureLog(t, LevelInfo)
+	if err := SetLogFormat(LogFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	WithFields(Fields{"project_id": "12", "scope": "project 12"}).With(Fields{"variable_key": "TOKEN"}).Warnf("protected was turned off")
+	log.Printf("Error: Project name not found for project 3")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2:\n%s", len(lines), out)
+	}
+	tests := []struct {
+		line int
+		want map[string]interface{}
+	}{
+		{0, map[string]interface{}{"level": "warn", "message": "protected was turned off", "project_id": "12", "scope": "project 12", "variable_key": "TOKEN"}},
+		{1, map[string]interface{}{"level": "error", "message": "Project name not found for project 3"}},
+	}
+	for _, tt := range tests {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[tt.line]), &record); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", tt.line, err, lines[tt.line])
+		}
+		if _, err := time.Parse(time.RFC3339, fmt.Sprint(record["time"])); err != nil {
+			t.Errorf("line %d time %v is not RFC 3339: %v", tt.line, record["time"], err)
+		}
+		delete(record, "time")
+		if !reflect.DeepEqual(record, tt.want) {
+			t.Errorf("line %d = %v, want %v", tt.line, record, tt.want)
+		}
+	}
+}
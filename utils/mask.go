@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MinMaskedLength is the shortest value GitLab accepts for a masked variable
+const MinMaskedLength = 8
+
+// maskableChars are the characters GitLab allows in masked variable values:
+// the Base64 alphabet (RFC4648, including the URL-safe variant) plus @ : . ~
+const maskableChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=-_@:.~"
+
+// MaskProblem returns why GitLab would refuse to mask value, or "" if it can be masked
+func MaskProblem(value string) string {
+	if strings.ContainsAny(value, "\r\n") {
+		return "value spans multiple lines"
+	}
+	if len(value) < MinMaskedLength {
+		return fmt.Sprintf("value is shorter than %d characters", MinMaskedLength)
+	}
+	for _, r := range value {
+		if !strings.ContainsRune(maskableChars, r) {
+			return fmt.Sprintf("value contains the unsupported character %q", r)
+		}
+	}
+	return ""
+}
+
+// IsMaskable reports whether GitLab can mask value
+func IsMaskable(value string) bool {
+	return MaskProblem(value) == ""
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when GitLab answers 404, e.g. for a group or project
+// that doesn't exist or isn't visible to the token
+var ErrNotFound = errors.New("not found")
+
+// DefaultPerPage is the page size sent with every list request. GitLab
+// silently returns only 20 items when per_page is omitted.
+const DefaultPerPage = 100
+
+// MaxPerPage is the largest page size GitLab accepts
+const MaxPerPage = 100
+
+// Paginate fetches every page of a GitLab list endpoint and returns the combined items.
+// The per_page and page query parameters are set on rawURL, overriding any present.
+func Paginate(client *http.Client, rawURL, accessToken string, perPage int) ([]map[string]interface{}, error) {
+	return paginate(context.Background(), client, rawURL, accessToken, perPage, false)
+}
+
+// PaginateContext is Paginate with the requests bound to ctx, so they are
+// abandoned once ctx is cancelled or its deadline passes
+func PaginateContext(ctx context.Context, client *http.Client, rawURL, accessToken string, perPage int) ([]map[string]interface{}, error) {
+	return paginate(ctx, client, rawURL, accessToken, perPage, false)
+}
+
+// PaginateKeyset fetches every page of a GitLab list endpoint using keyset
+// pagination, following the Link rel="next" cursor of each response. It is much
+// faster than offset pagination on very large collections, but only some
+// endpoints support it. Results are ordered by id unless rawURL sets order_by.
+func PaginateKeyset(client *http.Client, rawURL, accessToken string, perPage int) ([]map[string]interface{}, error) {
+	return paginate(context.Background(), client, rawURL, accessToken, perPage, true)
+}
+
+// CountItems returns the size of a GitLab collection from the X-Total header.
+// GitLab omits the header for collections over 10,000 items, in which case
+// known is false.
+func CountItems(client *http.Client, rawURL, accessToken string) (total int, known bool, err error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	query := pageURL.Query()
+	query.Set("per_page", "1")
+	pageURL.RawQuery = query.Encode()
+
+	_, header, err := fetchPage(context.Background(), client, pageURL.String(), accessToken)
+	if err != nil {
+		return 0, false, err
+	}
+
+	totalHeader := header.Get("X-Total")
+	if totalHeader == "" {
+		return 0, false, nil
+	}
+	total, err = strconv.Atoi(totalHeader)
+	if err != nil {
+		return 0, false, nil
+	}
+	return total, true, nil
+}
+
+func paginate(ctx context.Context, client *http.Client, rawURL, accessToken string, perPage int, keyset bool) ([]map[string]interface{}, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+
+	query := pageURL.Query()
+	query.Set("per_page", strconv.Itoa(perPage))
+	if keyset {
+		query.Set("pagination", "keyset")
+		if query.Get("order_by") == "" {
+			query.Set("order_by", "id")
+		}
+		if query.Get("sort") == "" {
+			query.Set("sort", "asc")
+		}
+	}
+
+	allItems := []map[string]interface{}{}
+	page := 1
+	for {
+		nextURL := *pageURL
+		if !keyset {
+			query.Set("page", strconv.Itoa(page))
+		}
+		nextURL.RawQuery = query.Encode()
+
+		items, header, err := fetchPage(ctx, client, nextURL.String(), accessToken)
+		if err != nil {
+			return nil, err
+		}
+		allItems = append(allItems, items...)
+
+		if keyset {
+			// The cursor is only available through the Link header; its absence marks the last page
+			next := nextLink(header.Get("Link"))
+			if next == "" || len(items) == 0 {
+				break
+			}
+			if pageURL, err = url.Parse(next); err != nil {
+				return nil, fmt.Errorf("invalid next page link %s: %w", next, err)
+			}
+			query = pageURL.Query()
+			continue
+		}
+
+		// GitLab sends an empty X-Next-Page on the last page; when the header is
+		// missing, a short page means there is nothing more to fetch
+		nextPage, hasNextHeader := header["X-Next-Page"]
+		if hasNextHeader && (len(nextPage) == 0 || nextPage[0] == "") {
+			break
+		}
+		if !hasNextHeader && len(items) < perPage {
+			break
+		}
+		if len(items) == 0 {
+			break
+		}
+		page++
+	}
+
+	return allItems, nil
+}
+
+// fetchPage performs a single authenticated list request
+func fetchPage(ctx context.Context, client *http.Client, pageURL, accessToken string) ([]map[string]interface{}, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", accessToken)
+
+	resp, err := DoWithRetry(client, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, fmt.Errorf("%w: API request failed with status %d: %s", ErrNotFound, resp.StatusCode, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	return items, resp.Header, nil
+}
+
+// nextLink returns the rel="next" URL of a Link header, or "" if there is none
+func nextLink(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, found := strings.Cut(part, ";")
+		if !found {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
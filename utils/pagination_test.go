@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginateFollowsNextPage(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		pages = append(pages, query.Get("page"))
+		if got := query.Get("per_page"); got != "2" {
+			t.Errorf("per_page = %q, want 2", got)
+		}
+		if got := query.Get("search"); got != "db" {
+			t.Errorf("search = %q, want the query of the original URL kept", got)
+		}
+		switch query.Get("page") {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id":1},{"id":2}]`)
+		default:
+			w.Header().Set("X-Next-Page", "")
+			fmt.Fprint(w, `[{"id":3}]`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	items, err := Paginate(server.Client(), server.URL+"/api/v4/projects?search=db&page=5", "secret", 2)
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3", len(items))
+	}
+	for i, item := range items {
+		if id := item["id"].(float64); id != float64(i+1) {
+			t.Errorf("items[%d] id = %v, want %d", i, id, i+1)
+		}
+	}
+	if fmt.Sprint(pages) != "[1 2]" {
+		t.Errorf("requested pages %v, want [1 2]", pages)
+	}
+}
+
+func TestPaginateWithoutNextPageHeader(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("page") == "1" {
+			fmt.Fprint(w, `[{"id":1},{"id":2}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id":3}]`)
+	}))
+	t.Cleanup(server.Close)
+
+	items, err := Paginate(server.Client(), server.URL+"/api/v4/groups", "secret", 2)
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if len(items) != 3 || requests != 2 {
+		t.Errorf("got %d items in %d requests, want 3 in 2: a short page ends the list", len(items), requests)
+	}
+}
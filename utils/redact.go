@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redactedPassword replaces secrets in printed output
+const redactedPassword = "****"
+
+// revealedSecretSuffix is how many trailing characters RedactSecret keeps, so
+// tokens can be told apart; secrets shorter than minRevealedSecretLength are
+// masked completely since the suffix would give away too much of them
+const (
+	revealedSecretSuffix    = 4
+	minRevealedSecretLength = 12
+)
+
+// RedactSecret returns a placeholder for a non-empty secret such as an access
+// token, so printed output shows whether it is set without revealing it. The
+// last 4 characters of long secrets are kept, e.g. ****x7Qz.
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) < minRevealedSecretLength {
+		return redactedPassword
+	}
+	return redactedPassword + secret[len(secret)-revealedSecretSuffix:]
+}
+
+// RedactURL returns rawURL with the password of any embedded credentials replaced,
+// so mirror URLs can be printed safely. The username is kept for context.
+func RedactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		// Fall back to hiding everything between the scheme and the host
+		if at := strings.LastIndex(rawURL, "@"); at != -1 {
+			if scheme := strings.Index(rawURL, "://"); scheme != -1 && scheme < at {
+				return rawURL[:scheme+3] + redactedPassword + rawURL[at:]
+			}
+		}
+		return rawURL
+	}
+	if parsed.User == nil {
+		return rawURL
+	}
+	if _, hasPassword := parsed.User.Password(); !hasPassword {
+		return rawURL
+	}
+
+	// url.UserPassword would escape the asterisks, so the user info is built by hand
+	userInfo := url.User(parsed.User.Username()).String() + ":" + redactedPassword + "@"
+	parsed.User = nil
+	redacted := parsed.String()
+	if scheme := strings.Index(redacted, "://"); scheme != -1 {
+		return redacted[:scheme+3] + userInfo + redacted[scheme+3:]
+	}
+	return redacted
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaintenanceRetries is how many times a request is retried while GitLab is under maintenance
+	MaintenanceRetries = 5
+	// MaintenanceBaseDelay is the longest wait before the first maintenance retry;
+	// it doubles on every attempt and the actual wait is jittered below it
+	MaintenanceBaseDelay = 15 * time.Second
+	// MaintenanceMaxDelay caps the wait between maintenance retries
+	MaintenanceMaxDelay = 2 * time.Minute
+)
+
+// ErrMaintenance is returned when GitLab keeps answering with its maintenance page
+var ErrMaintenance = errors.New("GitLab instance is under maintenance")
+
+// ErrUnauthorized is returned when GitLab rejects the access token with a 401,
+// which mid-run means it expired or was revoked. It is never retried.
+var ErrUnauthorized = errors.New("access token expired or revoked")
+
+// MaintenanceBackoff is the wait between maintenance retries
+var MaintenanceBackoff = Backoff{Base: MaintenanceBaseDelay, Max: MaintenanceMaxDelay}
+
+// RateLimitRetries is how many times a request answered with 429 Too Many
+// Requests is retried before the 429 response is returned to the caller
+var RateLimitRetries = 3
+
+// RateLimitBackoff is the wait between rate limit retries when GitLab doesn't
+// say how long to wait with a Retry-After header
+var RateLimitBackoff = Backoff{Base: 2 * time.Second, Max: 30 * time.Second}
+
+// DoWithRetry sends req like client.Do, but treats a 503 response that isn't
+// JSON (GitLab's HTML maintenance page, e.g. during upgrades) as transient and
+// retries with a growing, jittered delay. Requests whose body can't be replayed are not
+// retried. When the instance stays in maintenance, ErrMaintenance is returned
+// instead of the HTML response so callers never try to decode it. A 401 is
+// returned as ErrUnauthorized so long runs can stop instead of failing every
+// remaining request the same way. A 429 is retried up to RateLimitRetries
+// times after the wait GitLab asks for in Retry-After, or RateLimitBackoff
+// when it doesn't.
+func DoWithRetry(client *http.Client, req *http.Request) (*http.Response, error) {
+	maintenance, rateLimited := 0, 0
+	for {
+		resp, err := client.Do(req)
+		if err != nil {
+			Debugf("%s %s: %v", req.Method, req.URL.Redacted(), err)
+			return nil, err
+		}
+		Debugf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+		if resp.StatusCode == http.StatusUnauthorized {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: %s returned 401 Unauthorized", ErrUnauthorized, req.URL.Host)
+		}
+
+		replayable := req.Body == nil || req.GetBody != nil
+		var delay time.Duration
+		switch {
+		case isMaintenanceResponse(resp):
+			// Drain the maintenance page so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			maintenance++
+			if maintenance > MaintenanceRetries {
+				return nil, fmt.Errorf("%w: %s still returned 503 after %d attempts", ErrMaintenance, req.URL.Host, maintenance)
+			}
+			if !replayable {
+				return nil, fmt.Errorf("%w: %s returned 503 and the request can't be retried", ErrMaintenance, req.URL.Host)
+			}
+
+			delay = MaintenanceBackoff.Delay(maintenance).Round(time.Millisecond)
+			Infof("GitLab at %s is under maintenance (503), retrying in %s (retry %d/%d)", req.URL.Host, delay, maintenance, MaintenanceRetries)
+		case resp.StatusCode == http.StatusTooManyRequests && rateLimited < RateLimitRetries && replayable:
+			rateLimited++
+			var ok bool
+			if delay, ok = RetryAfter(resp.Header.Get("Retry-After"), time.Now()); !ok {
+				delay = RateLimitBackoff.Delay(rateLimited).Round(time.Millisecond)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			Infof("GitLab at %s is rate limiting requests (429), retrying in %s (retry %d/%d)", req.URL.Host, delay, rateLimited, RateLimitRetries)
+		default:
+			return resp, nil
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("error replaying request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// RetryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date, into the wait from now. ok is false when the header is missing
+// or invalid.
+func RetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// isMaintenanceResponse reports whether resp is a 503 without a JSON body
+func isMaintenanceResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err != nil || mediaType != "application/json"
+}
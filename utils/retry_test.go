@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDoWithRetryRateLimitThenSuccess(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader(`{"key":"A"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := DoWithRetry(server.Client(), req)
+	if err != nil {
+		t.Fatalf("DoWithRetry: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(bodies))
+	}
+	if bodies[1] != `{"key":"A"}` {
+		t.Errorf("retried body = %q, want the original body replayed", bodies[1])
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := RetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("RetryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDoWithRetryMaintenancePage(t *testing.T) {
+	MaintenanceBackoff = Backoff{Base: time.Millisecond, Max: time.Millisecond}
+	t.Cleanup(func() { MaintenanceBackoff = Backoff{Base: MaintenanceBaseDelay, Max: MaintenanceMaxDelay} })
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		wantStatus   int
+		wantRequests int
+	}{
+		// GitLab's HTML maintenance page is retried until the instance is back
+		{"html", "text/html; charset=utf-8", "<html>GitLab is down for maintenance</html>", http.StatusOK, 2},
+		// A JSON 503 comes from the API itself and is returned to the caller
+		{"json", "application/json", `{"message":"503 Service Unavailable"}`, http.StatusServiceUnavailable, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.Header().Set("Content-Type", tt.contentType)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					io.WriteString(w, tt.body)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(server.Close)
+
+			req, err := http.NewRequest("GET", server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := DoWithRetry(server.Client(), req)
+			if err != nil {
+				t.Fatalf("DoWithRetry: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
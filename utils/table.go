@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// defaultTerminalWidth is used when stdout is a terminal but $COLUMNS is unset
+	defaultTerminalWidth = 120
+	// minColumnWidth is the narrowest a column is shrunk to when fitting the terminal
+	minColumnWidth = 8
+	// columnPadding is the number of spaces between table columns
+	columnPadding = 2
+)
+
+// TableOptions controls how RenderTable lays out rows
+type TableOptions struct {
+	// Columns lists the fields to print, in order
+	Columns []string
+	// SortBy is the field rows are sorted by (empty keeps input order)
+	SortBy string
+	// MaxWidth is the total line width to fit into (0 disables truncation)
+	MaxWidth int
+}
+
+// ToRows converts decoded API data (a JSON array of objects) into table rows
+func ToRows(data interface{}) ([]map[string]interface{}, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rows: %w", err)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, fmt.Errorf("data is not a list of objects: %w", err)
+	}
+	return rows, nil
+}
+
+// ParseColumns splits a comma-separated column list, dropping empty entries
+func ParseColumns(value string) []string {
+	var columns []string
+	for _, column := range strings.Split(value, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// TerminalWidth returns the width table output should fit into.
+// It honors $COLUMNS, and returns 0 (no truncation) when stdout is not a terminal.
+func TerminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return 0
+	}
+	return defaultTerminalWidth
+}
+
+// RenderTable writes rows as an aligned table with a header line
+func RenderTable(w io.Writer, rows []map[string]interface{}, opts TableOptions) error {
+	if len(opts.Columns) == 0 {
+		return fmt.Errorf("no columns selected")
+	}
+
+	if opts.SortBy != "" {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return lessCell(rows[i][opts.SortBy], rows[j][opts.SortBy])
+		})
+	}
+
+	cells := make([][]string, 0, len(rows)+1)
+	header := make([]string, len(opts.Columns))
+	for i, column := range opts.Columns {
+		header[i] = strings.ToUpper(column)
+	}
+	cells = append(cells, header)
+	for _, row := range rows {
+		line := make([]string, len(opts.Columns))
+		for i, column := range opts.Columns {
+			line[i] = formatCell(row[column])
+		}
+		cells = append(cells, line)
+	}
+
+	widths := fitColumnWidths(cells, opts.MaxWidth)
+
+	tw := tabwriter.NewWriter(w, 0, 0, columnPadding, ' ', 0)
+	for _, line := range cells {
+		for i, cell := range line {
+			line[i] = truncateCell(cell, widths[i])
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(line, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// fitColumnWidths shrinks the widest columns until the table fits maxWidth
+func fitColumnWidths(cells [][]string, maxWidth int) []int {
+	widths := make([]int, len(cells[0]))
+	for _, line := range cells {
+		for i, cell := range line {
+			if n := len([]rune(cell)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if maxWidth <= 0 {
+		return widths
+	}
+
+	for {
+		total := columnPadding * (len(widths) - 1)
+		widest := 0
+		for i, width := range widths {
+			total += width
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= maxWidth || widths[widest] <= minColumnWidth {
+			return widths
+		}
+		widths[widest]--
+	}
+}
+
+// truncateCell shortens a cell to width, marking the cut with "..."
+func truncateCell(cell string, width int) string {
+	runes := []rune(cell)
+	if len(runes) <= width {
+		return cell
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// formatCell renders a decoded JSON value as a single-line string
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.ReplaceAll(v, "\n", `\n`)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(raw)
+	}
+}
+
+// lessCell orders numbers numerically and everything else by its string form
+func lessCell(a, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			return x < y
+		}
+	}
+	return strings.ToLower(formatCell(a)) < strings.ToLower(formatCell(b))
+}
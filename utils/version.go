@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// GitLabVersion is the parsed version reported by a GitLab instance's /version endpoint
+type GitLabVersion struct {
+	Major int
+	Minor int
+	Patch int
+	// Raw is the version string as reported, e.g. "16.5.1-ee"
+	Raw string
+}
+
+// ParseGitLabVersion parses versions such as "15.7.0", "16.5.1-ee" or "17.0.0-pre"
+func ParseGitLabVersion(version string) (*GitLabVersion, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "-+ "); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid GitLab version %q", version)
+	}
+
+	numbers := make([]int, 3)
+	for i := 0; i < len(parts) && i < 3; i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitLab version %q", version)
+		}
+		numbers[i] = n
+	}
+
+	return &GitLabVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Raw: version}, nil
+}
+
+// AtLeast reports whether the version is major.minor or newer
+func (v *GitLabVersion) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+// String returns the version as reported by the instance
+func (v *GitLabVersion) String() string {
+	return v.Raw
+}
+
+// SemanticVersion is a MAJOR.MINOR.PATCH version with an optional pre-release,
+// like the release tags of gitlab-migrate. Versions are ordered by the
+// precedence rules of Semantic Versioning 2.0.0, as golang.org/x/mod/semver
+// implements them.
+type SemanticVersion struct {
+	// Raw is the version string as given, e.g. "v1.2.0-rc.1"
+	Raw string
+	// canonical is the version in the form semver compares, "v1.2.0-rc.1"
+	canonical string
+}
+
+// ParseSemanticVersion parses versions such as "1.2.3", "v1.2.3",
+// "v2.0.0-rc.1" or "1.2.3+build.5". Build metadata is ignored, as it doesn't
+// affect precedence. Shorthands like "v1.2" that semver would accept are
+// rejected, release tags always have all three numbers.
+func ParseSemanticVersion(version string) (*SemanticVersion, error) {
+	prefixed := "v" + strings.TrimPrefix(strings.TrimSpace(version), "v")
+	canonical := semver.Canonical(prefixed)
+	if canonical == "" || canonical != strings.TrimSuffix(prefixed, semver.Build(prefixed)) {
+		return nil, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", version)
+	}
+	return &SemanticVersion{Raw: version, canonical: canonical}, nil
+}
+
+// Compare returns -1, 0 or 1 when v is older than, equal to or newer than
+// other. A pre-release is older than the release it precedes.
+func (v *SemanticVersion) Compare(other *SemanticVersion) int {
+	return semver.Compare(v.canonical, other.canonical)
+}
+
+// String returns the version as given
+func (v *SemanticVersion) String() string {
+	return v.Raw
+}
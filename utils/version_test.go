@@ -0,0 +1,57 @@
+package utils
+
+import "testing"
+
+func TestSemanticVersionCompare(t *testing.T) {
+	tests := []struct {
+		version, other string
+		// want is how version relates to other, or invalid when either
+		// can't be parsed
+		want string
+	}{
+		{"1.2.3", "1.2.3", "equal"},
+		{"v1.2.3", "1.2.3", "equal"},
+		{" v10.0.1 ", "10.0.1", "equal"},
+		{"1.0.0+build.1", "1.0.0+build.2", "equal"},
+		{"1.2.3", "1.2.4", "older"},
+		{"1.9.0", "1.10.0", "older"},
+		{"1.0.0-rc.1", "1.0.0", "older"},
+		{"1.0.0-alpha", "1.0.0-beta", "older"},
+		{"1.0.0-rc.2", "1.0.0-rc.10", "older"},
+		{"1.0.0-1", "1.0.0-alpha", "older"},
+		{"1.0.0-alpha", "1.0.0-alpha.1", "older"},
+		{"2.0.0", "1.99.99", "newer"},
+		{"1.0.0", "1.0.0-rc.1", "newer"},
+		{"1.2.3-beta+build.5", "1.2.3-alpha", "newer"},
+		{"", "1.0.0", "invalid"},
+		{"dev", "1.0.0", "invalid"},
+		{"1.2", "1.2.0", "invalid"},
+		{"1.2.3.4", "1.2.3", "invalid"},
+		{"v1.x.3", "1.0.0", "invalid"},
+		{"1.02.3", "1.2.3", "invalid"},
+		{"-1.2.3", "1.2.3", "invalid"},
+		{"1.2.3-", "1.2.3", "invalid"},
+		{"vv1.2.3", "1.2.3", "invalid"},
+	}
+	relation := map[int]string{-1: "older", 0: "equal", 1: "newer"}
+	for _, tt := range tests {
+		version, err := ParseSemanticVersion(tt.version)
+		other, otherErr := ParseSemanticVersion(tt.other)
+		if tt.want == "invalid" {
+			if err == nil && otherErr == nil {
+				t.Errorf("ParseSemanticVersion accepted %q and %q, want an error", tt.version, tt.other)
+			}
+			continue
+		}
+		if err != nil || otherErr != nil {
+			t.Errorf("ParseSemanticVersion(%q, %q): %v, %v", tt.version, tt.other, err, otherErr)
+			continue
+		}
+		if got := relation[version.Compare(other)]; got != tt.want {
+			t.Errorf("%q compared to %q is %s, want %s", tt.version, tt.other, got, tt.want)
+		}
+		if version.String() != tt.version {
+			t.Errorf("String() = %q, want %q", version.String(), tt.version)
+		}
+	}
+}